@@ -16,6 +16,10 @@ func printHelp() {
 	println("  / or Ctrl+F          Search (real-time incremental)")
 	println("  Shift+H              Find and replace")
 	println("  n/N                  Next/previous search result")
+	println("  * / #                Search forward/backward for word under cursor")
+	println("  g* / g#              Search for word under cursor (partial matches)")
+	println("  /foo/e /foo/+2       Search offsets (end of match, lines below)")
+	println("  Ctrl+R / Ctrl+W      Toggle regex / whole word (while searching)")
 	println("  c                    Copy current line (or selection)")
 	println("  x                    Cut selection (or delete character)")
 	println("  p                    Paste (or toggle preview for .md files)")
@@ -24,6 +28,16 @@ func printHelp() {
 	println("  r                    Redo")
 	println("  gg                   Jump to start of file")
 	println("  G                    Jump to end of file")
+	println("  za / zo / zc         Toggle/open/close fold under cursor")
+	println("  zR / zM              Open/close all folds")
+	println("  Ctrl+]               Jump to definition of word under cursor")
+	println("  Ctrl+T               Jump back from a definition")
+	println("  gs                   Fuzzy-find a symbol in the project")
+	println("  K                    Show documentation (language server)")
+	println("  gd / gr              Go to definition / list references")
+	println("  gcc / gc{motion}     Toggle comments on the line / lines moved over")
+	println("  ]d / [d              Next / previous diagnostic")
+	println("  ]c / [c              Next / previous unstaged git change")
 	println("  Ctrl+S               Save file")
 	println("  Ctrl+N/P             Next/previous pane")
 	println("  Esc                  Clear selection")
@@ -32,22 +46,77 @@ func printHelp() {
 	println("")
 	println("MOUSE SELECTION:")
 	println("  Click and drag       Select text (auto-scrolls at edges)")
+	println("  gc                   Toggle comments on the selected lines")
 	println("  c                    Copy selected text")
 	println("  x                    Cut selected text")
 	println("  Esc or movement      Clear selection")
 	println("")
 	println("COMMAND MODE:")
-	println("  :w                   Save file")
+	println("  :w                   Save file (formatted first)")
+	println("  :w!                  Save even if the formatter fails")
 	println("  :w filename          Save as filename")
 	println("  :q                   Quit")
 	println("  :q!                  Force quit without saving")
 	println("  :wq                  Save and quit")
+	println("  :format (:fmt)       Format the buffer")
+	println("  :lint                Lint the saved file")
+	println("  :hunk [stage|revert] Preview, stage or revert the git change here")
+	println("  :blame               Toggle the git blame column")
+	println("  :Glog                List the file's commits (Enter opens one)")
 	println("  :e filename          Edit new file (replace current pane)")
 	println("  :b filename          Open file in new pane")
 	println("  :db                  Close current pane")
 	println("  :f                   Toggle file browser sidebar")
 	println("  :set:show-hidden     Show hidden files in file browser")
 	println("  :set:hide-hidden     Hide hidden files in file browser")
+	println("  :set opt / noopt     Turn a boolean option on/off")
+	println("  :set opt=value       Set a number or string option")
+	println("  :set opt?            Show the current value of an option")
+	println("  :setlocal ...        Set an option for the current buffer only")
+	println("  :grep pat [path]     Search files under the browser root")
+	println("  :replace pat rep     Find and replace across files, with preview")
+	println("  :make[!] [args]      Run makeprg and list its errors (! doesn't jump)")
+	println("  :cn / :cp            Next/previous quickfix item")
+	println("  :copen / :cclose     Show/hide the quickfix list")
+	println("  :colo [name]         Switch color scheme (or show the current one)")
+	println("  :outline             Toggle the symbol outline sidebar")
+	println("  :tag name (:ta)      Jump to the definition of name")
+	println("  :symbols [q] (:sym)  Fuzzy-find a symbol in the project")
+	println("  :tags                Rebuild the symbol index (or reload tags file)")
+	println("  :rename name         Rename the symbol under the cursor (language server)")
+	println("  :lsp [restart|stop]  Show, restart or stop language servers")
+	println("")
+	println("OPTIONS:")
+	println("  tabstop (ts)         Width of a tab character")
+	println("  shiftwidth (sw)      Columns per indent level")
+	println("  wrap                 Wrap long lines")
+	println("  indentguides (ig)    Draw guides in leading whitespace")
+	println("  number (nu)          Show line numbers")
+	println("  ignorecase (ic)      Case-insensitive search")
+	println("  smartcase (scs)      Case-sensitive when query has uppercase")
+	println("  regex                Treat search queries as regular expressions")
+	println("  wholeword            Only match whole words")
+	println("  showhidden           Show hidden files in file browser")
+	println("  showignored          Show files git ignores in file browser")
+	println("  preservecase (pc)    Keep the case of replaced text")
+	println("  filetype (ft)        Language for syntax highlighting (detected)")
+	println("  foldmethod (fdm)     indent or bracket")
+	println("  autocomplete (ac)    Open the completion menu while typing")
+	println("  expandtab (et)       Indent with spaces instead of tabs")
+	println("  smartindent (si)     Indent new lines by the filetype's rules")
+	println("  autopairs (ap)       Close brackets and quotes as they are typed")
+	println("  formatonsave (fos)   Format the buffer when it is saved")
+	println("  lintonsave (los)     Lint the file when it is saved")
+	println("  makeprg (mp)         Build command for :make")
+	println("  errorformat (efm)    Error patterns for :make, e.g. %f:%l:%c: %m")
+	println("  gitgutter (gg)       Mark unstaged git changes in the gutter")
+	println("")
+	println("REPLACE MODE:")
+	println("  Ctrl+L / Ctrl+K      Toggle in-selection / case-preserving (while typing)")
+	println("  y/n                  Replace / skip this match")
+	println("  a                    Replace all remaining matches")
+	println("  l                    Replace this match and stop")
+	println("  q                    Quit replace")
 	println("")
 	println("MARKDOWN PREVIEW:")
 	println("  p                    Toggle preview (in .md files)")
@@ -56,5 +125,42 @@ func printHelp() {
 	println("FILE BROWSER:")
 	println("  :f                   Toggle file browser sidebar")
 	println("")
+	println("OUTLINE:")
+	println("  j/k or arrows        Move selection")
+	println("  Enter or click       Jump to the symbol (Space keeps focus)")
+	println("  Esc / q              Return to the editor / close the outline")
+	println("")
+	println("SYMBOL PICKER:")
+	println("  Type                 Filter by name and file")
+	println("  Up/Down, Ctrl+P/N    Move selection")
+	println("  Enter / Esc          Jump to the symbol / close")
+	println("")
+	println("COMPLETION (INSERT MODE):")
+	println("  Ctrl+N/P, Ctrl+Space Open the menu, or move through it")
+	println("  Tab / Enter          Insert the selected item")
+	println("  Ctrl+E               Close the menu")
+	println("")
+	println("SNIPPETS (INSERT MODE):")
+	println("  Tab                  Expand the snippet before the cursor, or go to the next tab stop")
+	println("  Shift+Tab            Go to the previous tab stop")
+	println("  ~/.config/vx/snippets/<filetype>.json, *.code-snippets (VS Code format)")
+	println("  :snippets reload     Read the snippet files again now")
+	println("")
+	println("LANGUAGE SERVERS:")
+	println("  gopls, rust-analyzer, pylsp, clangd, typescript-language-server when installed")
+	println("  ~/.config/vx/lsp.json Servers by filetype, e.g. {\"go\": {\"command\": [\"gopls\"]}}")
+	println("")
+	println("FORMATTERS:")
+	println("  gofmt, black, rustfmt, clang-format, prettier when installed")
+	println("  ~/.config/vx/formatters.json Formatters by filetype, e.g. {\"go\": {\"command\": [\"goimports\"]}}")
+	println("")
+	println("LINTERS:")
+	println("  go vet, shellcheck, flake8, eslint when installed")
+	println("  ~/.config/vx/linters.json Linters by filetype, e.g. {\"go\": {\"command\": [\"staticcheck\", \".\"]}}")
+	println("")
+	println("COLOR SCHEMES:")
+	println("  default, any Chroma style, or ~/.config/vx/themes/name.toml|json")
+	println("  VX_COLORSCHEME       Color scheme used at startup")
+	println("")
 	println("For more information, visit: https://github.com/Adelodunpeter25/vx")
 }
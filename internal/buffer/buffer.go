@@ -15,6 +15,9 @@ type Buffer struct {
 	undoStack  *undo.Stack
 	lazy       *utils.LazyFileReader
 	totalLines int
+	changes    []versionedChange
+	scratch    bool // holds output and is never saved
+	readOnly   bool // can't be edited
 }
 
 func New() *Buffer {
@@ -58,7 +61,7 @@ func (b *Buffer) LineRuneCount(n int) int {
 }
 
 func (b *Buffer) IsModified() bool {
-	return b.modified
+	return b.modified && !b.scratch
 }
 
 func (b *Buffer) Filename() string {
@@ -0,0 +1,41 @@
+package buffer
+
+// maxChanges is how many edits the change log keeps
+const maxChanges = 1024
+
+// Change describes one edit: line Line was modified and Delta lines were
+// inserted after it, or removed after it when Delta is negative
+type Change struct {
+	Line  int
+	Delta int
+}
+
+type versionedChange struct {
+	Change
+	version int // ModVersion after the edit
+}
+
+// recordChange logs an edit made before the next markModified
+func (b *Buffer) recordChange(line, delta int) {
+	if len(b.changes) >= maxChanges {
+		b.changes = append(b.changes[:0], b.changes[len(b.changes)-maxChanges/2:]...)
+	}
+	b.changes = append(b.changes, versionedChange{Change{Line: line, Delta: delta}, b.modVersion + 1})
+}
+
+// ChangesSince returns the edits made after version, oldest first. ok is
+// false when the log no longer reaches back that far.
+func (b *Buffer) ChangesSince(version int) (changes []Change, ok bool) {
+	if version == b.modVersion {
+		return nil, true
+	}
+	if version > b.modVersion || len(b.changes) == 0 || b.changes[0].version > version+1 {
+		return nil, false
+	}
+	for _, c := range b.changes {
+		if c.version > version {
+			changes = append(changes, c.Change)
+		}
+	}
+	return changes, true
+}
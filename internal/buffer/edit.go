@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"strings"
 	"unicode/utf8"
 
 	"github.com/Adelodunpeter25/vx/internal/undo"
@@ -25,6 +26,9 @@ func runeIndexToByteIndex(s string, col int) int {
 }
 
 func (b *Buffer) InsertRune(line, col int, r rune) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -48,10 +52,14 @@ func (b *Buffer) InsertRune(line, col int, r rune) {
 	})
 
 	b.lines[line] = lineStr[:byteCol] + string(r) + lineStr[byteCol:]
+	b.recordChange(line, 0)
 	b.markModified()
 }
 
 func (b *Buffer) DeleteRune(line, col int) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -79,10 +87,14 @@ func (b *Buffer) DeleteRune(line, col int) {
 	})
 
 	b.lines[line] = lineStr[:start] + lineStr[end:]
+	b.recordChange(line, 0)
 	b.markModified()
 }
 
 func (b *Buffer) InsertLine(line int) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -97,10 +109,14 @@ func (b *Buffer) InsertLine(line int) {
 	})
 
 	b.lines = append(b.lines[:line], append([]string{""}, b.lines[line:]...)...)
+	b.recordChange(line, 1)
 	b.markModified()
 }
 
 func (b *Buffer) DeleteLine(line int) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -116,10 +132,14 @@ func (b *Buffer) DeleteLine(line int) {
 	})
 
 	b.lines = append(b.lines[:line], b.lines[line+1:]...)
+	b.recordChange(line, -1)
 	b.markModified()
 }
 
 func (b *Buffer) SplitLine(line, col int) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -143,10 +163,14 @@ func (b *Buffer) SplitLine(line, col int) {
 
 	b.lines[line] = lineStr[:byteCol]
 	b.lines = append(b.lines[:line+1], append([]string{lineStr[byteCol:]}, b.lines[line+1:]...)...)
+	b.recordChange(line, 1)
 	b.markModified()
 }
 
 func (b *Buffer) JoinLine(line int) {
+	if b.readOnly {
+		return
+	}
 	if line < 0 {
 		return
 	}
@@ -163,9 +187,109 @@ func (b *Buffer) JoinLine(line int) {
 
 	b.lines[line] = b.lines[line] + b.lines[line+1]
 	b.lines = append(b.lines[:line+1], b.lines[line+2:]...)
+	b.recordChange(line, -1)
 	b.markModified()
 }
 
+// ReplaceText replaces length runes at line/col with text, which must not
+// contain newlines, as a single undoable action
+func (b *Buffer) ReplaceText(line, col, length int, text string) {
+	if b.readOnly {
+		return
+	}
+	b.ensureLineLoaded(line)
+	if line < 0 || line >= len(b.lines) {
+		return
+	}
+
+	lineStr := b.lines[line]
+	lineLen := runeCount(lineStr)
+	if col < 0 || col > lineLen {
+		return
+	}
+	if length < 0 {
+		length = 0
+	}
+	if col+length > lineLen {
+		length = lineLen - col
+	}
+	start := runeIndexToByteIndex(lineStr, col)
+	end := runeIndexToByteIndex(lineStr, col+length)
+	if lineStr[start:end] == text {
+		return
+	}
+
+	// Record undo action
+	b.undoStack.Push(undo.Action{
+		Type:    undo.ActionReplaceText,
+		Line:    line,
+		Col:     col,
+		Text:    text,
+		OldText: lineStr[start:end],
+	})
+
+	b.replaceText(line, col, length, text)
+	b.recordChange(line, 0)
+	b.markModified()
+}
+
+// ReplaceRange replaces the text from startLine/startCol up to endLine/endCol
+// with text, which may span lines, and returns where the new text ends. It
+// is made of several undo actions, so callers group it.
+func (b *Buffer) ReplaceRange(startLine, startCol, endLine, endCol int, text string) (line, col int) {
+	if b.readOnly || startLine < 0 || startLine >= b.LineCount() {
+		return startLine, startCol
+	}
+	if endLine >= b.LineCount() {
+		endLine = b.LineCount() - 1
+		endCol = runeCount(b.Line(endLine))
+	}
+	startCol = max(0, min(startCol, runeCount(b.Line(startLine))))
+	endCol = max(0, min(endCol, runeCount(b.Line(endLine))))
+	if endLine < startLine || (endLine == startLine && endCol < startCol) {
+		endLine, endCol = startLine, startCol
+	}
+	parts := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	// Remove the old text, joining what is left of its first and last lines
+	if endLine > startLine {
+		b.ReplaceText(endLine, 0, endCol, "")
+		b.ReplaceText(startLine, startCol, runeCount(b.Line(startLine))-startCol, "")
+		for l := endLine - 1; l > startLine; l-- {
+			b.DeleteLine(l)
+		}
+		b.JoinLine(startLine)
+		endCol = startCol
+	}
+	if len(parts) == 1 {
+		b.ReplaceText(startLine, startCol, endCol-startCol, parts[0])
+		return startLine, startCol + runeCount(parts[0])
+	}
+
+	b.ReplaceText(startLine, startCol, endCol-startCol, "")
+	b.SplitLine(startLine, startCol)
+	b.ReplaceText(startLine, startCol, 0, parts[0])
+	for i := 1; i < len(parts); i++ {
+		if i < len(parts)-1 {
+			b.InsertLine(startLine + i)
+		}
+		b.ReplaceText(startLine+i, 0, 0, parts[i])
+	}
+	last := len(parts) - 1
+	return startLine + last, runeCount(parts[last])
+}
+
+// replaceText swaps length runes at line/col for text without recording undo
+func (b *Buffer) replaceText(line, col, length int, text string) {
+	if line < 0 || line >= len(b.lines) {
+		return
+	}
+	lineStr := b.lines[line]
+	start := runeIndexToByteIndex(lineStr, col)
+	end := runeIndexToByteIndex(lineStr, col+length)
+	b.lines[line] = lineStr[:start] + text + lineStr[end:]
+}
+
 // Undo operations (without recording to undo stack)
 func (b *Buffer) undoInsertRune(line, col int) {
 	if line < 0 || line >= len(b.lines) {
@@ -235,11 +359,19 @@ func (b *Buffer) undoJoinLine(line int, text string) {
 
 // Undo performs an undo operation
 func (b *Buffer) Undo() bool {
-	action := b.undoStack.Undo()
-	if action == nil {
+	actions := b.undoStack.UndoGroup()
+	if len(actions) == 0 {
 		return false
 	}
+	for _, action := range actions {
+		b.undoAction(action)
+	}
+	b.markModified()
+	return true
+}
 
+func (b *Buffer) undoAction(action undo.Action) {
+	b.recordChange(action.Line, undoDelta(action.Type))
 	switch action.Type {
 	case undo.ActionInsertRune:
 		b.undoInsertRune(action.Line, action.Col)
@@ -253,19 +385,26 @@ func (b *Buffer) Undo() bool {
 		b.undoSplitLine(action.Line, action.Col)
 	case undo.ActionJoinLine:
 		b.undoJoinLine(action.Line, action.OldText)
+	case undo.ActionReplaceText:
+		b.replaceText(action.Line, action.Col, runeCount(action.Text), action.OldText)
 	}
-
-	b.markModified()
-	return true
 }
 
 // Redo performs a redo operation
 func (b *Buffer) Redo() bool {
-	action := b.undoStack.Redo()
-	if action == nil {
+	actions := b.undoStack.RedoGroup()
+	if len(actions) == 0 {
 		return false
 	}
+	for _, action := range actions {
+		b.redoAction(action)
+	}
+	b.markModified()
+	return true
+}
 
+func (b *Buffer) redoAction(action undo.Action) {
+	b.recordChange(action.Line, -undoDelta(action.Type))
 	switch action.Type {
 	case undo.ActionInsertRune:
 		lineStr := b.lines[action.Line]
@@ -290,8 +429,18 @@ func (b *Buffer) Redo() bool {
 	case undo.ActionJoinLine:
 		b.lines[action.Line] = b.lines[action.Line] + b.lines[action.Line+1]
 		b.lines = append(b.lines[:action.Line+1], b.lines[action.Line+2:]...)
+	case undo.ActionReplaceText:
+		b.replaceText(action.Line, action.Col, runeCount(action.OldText), action.Text)
 	}
+}
 
-	b.markModified()
-	return true
+// undoDelta is the change in line count from undoing an action of type t
+func undoDelta(t undo.ActionType) int {
+	switch t {
+	case undo.ActionInsertLine, undo.ActionSplitLine:
+		return -1
+	case undo.ActionDeleteLine, undo.ActionJoinLine:
+		return 1
+	}
+	return 0
 }
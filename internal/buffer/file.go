@@ -108,6 +108,9 @@ func (b *Buffer) Save() error {
 	if b.filename == "" {
 		return fmt.Errorf("no filename set")
 	}
+	if b.scratch {
+		return fmt.Errorf("%s is a scratch buffer", b.filename)
+	}
 	b.ensureAllLoaded()
 
 	file, err := os.Create(b.filename)
@@ -0,0 +1,50 @@
+package buffer
+
+// NewScratch creates a buffer for output such as that of :make. It shows
+// name in place of a file name, can't be saved and never counts as
+// modified, so it can be closed without a prompt.
+func NewScratch(name string) *Buffer {
+	b := New()
+	b.filename = name
+	b.scratch = true
+	return b
+}
+
+// NewReadOnly creates a scratch buffer holding lines, such as a file at an
+// older revision, that edits leave as it is
+func NewReadOnly(name string, lines []string) *Buffer {
+	b := NewScratch(name)
+	b.AppendLines(lines...)
+	b.readOnly = true
+	return b
+}
+
+// IsReadOnly reports whether the buffer was made by NewReadOnly
+func (b *Buffer) IsReadOnly() bool {
+	return b.readOnly
+}
+
+// IsScratch reports whether the buffer was made by NewScratch
+func (b *Buffer) IsScratch() bool {
+	return b.scratch
+}
+
+// AppendLines adds lines to the end of the buffer without recording them
+// for undo. The first lines appended to an empty buffer replace its empty
+// line.
+func (b *Buffer) AppendLines(lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	b.ensureAllLoaded()
+	if len(b.lines) == 1 && b.lines[0] == "" {
+		b.lines = append(b.lines[:0], lines...)
+		b.recordChange(0, len(lines)-1)
+	} else {
+		last := len(b.lines) - 1
+		b.lines = append(b.lines, lines...)
+		b.recordChange(last, len(lines))
+	}
+	b.totalLines = len(b.lines)
+	b.markModified()
+}
@@ -0,0 +1,78 @@
+package buffer
+
+import (
+	"bufio"
+	"context"
+	"os"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// Snapshot is a read-only copy of the buffer's lines that can be used from
+// another goroutine. Lines of a lazily loaded file that are not in memory yet
+// are streamed from disk by ReadRest instead of being loaded into the buffer.
+type Snapshot struct {
+	lines    []string
+	filename string
+	fileSkip int
+	pending  bool
+}
+
+// Snapshot captures the current contents of the buffer
+func (b *Buffer) Snapshot() *Snapshot {
+	lines := make([]string, len(b.lines))
+	copy(lines, b.lines)
+	snap := &Snapshot{
+		lines:    lines,
+		filename: b.filename,
+	}
+	if b.lazy != nil && !b.lazy.IsFullyLoaded() {
+		snap.fileSkip = b.lazy.LoadedCount()
+		snap.pending = true
+	}
+	return snap
+}
+
+// LineCount returns the number of lines held in memory
+func (s *Snapshot) LineCount() int {
+	return len(s.lines)
+}
+
+// Line returns an in-memory line
+func (s *Snapshot) Line(n int) string {
+	if n < 0 || n >= len(s.lines) {
+		return ""
+	}
+	return s.lines[n]
+}
+
+// ReadRest streams the lines that were not loaded when the snapshot was
+// taken. fn receives buffer line numbers and can return false to stop.
+func (s *Snapshot) ReadRest(ctx context.Context, fn func(lineNum int, line string) bool) error {
+	if !s.pending {
+		return nil
+	}
+	file, err := os.Open(s.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	fileLine := 0
+	lineNum := len(s.lines)
+	for scanner.Scan() {
+		if fileLine < s.fileSkip {
+			fileLine++
+			continue
+		}
+		if lineNum%1000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !fn(lineNum, utils.ValidateUTF8(scanner.Text())) {
+			return nil
+		}
+		lineNum++
+	}
+	return scanner.Err()
+}
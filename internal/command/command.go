@@ -9,16 +9,52 @@ import (
 )
 
 type Result struct {
-	Quit         bool
-	Message      string
-	Error        error
-	NewBuffer    *buffer.Buffer
-	SwitchFile   bool
-	AddBuffer    bool
-	DeleteBuffer bool
-	ToggleFiles  bool
-	ShowHidden   bool
-	HideHidden   bool
+	Quit            bool
+	Message         string
+	Error           error
+	NewBuffer       *buffer.Buffer
+	SwitchFile      bool
+	AddBuffer       bool
+	DeleteBuffer    bool
+	ToggleFiles     bool
+	ShowHidden      bool
+	HideHidden      bool
+	Set             bool
+	SetLocal        bool
+	SetArgs         string
+	Grep            bool
+	GrepPattern     string
+	GrepPath        string
+	ReplaceWith     string
+	ProjectReplace  bool
+	QuickfixNext    bool
+	QuickfixPrev    bool
+	QuickfixOpen    bool
+	QuickfixClose   bool
+	ColorScheme     bool
+	ColorSchemeName string
+	ToggleOutline   bool
+	JumpTag         bool
+	TagName         string
+	SymbolPicker    bool
+	SymbolQuery     string
+	Reindex         bool
+	Rename          bool
+	NewName         string
+	LSP             bool
+	LSPArg          string
+	Write           bool
+	Force           bool
+	Format          bool
+	Lint            bool
+	Make            bool
+	MakeArgs        string
+	Hunk            bool
+	HunkArg         string
+	Blame           bool
+	GitLog          bool
+	Snippets        bool
+	SnippetsArg     string
 }
 
 func Execute(cmd string, buf *buffer.Buffer) Result {
@@ -34,31 +70,29 @@ func Execute(cmd string, buf *buffer.Buffer) Result {
 	case "q!":
 		return Result{Quit: true}
 	
-	case "w":
+	case "w", "w!":
 		if buf.Filename() == "" {
 			return Result{Error: fmt.Errorf("no file name")}
 		}
-		
-		if err := buf.Save(); err != nil {
-			return Result{Error: err}
-		}
-		
-		size, _ := buf.GetFileSize()
-		msg := utils.FormatFileInfo(buf.Filename(), size, buf.LineCount())
-		return Result{Message: msg}
+		return Result{Write: true, Force: cmd == "w!"}
 	
-	case "wq":
+	case "wq", "wq!":
 		if buf.Filename() == "" {
 			return Result{Error: fmt.Errorf("no file name")}
 		}
-		
-		if err := buf.Save(); err != nil {
-			return Result{Error: err}
-		}
-		
-		size, _ := buf.GetFileSize()
-		msg := utils.FormatFileInfo(buf.Filename(), size, buf.LineCount())
-		return Result{Quit: true, Message: msg}
+		return Result{Write: true, Force: cmd == "wq!", Quit: true}
+	
+	case "format", "fmt":
+		return Result{Format: true}
+	
+	case "lint":
+		return Result{Lint: true}
+	
+	case "blame":
+		return Result{Blame: true}
+	
+	case "Glog", "glog":
+		return Result{GitLog: true}
 	
 	default:
 		if cmd == "f" {
@@ -71,6 +105,88 @@ func Execute(cmd string, buf *buffer.Buffer) Result {
 			return Result{HideHidden: true}
 		}
 
+		if cmd == "set" || strings.HasPrefix(cmd, "set ") {
+			return Result{Set: true, SetArgs: strings.TrimSpace(cmd[3:])}
+		}
+		if cmd == "setlocal" || strings.HasPrefix(cmd, "setlocal ") {
+			return Result{Set: true, SetLocal: true, SetArgs: strings.TrimSpace(cmd[8:])}
+		}
+		if cmd == "setl" || strings.HasPrefix(cmd, "setl ") {
+			return Result{Set: true, SetLocal: true, SetArgs: strings.TrimSpace(cmd[4:])}
+		}
+
+		if cmd == "grep" || strings.HasPrefix(cmd, "grep ") {
+			pattern, path, err := nextArg(cmd[4:])
+			if err != nil {
+				return Result{Error: err}
+			}
+			if pattern == "" {
+				return Result{Error: fmt.Errorf("no pattern given")}
+			}
+			return Result{Grep: true, GrepPattern: pattern, GrepPath: path}
+		}
+		if cmd == "replace" || strings.HasPrefix(cmd, "replace ") {
+			pattern, rest, err := nextArg(cmd[7:])
+			if err == nil && pattern == "" {
+				err = fmt.Errorf("no pattern given")
+			}
+			if err != nil {
+				return Result{Error: err}
+			}
+			with, path, err := nextArg(rest)
+			if err != nil {
+				return Result{Error: err}
+			}
+			return Result{ProjectReplace: true, GrepPattern: pattern, ReplaceWith: with, GrepPath: path}
+		}
+		if name, ok := commandArg(cmd, "colorscheme", "colo"); ok {
+			return Result{ColorScheme: true, ColorSchemeName: name}
+		}
+		if name, ok := commandArg(cmd, "tag", "ta"); ok {
+			if name == "" {
+				return Result{Error: fmt.Errorf("no tag name given")}
+			}
+			return Result{JumpTag: true, TagName: name}
+		}
+		if query, ok := commandArg(cmd, "symbols", "sym"); ok {
+			return Result{SymbolPicker: true, SymbolQuery: query}
+		}
+		if name, ok := commandArg(cmd, "rename"); ok {
+			if name == "" {
+				return Result{Error: fmt.Errorf("no new name given")}
+			}
+			return Result{Rename: true, NewName: name}
+		}
+		if args, ok := commandArg(cmd, "make"); ok {
+			return Result{Make: true, MakeArgs: args}
+		}
+		if args, ok := commandArg(cmd, "make!"); ok {
+			return Result{Make: true, MakeArgs: args, Force: true}
+		}
+		if arg, ok := commandArg(cmd, "lsp"); ok {
+			return Result{LSP: true, LSPArg: arg}
+		}
+		if arg, ok := commandArg(cmd, "hunk"); ok {
+			return Result{Hunk: true, HunkArg: arg}
+		}
+		if arg, ok := commandArg(cmd, "snippets"); ok {
+			return Result{Snippets: true, SnippetsArg: arg}
+		}
+		switch cmd {
+		case "cn", "cnext":
+			return Result{QuickfixNext: true}
+		case "cp", "cprev", "cprevious", "cN", "cNext":
+			return Result{QuickfixPrev: true}
+		case "copen", "cope":
+			return Result{QuickfixOpen: true}
+		case "cclose", "ccl":
+			return Result{QuickfixClose: true}
+		case "outline":
+			return Result{ToggleOutline: true}
+		case "tags":
+			return Result{Reindex: true}
+		}
+
 		if cmd == "db" {
 			return Result{DeleteBuffer: true}
 		}
@@ -120,13 +236,7 @@ func Execute(cmd string, buf *buffer.Buffer) Result {
 				return Result{Error: fmt.Errorf("no file name")}
 			}
 			buf.SetFilename(filename)
-			if err := buf.Save(); err != nil {
-				return Result{Error: err}
-			}
-			
-			size, _ := buf.GetFileSize()
-			msg := utils.FormatFileInfo(filename, size, buf.LineCount())
-			return Result{Message: msg}
+			return Result{Write: true}
 		}
 		
 		if strings.HasPrefix(cmd, "wq ") {
@@ -135,15 +245,54 @@ func Execute(cmd string, buf *buffer.Buffer) Result {
 				return Result{Error: fmt.Errorf("no file name")}
 			}
 			buf.SetFilename(filename)
-			if err := buf.Save(); err != nil {
-				return Result{Error: err}
-			}
-			
-			size, _ := buf.GetFileSize()
-			msg := utils.FormatFileInfo(filename, size, buf.LineCount())
-			return Result{Quit: true, Message: msg}
+			return Result{Write: true, Quit: true}
 		}
 		
 		return Result{Error: fmt.Errorf("not an editor command: :%s", cmd)}
 	}
 }
+
+// commandArg reports whether cmd is one of names, alone or followed by an
+// argument, and returns the argument
+func commandArg(cmd string, names ...string) (string, bool) {
+	for _, name := range names {
+		if cmd == name {
+			return "", true
+		}
+		if strings.HasPrefix(cmd, name+" ") {
+			return strings.TrimSpace(cmd[len(name):]), true
+		}
+	}
+	return "", false
+}
+
+// nextArg splits the first argument off args. It may be quoted with single
+// or double quotes to include spaces.
+func nextArg(args string) (string, string, error) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return "", "", nil
+	}
+	quote := args[0]
+	if quote != '"' && quote != '\'' {
+		if i := strings.IndexAny(args, " \t"); i >= 0 {
+			return args[:i], strings.TrimSpace(args[i:]), nil
+		}
+		return args, "", nil
+	}
+
+	var arg strings.Builder
+	for i := 1; i < len(args); i++ {
+		c := args[i]
+		if c == '\\' && i+1 < len(args) && args[i+1] == quote {
+			arg.WriteByte(quote)
+			i++
+			continue
+		}
+		if c == quote {
+			return arg.String(), strings.TrimSpace(args[i+1:]), nil
+		}
+		arg.WriteByte(c)
+	}
+	return "", "", fmt.Errorf("missing closing quote")
+}
@@ -0,0 +1,125 @@
+// Package comment comments lines out and back in with the comment markers
+// of their language
+package comment
+
+import "strings"
+
+// Markers are how a filetype writes comments: a line comment, or a block
+// comment put around each line for languages without one
+type Markers struct {
+	Line       string
+	Start, End string
+}
+
+var byFiletype = map[string]Markers{}
+
+func init() {
+	add := func(m Markers, filetypes ...string) {
+		for _, ft := range filetypes {
+			byFiletype[ft] = m
+		}
+	}
+	add(Markers{Line: "//"}, "go", "c", "c++", "c#", "java", "javascript", "typescript", "react",
+		"rust", "swift", "kotlin", "scala", "dart", "php", "zig", "groovy", "protobuf",
+		"objective-c", "d", "v", "odin", "gleam", "json", "scss", "sass", "systemverilog",
+		"graphql", "typst", "glsl", "hlsl", "solidity", "verilog")
+	add(Markers{Line: "#"}, "python", "cython", "bash", "fish", "ruby", "perl", "r", "yaml",
+		"toml", "makefile", "docker", "cmake", "nim", "elixir", "powershell", "nginx",
+		"terraform", "hcl", "julia", "crystal", "coffeescript", "gdscript", "tcl", "awk",
+		"nix", "java-properties", "gitignore", "starlark", "meson")
+	add(Markers{Line: "--"}, "lua", "sql", "haskell", "elm", "ada", "vhdl", "plpgsql", "mysql")
+	add(Markers{Line: ";"}, "common-lisp", "clojure", "scheme", "emacs", "racket", "ini", "nasm")
+	add(Markers{Line: "%"}, "tex", "erlang", "prolog", "matlab", "bibtex")
+	add(Markers{Line: "\""}, "vim")
+	add(Markers{Line: "!"}, "fortran")
+	add(Markers{Line: "'"}, "vb.net")
+	add(Markers{Line: "REM"}, "bat")
+	add(Markers{Start: "/*", End: "*/"}, "css")
+	add(Markers{Start: "<!--", End: "-->"}, "html", "xml", "md", "markdown", "vue", "svelte", "handlebars")
+	add(Markers{Start: "(*", End: "*)"}, "ocaml", "objectpascal", "mathematica")
+	add(Markers{Start: "{#", End: "#}"}, "twig")
+}
+
+// For returns the comment markers of a filetype, or false if none are known
+func For(filetype string) (Markers, bool) {
+	m, ok := byFiletype[filetype]
+	return m, ok
+}
+
+// Toggle comments lines out, or back in when every line but blank ones is
+// already a comment. Markers go at the smallest indentation of the lines,
+// so a block stays aligned; a block of commented and uncommented lines is
+// commented as a whole, and toggling it again gives it back as it was.
+func Toggle(lines []string, m Markers) []string {
+	out := make([]string, len(lines))
+	if m.Line == "" && m.Start == "" {
+		copy(out, lines)
+		return out
+	}
+
+	commented := true
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !m.isComment(line) {
+			commented = false
+		}
+		if n := len(leading(line)); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 0 {
+		// Only blank lines
+		copy(out, lines)
+		return out
+	}
+
+	for i, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "":
+			out[i] = line
+		case commented:
+			out[i] = m.uncomment(line)
+		default:
+			out[i] = m.comment(line, indent)
+		}
+	}
+	return out
+}
+
+func (m Markers) isComment(line string) bool {
+	text := strings.TrimSpace(line)
+	if m.Line != "" {
+		return strings.HasPrefix(text, m.Line)
+	}
+	return len(text) >= len(m.Start)+len(m.End) && strings.HasPrefix(text, m.Start) && strings.HasSuffix(text, m.End)
+}
+
+// comment puts the markers around line, after indent bytes of its indentation
+func (m Markers) comment(line string, indent int) string {
+	if m.Line != "" {
+		return line[:indent] + m.Line + " " + line[indent:]
+	}
+	return line[:indent] + m.Start + " " + strings.TrimRight(line[indent:], " \t") + " " + m.End
+}
+
+// uncomment removes the markers of a commented line, and the space next to
+// each one
+func (m Markers) uncomment(line string) string {
+	ws := leading(line)
+	text := line[len(ws):]
+	if m.Line != "" {
+		text = strings.TrimPrefix(text, m.Line)
+		return ws + strings.TrimPrefix(text, " ")
+	}
+	text = strings.TrimRight(text, " \t")
+	text = strings.TrimSuffix(strings.TrimPrefix(text, m.Start), m.End)
+	text = strings.TrimPrefix(text, " ")
+	return ws + strings.TrimSuffix(text, " ")
+}
+
+func leading(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
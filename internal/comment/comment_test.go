@@ -0,0 +1,69 @@
+package comment
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToggle(t *testing.T) {
+	slash := Markers{Line: "//"}
+	html := Markers{Start: "<!--", End: "-->"}
+	tests := []struct {
+		name    string
+		markers Markers
+		lines   []string
+		want    []string // after one toggle; a second gives lines back
+	}{
+		{"line", slash, []string{"x := 1"}, []string{"// x := 1"}},
+		{"keeps indent", slash, []string{"\tif x {", "\t\ty()", "\t}"}, []string{"\t// if x {", "\t// \ty()", "\t// }"}},
+		{"smallest indent", slash, []string{"    a", "  b"}, []string{"  //   a", "  // b"}},
+		{"mixed indent", slash, []string{"\ta", "  b"}, []string{"\t// a", " //  b"}},
+		{"blank lines kept", slash, []string{"a", "", "  ", "b"}, []string{"// a", "", "  ", "// b"}},
+		{"mixed block", slash, []string{"a", "// b", "c"}, []string{"// a", "// // b", "// c"}},
+		{"hash", Markers{Line: "#"}, []string{"  x = 1"}, []string{"  # x = 1"}},
+		{"block", html, []string{"<p>", "  text", "</p>"}, []string{"<!-- <p> -->", "<!--   text -->", "<!-- </p> -->"}},
+		{"block indented", html, []string{"  <br>"}, []string{"  <!-- <br> -->"}},
+		{"only blank", slash, []string{"", "  "}, []string{"", "  "}},
+		{"no markers", Markers{}, []string{"a"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			once := Toggle(tt.lines, tt.markers)
+			if !slices.Equal(once, tt.want) {
+				t.Errorf("Toggle = %q, want %q", once, tt.want)
+			}
+			if twice := Toggle(once, tt.markers); !slices.Equal(twice, tt.lines) {
+				t.Errorf("Toggle twice = %q, want %q", twice, tt.lines)
+			}
+		})
+	}
+}
+
+func TestToggleUncomments(t *testing.T) {
+	tests := []struct {
+		name    string
+		markers Markers
+		lines   []string
+		want    []string
+	}{
+		{"without space", Markers{Line: "//"}, []string{"//a", "  //b"}, []string{"a", "  b"}},
+		{"empty comment", Markers{Line: "//"}, []string{"// a", "//"}, []string{"a", ""}},
+		{"block without spaces", Markers{Start: "/*", End: "*/"}, []string{"/*a*/"}, []string{"a"}},
+		{"block trailing space", Markers{Start: "/*", End: "*/"}, []string{"/* a */  "}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Toggle(tt.lines, tt.markers); !slices.Equal(got, tt.want) {
+				t.Errorf("Toggle = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleDoesNotModifyInput(t *testing.T) {
+	lines := []string{"a", "b"}
+	Toggle(lines, Markers{Line: "//"})
+	if !slices.Equal(lines, []string{"a", "b"}) {
+		t.Errorf("input changed to %q", lines)
+	}
+}
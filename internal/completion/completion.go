@@ -0,0 +1,74 @@
+// Package completion finds insert-mode completions for the text before the
+// cursor. Providers offer items, possibly some time after being asked, and
+// a Menu ranks them against what has been typed since.
+package completion
+
+import (
+	"unicode"
+)
+
+// Item is one completion
+type Item struct {
+	Text   string // replaces the text from Start to the cursor
+	Label  string // shown in the menu; Text when empty
+	Kind   string // shown after the label, e.g. "word" or "function"
+	Filter string // matched against what was typed; Label when empty
+	Start  int    // rune column the item replaces from
+	Accept func() // inserts the item in place of Text when set
+}
+
+func (it *Item) label() string {
+	if it.Label != "" {
+		return it.Label
+	}
+	return it.Text
+}
+
+func (it *Item) filter() string {
+	if it.Filter != "" {
+		return it.Filter
+	}
+	return it.label()
+}
+
+// Request is where completions are wanted
+type Request struct {
+	Line   string // the cursor line
+	Col    int    // rune column of the cursor
+	Dir    string // directory of the buffer's file, for relative paths
+	Manual bool   // asked for with a key rather than while typing
+}
+
+// WordStart returns the column the word before the cursor starts at
+func (r Request) WordStart() int {
+	runes := []rune(r.Line)
+	start := min(r.Col, len(runes))
+	for start > 0 && IsWordRune(runes[start-1]) {
+		start--
+	}
+	return start
+}
+
+// Prefix returns the word before the cursor
+func (r Request) Prefix() string {
+	runes := []rune(r.Line)
+	return string(runes[r.WordStart():min(r.Col, len(runes))])
+}
+
+// Provider is a source of completions. It calls add with what it finds,
+// either before Complete returns or later on the editor's main loop.
+type Provider interface {
+	Complete(req Request, add func([]Item))
+}
+
+// ProviderFunc lets a function be used as a Provider
+type ProviderFunc func(req Request, add func([]Item))
+
+func (f ProviderFunc) Complete(req Request, add func([]Item)) {
+	f(req, add)
+}
+
+// IsWordRune reports whether r is part of a word
+func IsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,205 @@
+package completion
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/picker"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Menu size limits
+const (
+	MaxRows  = 10
+	maxWidth = 60
+)
+
+// Menu holds the completions offered at a point and ranks them against the
+// text typed since
+type Menu struct {
+	Line   int  // buffer line the menu was opened on
+	Manual bool // opened by a key rather than by typing
+
+	items    []Item
+	seen     map[string]int // index of each item by text and label
+	matches  []match
+	selected int
+	moved    bool // the selection was moved since the last Update
+	scroll   int
+	text     []rune // cursor line as last filtered
+	col      int
+}
+
+type match struct {
+	index     int
+	score     int
+	positions []int
+}
+
+func NewMenu(line int) *Menu {
+	return &Menu{Line: line, seen: make(map[string]int)}
+}
+
+// Add adds items and ranks them with the rest. An item already offered
+// is replaced, as later providers, such as language servers, know more
+// about it.
+func (m *Menu) Add(items []Item) {
+	for _, item := range items {
+		key := item.Text + "\x00" + item.label()
+		if i, ok := m.seen[key]; ok {
+			m.items[i] = item
+			continue
+		}
+		m.seen[key] = len(m.items)
+		m.items = append(m.items, item)
+	}
+	m.filter()
+}
+
+// Update ranks the items against the text between their start and the
+// cursor
+func (m *Menu) Update(line string, col int) {
+	m.text = []rune(line)
+	m.col = min(col, len(m.text))
+	m.moved = false
+	m.filter()
+}
+
+func (m *Menu) filter() {
+	selected := -1
+	if m.moved && m.selected < len(m.matches) {
+		selected = m.matches[m.selected].index
+	}
+	m.matches = m.matches[:0]
+	for i := range m.items {
+		item := &m.items[i]
+		if item.Start > m.col {
+			continue
+		}
+		typed := m.text[item.Start:m.col]
+		if string(typed) == item.Text && item.Accept == nil {
+			// Nothing left to complete
+			continue
+		}
+		if score, positions, ok := picker.Match(typed, []rune(item.filter())); ok {
+			m.matches = append(m.matches, match{index: i, score: score, positions: positions})
+		}
+	}
+	sort.SliceStable(m.matches, func(i, j int) bool {
+		a, b := m.matches[i], m.matches[j]
+		if a.score != b.score || a.score == 0 {
+			return a.score > b.score
+		}
+		return len(m.items[a.index].Text) < len(m.items[b.index].Text)
+	})
+
+	// A selection that was moved stays on its item while results arrive
+	m.selected = 0
+	for i, mt := range m.matches {
+		if mt.index == selected {
+			m.selected = i
+		}
+	}
+	m.reveal()
+}
+
+// Len returns the number of items matching what was typed
+func (m *Menu) Len() int {
+	return len(m.matches)
+}
+
+// Selected returns the highlighted item
+func (m *Menu) Selected() (Item, bool) {
+	if m.selected >= len(m.matches) {
+		return Item{}, false
+	}
+	return m.items[m.matches[m.selected].index], true
+}
+
+// Move moves the selection, wrapping around at either end
+func (m *Menu) Move(delta int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.selected = ((m.selected+delta)%len(m.matches) + len(m.matches)) % len(m.matches)
+	m.moved = true
+	m.reveal()
+}
+
+// Moved reports whether the selection was moved since the last Update
+func (m *Menu) Moved() bool {
+	return m.moved
+}
+
+func (m *Menu) reveal() {
+	if m.selected < m.scroll {
+		m.scroll = m.selected
+	}
+	if m.selected >= m.scroll+MaxRows {
+		m.scroll = m.selected - MaxRows + 1
+	}
+	m.scroll = max(min(m.scroll, len(m.matches)-MaxRows), 0)
+}
+
+// Start returns the column the selected item replaces from, which is where
+// the menu lines up with the text
+func (m *Menu) Start() int {
+	if item, ok := m.Selected(); ok {
+		return item.Start
+	}
+	return m.col
+}
+
+// Size returns the width and height the menu needs
+func (m *Menu) Size() (width, height int) {
+	for _, mt := range m.matches {
+		item := &m.items[mt.index]
+		w := len([]rune(item.label())) + 2
+		if item.Kind != "" {
+			w += len([]rune(item.Kind)) + 2
+		}
+		width = max(width, w)
+	}
+	return min(width, maxWidth), min(len(m.matches), MaxRows)
+}
+
+// Render draws the visible items in a box, with the matched runes
+// underlined, the kinds dimmed on the right and the selection reversed
+func (m *Menu) Render(term *terminal.Terminal, x, y, width, height int, style tcell.Style) {
+	if term == nil || width <= 0 {
+		return
+	}
+	for row := 0; row < height; row++ {
+		idx := m.scroll + row
+		if idx >= len(m.matches) {
+			break
+		}
+		mt := m.matches[idx]
+		item := &m.items[mt.index]
+		s := style
+		if idx == m.selected {
+			s = s.Reverse(true)
+		}
+		term.DrawText(x, y+row, strings.Repeat(" ", width), s)
+
+		// Matched runes are underlined when the label is what was matched
+		label := []rune(item.label())
+		underline := item.filter() == item.label()
+		pos := 0
+		for i, r := range label {
+			if 1+i >= width {
+				break
+			}
+			rs := s
+			if underline && pos < len(mt.positions) && mt.positions[pos] == i {
+				rs = rs.Bold(true).Underline(true)
+				pos++
+			}
+			term.SetCell(x+1+i, y+row, r, rs)
+		}
+		if kind := []rune(item.Kind); len(kind) > 0 && len(label)+len(kind)+3 <= width {
+			term.DrawText(x+width-len(kind)-1, y+row, string(kind), s.Dim(true))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package completion
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text is the contents of a buffer by line
+type Text interface {
+	LineCount() int
+	Line(n int) string
+}
+
+// minWordLen is the shortest word offered from buffers
+const minWordLen = 3
+
+// Words offers the words of buffers, those of the first text first
+type Words struct {
+	Texts func() []Text
+}
+
+func (w Words) Complete(req Request, add func([]Item)) {
+	start := req.WordStart()
+	prefix := req.Prefix()
+	if prefix == "" && !req.Manual {
+		return
+	}
+	// The word being typed is not offered, wherever else it occurs
+	runes := []rune(req.Line)
+	end := min(req.Col, len(runes))
+	for end < len(runes) && IsWordRune(runes[end]) {
+		end++
+	}
+	seen := map[string]bool{prefix: true, string(runes[start:end]): true}
+	var items []Item
+	for _, text := range w.Texts() {
+		for n := 0; n < text.LineCount(); n++ {
+			line := text.Line(n)
+			for i := 0; i < len(line); {
+				r, size := utf8.DecodeRuneInString(line[i:])
+				if !IsWordRune(r) {
+					i += size
+					continue
+				}
+				j := i
+				for j < len(line) {
+					r, size := utf8.DecodeRuneInString(line[j:])
+					if !IsWordRune(r) {
+						break
+					}
+					j += size
+				}
+				word := line[i:j]
+				i = j
+				if seen[word] || utf8.RuneCountInString(word) < minWordLen {
+					continue
+				}
+				seen[word] = true
+				items = append(items, Item{Text: word, Kind: "word", Start: start})
+			}
+		}
+	}
+	add(items)
+}
+
+// Paths offers the entries of the directory named before the cursor, such
+// as "./src/ma" or "~/.config/", relative to the buffer's directory
+type Paths struct{}
+
+func (Paths) Complete(req Request, add func([]Item)) {
+	runes := []rune(req.Line)
+	col := min(req.Col, len(runes))
+	start := col
+	for start > 0 && isPathRune(runes[start-1]) {
+		start--
+	}
+	token := string(runes[start:col])
+	slash := strings.LastIndex(token, "/")
+	if slash < 0 {
+		return
+	}
+	dir, base := token[:slash+1], token[slash+1:]
+	switch {
+	case strings.HasPrefix(dir, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		dir = filepath.Join(home, dir[2:])
+	case !filepath.IsAbs(dir):
+		if req.Dir == "" {
+			return
+		}
+		dir = filepath.Join(req.Dir, dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	baseStart := col - utf8.RuneCountInString(base)
+	items := make([]Item, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		item := Item{Text: name, Kind: "file", Start: baseStart}
+		if entry.IsDir() {
+			item.Text += "/"
+			item.Kind = "dir"
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		// Directories first
+		return items[i].Kind == "dir" && items[j].Kind != "dir"
+	})
+	add(items)
+}
+
+// isPathRune reports whether r can be part of a path typed in text
+func isPathRune(r rune) bool {
+	return !strings.ContainsRune(" \t\"'`()[]{}<>=,;:", r)
+}
@@ -0,0 +1,67 @@
+// Package diagnostic is the model of the problems language servers and
+// linters report in a buffer.
+package diagnostic
+
+import "sort"
+
+// Severities, most severe first, numbered as in the language server protocol
+const (
+	Error   = 1
+	Warning = 2
+	Info    = 3
+	Hint    = 4
+)
+
+// Diagnostic is a problem in a range of a buffer
+type Diagnostic struct {
+	Line, Col       int // start, 0-based, in runes
+	EndLine, EndCol int // end, exclusive
+	Severity        int
+	Source          string // the server or linter that reported it
+	Message         string
+}
+
+// Before reports whether d starts before the position line, col
+func (d Diagnostic) Before(line, col int) bool {
+	return d.Line < line || d.Line == line && d.Col < col
+}
+
+// After reports whether d starts after the position line, col
+func (d Diagnostic) After(line, col int) bool {
+	return d.Line > line || d.Line == line && d.Col > col
+}
+
+// Sort orders diagnostics by where they start
+func Sort(list []Diagnostic) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Before(list[j].Line, list[j].Col)
+	})
+}
+
+// Next returns the first diagnostic after the position, wrapping around to
+// the first one, from a sorted list
+func Next(list []Diagnostic, line, col int) (Diagnostic, bool) {
+	if len(list) == 0 {
+		return Diagnostic{}, false
+	}
+	for _, d := range list {
+		if d.After(line, col) {
+			return d, true
+		}
+	}
+	return list[0], true
+}
+
+// Prev returns the last diagnostic before the position, wrapping around to
+// the last one, from a sorted list
+func Prev(list []Diagnostic, line, col int) (Diagnostic, bool) {
+	if len(list) == 0 {
+		return Diagnostic{}, false
+	}
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].Before(line, col) {
+			return list[i], true
+		}
+	}
+	return list[len(list)-1], true
+}
@@ -0,0 +1,79 @@
+// Package diff works out the line edits between two versions of a text,
+// for formatters and the git gutter.
+package diff
+
+// maxDiffCells bounds the table Lines fills in; past it the changed middle
+// is replaced as a whole
+const maxDiffCells = 1 << 22
+
+// Edit replaces the old lines from Start up to End with Lines
+type Edit struct {
+	Start, End int
+	Lines      []string
+}
+
+// Lines returns the edits that turn the lines a into b, in order, leaving
+// the longest run of lines the two share in place
+func Lines(a, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(am) == 0 && len(bm) == 0 {
+		return nil
+	}
+	if len(am) == 0 || len(bm) == 0 || (len(am)+1)*(len(bm)+1) > maxDiffCells {
+		return []Edit{{Start: prefix, End: prefix + len(am), Lines: bm}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of am[i:]
+	// and bm[j:]
+	w := len(bm) + 1
+	lcs := make([]int32, (len(am)+1)*w)
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+
+	var edits []Edit
+	var cur *Edit
+	flush := func() {
+		if cur != nil {
+			edits = append(edits, *cur)
+			cur = nil
+		}
+	}
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			flush()
+			i++
+			j++
+		case j < len(bm) && (i == len(am) || lcs[i*w+j+1] >= lcs[(i+1)*w+j]):
+			if cur == nil {
+				cur = &Edit{Start: prefix + i, End: prefix + i}
+			}
+			cur.Lines = append(cur.Lines, bm[j])
+			j++
+		default:
+			if cur == nil {
+				cur = &Edit{Start: prefix + i, End: prefix + i}
+			}
+			cur.End++
+			i++
+		}
+	}
+	flush()
+	return edits
+}
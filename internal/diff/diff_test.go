@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// apply makes the edits to a, last first so the earlier ones stay in place
+func apply(a []string, edits []Edit) []string {
+	out := slices.Clone(a)
+	for i := len(edits) - 1; i >= 0; i-- {
+		ed := edits[i]
+		out = slices.Replace(out, ed.Start, ed.End, ed.Lines...)
+	}
+	return out
+}
+
+func TestLines(t *testing.T) {
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, " ")
+	}
+	tests := []struct {
+		name string
+		a, b string // lines separated by spaces
+		want []Edit
+	}{
+		{"same", "a b c", "a b c", nil},
+		{"both empty", "", "", nil},
+		{"from empty", "", "a b", []Edit{{0, 0, []string{"a", "b"}}}},
+		{"to empty", "a b", "", []Edit{{0, 2, nil}}},
+		{"insert", "a c", "a b c", []Edit{{1, 1, []string{"b"}}}},
+		{"insert at start", "b c", "a b c", []Edit{{0, 0, []string{"a"}}}},
+		{"append", "a b", "a b c", []Edit{{2, 2, []string{"c"}}}},
+		{"delete", "a b c", "a c", []Edit{{1, 2, nil}}},
+		{"replace", "a b c", "a x c", []Edit{{1, 2, []string{"x"}}}},
+		{"two hunks", "a b c d e", "a x c d y e", []Edit{{1, 2, []string{"x"}}, {4, 4, []string{"y"}}}},
+		{"keeps longest run", "x a b c y", "a b c", []Edit{{0, 1, nil}, {4, 5, nil}}},
+		{"moved line", "a b c", "b c a", []Edit{{0, 1, nil}, {3, 3, []string{"a"}}}},
+		{"repeated lines", "a a a", "a a", []Edit{{2, 3, nil}}},
+		{"all different", "a b", "x y z", []Edit{{0, 2, []string{"x", "y", "z"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := split(tt.a), split(tt.b)
+			got := Lines(a, b)
+			if !slices.EqualFunc(got, tt.want, func(x, y Edit) bool {
+				return x.Start == y.Start && x.End == y.End && slices.Equal(x.Lines, y.Lines)
+			}) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if applied := apply(a, got); !slices.Equal(applied, b) && !(len(applied) == 0 && len(b) == 0) {
+				t.Errorf("applying %v to %q gives %q, want %q", got, tt.a, applied, tt.b)
+			}
+		})
+	}
+}
+
+func TestLinesTooLarge(t *testing.T) {
+	// Past maxDiffCells the changed middle is replaced as a whole
+	n := 2100
+	a, b := make([]string, n+2), make([]string, n+2)
+	a[0], b[0] = "same", "same"
+	for i := 1; i <= n; i++ {
+		a[i] = "a" + strings.Repeat("x", i%7)
+		b[i] = "b" + strings.Repeat("x", i%7)
+		if i%10 == 5 {
+			// Shared lines that would split a smaller diff into hunks
+			b[i] = a[i]
+		}
+	}
+	a[n+1], b[n+1] = "end", "end"
+	got := Lines(a, b)
+	if len(got) != 1 || got[0].Start != 1 || got[0].End != n+1 || len(got[0].Lines) != n {
+		t.Fatalf("got %d edits, want one replacing lines 1 to %d", len(got), n+1)
+	}
+	if !slices.Equal(apply(a, got), b) {
+		t.Error("applying the edit doesn't give b")
+	}
+}
@@ -0,0 +1,158 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/indent"
+	"github.com/Adelodunpeter25/vx/internal/options"
+)
+
+// indentRules returns the indentation rules of the pane's filetype, or none
+// when smartindent is off
+func (p *Pane) indentRules() indent.Rules {
+	if !p.options.Bool(options.SmartIndent) {
+		return indent.Rules{}
+	}
+	return indent.For(p.options.String(options.Filetype))
+}
+
+// indentUnit returns one level of indentation in the pane
+func (p *Pane) indentUnit() string {
+	return indent.Unit(p.options.Bool(options.ExpandTab), p.options.Int(options.ShiftWidth))
+}
+
+// useFiletypeIndent indents with spaces in a filetype that requires them,
+// unless expandtab or shiftwidth were set for the buffer
+func (p *Pane) useFiletypeIndent() {
+	spaces := indent.For(p.options.String(options.Filetype)).Spaces
+	if spaces == 0 {
+		return
+	}
+	if !p.options.IsSet(options.ExpandTab) {
+		_ = p.options.Set(options.ExpandTab, true)
+	}
+	if !p.options.IsSet(options.ShiftWidth) {
+		_ = p.options.Set(options.ShiftWidth, spaces)
+	}
+}
+
+// insertNewline splits the line at the cursor. The new line keeps the
+// indentation of the old one, one level more after a line that opens a
+// block or one less after a line that ends it. Enter between brackets puts
+// the closing one on a line of its own, and a line left holding only its
+// indentation is emptied.
+func (e *Editor) insertNewline() {
+	p := e.active()
+	buf := p.buffer
+	runes := []rune(buf.Line(p.cursorY))
+	col := min(p.cursorX, len(runes))
+	before, after := string(runes[:col]), string(runes[col:])
+	rules := p.indentRules()
+	tabstop, shiftwidth := p.options.Int(options.TabStop), p.options.Int(options.ShiftWidth)
+
+	ws := indent.Leading(string(runes))
+	newIndent := ws
+	switch {
+	case rules.Opens(before):
+		newIndent += p.indentUnit()
+	case rules.Ends(before):
+		newIndent = indent.Outdent(ws, tabstop, shiftwidth)
+	}
+
+	// A closing bracket moved down lines up with the line that opened it
+	rest := strings.TrimLeft(after, " \t")
+	closer, closing := firstRune(rest), false
+	if open := indent.Opener(closer); open != 0 && rules.Brackets {
+		if l, c := e.findBackwardBracket(p.cursorY, col, open, closer); l >= 0 {
+			closing = l == p.cursorY && c == lineRuneCount(strings.TrimRight(before, " \t"))-1
+			if !closing {
+				newIndent = indent.Leading(buf.Line(l))
+			}
+		}
+	}
+
+	buf.UndoStack().BeginGroup()
+	defer buf.UndoStack().EndGroup()
+	buf.SplitLine(p.cursorY, col)
+	if strings.TrimSpace(before) == "" {
+		buf.ReplaceText(p.cursorY, 0, col, "")
+	}
+	p.cursorY++
+	buf.ReplaceText(p.cursorY, 0, lineRuneCount(indent.Leading(after)), newIndent)
+	p.cursorX = lineRuneCount(newIndent)
+	if closing {
+		buf.SplitLine(p.cursorY, p.cursorX)
+		buf.ReplaceText(p.cursorY+1, 0, 0, ws)
+	}
+	e.adjustScroll()
+}
+
+// dedentCloser lines up a closing bracket typed at the start of a line with
+// the line that opened it
+func (e *Editor) dedentCloser(r rune) {
+	p := e.active()
+	open := indent.Opener(r)
+	if open == 0 || !p.indentRules().Brackets {
+		return
+	}
+	runes := []rune(p.buffer.Line(p.cursorY))
+	col := min(p.cursorX, len(runes))
+	if strings.TrimLeft(string(runes[:col]), " \t") != "" {
+		return
+	}
+	l, _ := e.findBackwardBracket(p.cursorY, col, open, r)
+	if l < 0 {
+		return
+	}
+	ws := indent.Leading(p.buffer.Line(l))
+	p.buffer.ReplaceText(p.cursorY, 0, col, ws)
+	p.cursorX = lineRuneCount(ws)
+}
+
+// insertTab inserts a tab, or with expandtab the spaces up to the next
+// multiple of shiftwidth
+func (e *Editor) insertTab() {
+	p := e.active()
+	if !p.options.Bool(options.ExpandTab) {
+		p.buffer.InsertRune(p.cursorY, p.cursorX, '\t')
+		p.cursorX++
+		return
+	}
+	runes := []rune(p.buffer.Line(p.cursorY))
+	col := min(p.cursorX, len(runes))
+	shiftwidth := max(p.options.Int(options.ShiftWidth), 1)
+	n := shiftwidth - indent.Columns(string(runes[:col]), p.options.Int(options.TabStop))%shiftwidth
+	p.buffer.ReplaceText(p.cursorY, col, 0, strings.Repeat(" ", n))
+	p.cursorX = col + n
+}
+
+// deleteBefore deletes what is before the cursor on its line: the rune, an
+// empty pair the cursor is in, or with expandtab the spaces back to the
+// previous level of indentation
+func (e *Editor) deleteBefore() {
+	p := e.active()
+	runes := []rune(p.buffer.Line(p.cursorY))
+	col := min(p.cursorX, len(runes))
+	before := string(runes[:col])
+	switch {
+	case col == 0:
+		return
+	case e.options.Bool(options.AutoPairs) && col < len(runes) && autoPairs[runes[col-1]] == runes[col]:
+		p.buffer.ReplaceText(p.cursorY, col-1, 2, "")
+		p.cursorX = col - 1
+	case p.options.Bool(options.ExpandTab) && strings.HasSuffix(before, " ") && strings.TrimLeft(before, " \t") == "":
+		n := lineRuneCount(before) - lineRuneCount(indent.Outdent(before, p.options.Int(options.TabStop), p.options.Int(options.ShiftWidth)))
+		p.buffer.ReplaceText(p.cursorY, col-n, n, "")
+		p.cursorX = col - n
+	default:
+		p.buffer.DeleteRune(p.cursorY, col)
+		p.cursorX = col - 1
+	}
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"unicode"
+
+	"github.com/Adelodunpeter25/vx/internal/options"
+)
+
+// autoPairs maps each character the autopairs option closes to its closer
+var autoPairs = map[rune]rune{
+	'(':  ')',
+	'[':  ']',
+	'{':  '}',
+	'"':  '"',
+	'\'': '\'',
+	'`':  '`',
+}
+
+// isPairCloser reports whether r closes a pair
+func isPairCloser(r rune) bool {
+	switch r {
+	case ')', ']', '}', '"', '\'', '`':
+		return true
+	}
+	return false
+}
+
+// insertPaired types r for the autopairs option, returning false when r
+// is to be inserted as usual. A closer typed over the same one moves past
+// it; an opener followed by nothing, a space or a closer gets its closer
+// too. Quotes after a word character or a backslash are left alone.
+func (e *Editor) insertPaired(r rune) bool {
+	if !e.options.Bool(options.AutoPairs) {
+		return false
+	}
+	p := e.active()
+	runes := []rune(p.buffer.Line(p.cursorY))
+	col := min(p.cursorX, len(runes))
+	var prev, next rune
+	if col > 0 {
+		prev = runes[col-1]
+	}
+	if col < len(runes) {
+		next = runes[col]
+	}
+	if prev == '\\' {
+		return false
+	}
+	if next == r && isPairCloser(r) {
+		p.cursorX = col + 1
+		return true
+	}
+
+	closer, ok := autoPairs[r]
+	if !ok || (next != 0 && !unicode.IsSpace(next) && !isPairCloser(next)) {
+		return false
+	}
+	if closer == r && isWordRune(prev) {
+		return false
+	}
+	p.buffer.ReplaceText(p.cursorY, col, 0, string(r)+string(closer))
+	p.cursorX = col + 1
+	return true
+}
+
+// insertRune types r at the cursor
+func (e *Editor) insertRune(r rune) {
+	p := e.active()
+	p.buffer.UndoStack().BeginGroup()
+	defer p.buffer.UndoStack().EndGroup()
+	if e.insertPaired(r) {
+		return
+	}
+	e.dedentCloser(r)
+	p.buffer.InsertRune(p.cursorY, p.cursorX, r)
+	p.cursorX++
+}
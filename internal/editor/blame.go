@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/git"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// blameWidth is the width of the :blame column: an 8 character hash, the
+// author and the date, and a space before the rest of the gutter
+const blameWidth = 33
+
+// blameAuthorWidth is how much of the author's name the column shows
+const blameAuthorWidth = 12
+
+// blameView is the :blame column of a pane
+type blameView struct {
+	file    git.File
+	lines   []git.BlameLine // nil until the first blame finishes
+	version int             // buffer version the lines are for
+	loading bool
+}
+
+// blameResult is a blame run in the background
+type blameResult struct {
+	view    *blameView
+	version int
+	lines   []git.BlameLine
+	err     error
+}
+
+// toggleBlame opens the :blame column of the active pane, or closes it
+func (e *Editor) toggleBlame() error {
+	p := e.active()
+	if p.blame != nil {
+		p.blame = nil
+		return nil
+	}
+	if p.buffer.Filename() == "" || p.buffer.IsScratch() {
+		return fmt.Errorf("no file name")
+	}
+	file, err := git.Find(p.buffer.Filename())
+	if err != nil {
+		return err
+	}
+	p.blame = &blameView{file: file, version: -1}
+	e.syncBlame()
+	return nil
+}
+
+// syncBlame blames the buffers of the panes with a blame column again when
+// they were edited since their last blame, so the column follows the edits
+func (e *Editor) syncBlame() {
+	for _, p := range e.panes {
+		view := p.blame
+		if view == nil || view.loading || view.version == p.buffer.ModVersion() {
+			continue
+		}
+		if e.blames == nil {
+			e.blames = make(chan blameResult, 4)
+		}
+		view.loading = true
+		version := p.buffer.ModVersion()
+		lines := make([]string, p.buffer.LineCount())
+		for i := range lines {
+			lines[i] = p.buffer.Line(i)
+		}
+		contents := strings.Join(lines, "\n") + "\n"
+		go func() {
+			blame, err := view.file.Blame(contents)
+			e.blames <- blameResult{view: view, version: version, lines: blame, err: err}
+			e.term.Wake()
+		}()
+	}
+}
+
+// pollBlame picks up the blames finished in the background
+func (e *Editor) pollBlame() bool {
+	changed := false
+	for {
+		select {
+		case res := <-e.blames:
+			for _, p := range e.panes {
+				if p.blame != res.view {
+					continue
+				}
+				changed = true
+				if res.err != nil {
+					p.blame = nil
+					p.msgManager.SetError(utils.FormatUserError(res.err))
+					continue
+				}
+				res.view.lines, res.view.version = res.lines, res.version
+				res.view.loading = false
+			}
+		default:
+			return changed
+		}
+	}
+}
+
+// blameText formats a line's commit as "hash author date"
+func blameText(l git.BlameLine) string {
+	if !l.Committed() {
+		return fmt.Sprintf("%-8s %-*s", "", blameAuthorWidth, "Uncommitted")
+	}
+	return fmt.Sprintf("%.8s %-*s %s", l.Hash, blameAuthorWidth, shortAuthor(l.Author), l.Time.Format("2006-01-02"))
+}
@@ -19,7 +19,7 @@ func (e *Editor) handleBufferPromptMode(ev *tcell.EventKey) {
 		switch r {
 		case 'y', 'Y':
 			// Save and close buffer
-			if err := p.buffer.Save(); err != nil {
+			if _, err := e.writeBuffer(false); err != nil {
 				p.msgManager.SetError(utils.FormatSaveError(p.buffer.Filename(), err))
 				p.mode = ModeNormal
 			} else {
@@ -4,7 +4,7 @@ import (
 	"strings"
 
 	"github.com/Adelodunpeter25/vx/internal/command"
-	"github.com/Adelodunpeter25/vx/internal/syntax"
+	"github.com/Adelodunpeter25/vx/internal/options"
 	"github.com/Adelodunpeter25/vx/internal/terminal"
 	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
@@ -34,7 +34,12 @@ func (e *Editor) handleCommandMode(ev *terminal.Event) {
 			e.render()
 		}
 
+		filename := p.buffer.Filename()
 		result := command.Execute(p.commandBuf, p.buffer)
+		if p.buffer.Filename() != filename {
+			// Saved under a new name, which may say more about the language
+			p.detectFiletype()
+		}
 
 		// Handle buffer operations
 		if result.AddBuffer && result.NewBuffer != nil {
@@ -49,17 +54,117 @@ func (e *Editor) handleCommandMode(ev *terminal.Event) {
 		} else if result.ToggleFiles {
 			e.toggleFileBrowser()
 		} else if result.ShowHidden {
-			e.setFileBrowserHidden(true)
+			_ = e.options.Set(options.ShowHidden, true)
 		} else if result.HideHidden {
-			e.setFileBrowserHidden(false)
+			_ = e.options.Set(options.ShowHidden, false)
+		} else if result.Set {
+			msg, err := e.options.Apply(result.SetArgs, p.options, result.SetLocal)
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Grep {
+			if err := e.startGrep(result.GrepPattern, result.GrepPath); err != nil {
+				result.Error = err
+			}
+		} else if result.ProjectReplace {
+			if err := e.startProjectReplace(result.GrepPattern, result.ReplaceWith, result.GrepPath); err != nil {
+				result.Error = err
+			}
+		} else if result.QuickfixNext || result.QuickfixPrev {
+			p.mode = ModeNormal
+			p.commandBuf = ""
+			e.quickfixStep(result.QuickfixNext)
+			return
+		} else if result.ColorScheme {
+			if result.ColorSchemeName == "" {
+				result.Message = "Color scheme: " + e.theme.Name
+			} else if err := e.setColorScheme(result.ColorSchemeName); err != nil {
+				result.Error = err
+			} else {
+				result.Message = "Color scheme: " + e.theme.Name
+			}
+		} else if result.QuickfixOpen {
+			e.openQuickfix()
+		} else if result.QuickfixClose {
+			e.closeQuickfix()
+		} else if result.ToggleOutline {
+			e.toggleOutline()
+		} else if result.JumpTag {
+			p.mode = ModeNormal
+			p.commandBuf = ""
+			e.jumpToTagName(result.TagName)
+			return
+		} else if result.SymbolPicker {
+			p.commandBuf = ""
+			e.openSymbolPicker(result.SymbolQuery)
+			return
+		} else if result.Reindex {
+			e.reindexTags()
+		} else if result.Rename {
+			e.renameSymbol(result.NewName)
+		} else if result.LSP {
+			msg, err := e.lspCommand(result.LSPArg)
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Write {
+			msg, err := e.writeBuffer(result.Force)
+			if err != nil {
+				result.Error = err
+				result.Quit = false
+			} else {
+				result.Message = msg
+			}
+		} else if result.Format {
+			msg, err := e.formatBuffer()
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Make {
+			msg, err := e.startMake(result.MakeArgs, result.Force)
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Lint {
+			msg, err := e.lintBuffer()
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Blame {
+			if err := e.toggleBlame(); err != nil {
+				result.Error = err
+			}
+		} else if result.GitLog {
+			if err := e.showGitLog(); err != nil {
+				result.Error = err
+			}
+		} else if result.Hunk {
+			msg, err := e.hunkCommand(result.HunkArg)
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
+		} else if result.Snippets {
+			msg, err := e.snippetsCommand(result.SnippetsArg)
+			if err != nil {
+				result.Error = err
+			} else {
+				result.Message = msg
+			}
 		} else if result.SwitchFile && result.NewBuffer != nil {
 			// Handle file switching (replace current buffer)
-			p.buffer = result.NewBuffer
-			p.syntax = syntax.New(result.NewBuffer.Filename())
-			p.cursorX = 0
-			p.cursorY = 0
-			p.offsetY = 0
-			p.renderCache.invalidate()
+			p.setBuffer(result.NewBuffer)
 			e.showFileInfo()
 		}
 
@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/Adelodunpeter25/vx/internal/comment"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// startCommentOperator handles gc: with a selection it toggles the comments
+// of the selected lines, otherwise it waits for a motion
+func (e *Editor) startCommentOperator() {
+	p := e.active()
+	if p.selection.IsActive() {
+		startLine, _, endLine, endCol, ok := p.selection.GetRange()
+		if !ok {
+			return
+		}
+		// A selection ending at the start of a line doesn't take it in
+		if endCol == 0 && endLine > startLine {
+			endLine--
+		}
+		p.selection.Clear()
+		e.toggleComments(startLine, endLine)
+		return
+	}
+	p.pendingOp = "gc"
+	p.opLine = p.cursorY
+}
+
+// isOperatorMotion reports whether a key moves the cursor for an operator
+func isOperatorMotion(ev *terminal.Event) bool {
+	switch ev.Key {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight:
+		return true
+	}
+	switch ev.Rune {
+	case 'j', 'k', 'h', 'l', 'w', 'b', 'g', 'G':
+		return true
+	}
+	return false
+}
+
+// handleOperatorKey takes the motion of a pending operator: gc then c or
+// gc (gcc, gcgc) for the cursor line, or a motion for the lines it moves
+// over
+func (e *Editor) handleOperatorKey(ev *terminal.Event) {
+	p := e.active()
+	op := p.pendingOp
+	p.pendingOp = ""
+	if op != "gc" {
+		return
+	}
+	if ev.Rune == 'c' && (p.lastKey == 0 || p.lastKey == 'g') {
+		p.lastKey = 0
+		e.toggleComments(p.cursorY, p.cursorY)
+		return
+	}
+	if !isOperatorMotion(ev) {
+		p.lastKey = 0
+		return
+	}
+
+	e.handleNormalMode(ev)
+	if p.lastKey != 0 {
+		// The motion has more keys to come, as gg does
+		p.pendingOp = op
+		return
+	}
+	e.toggleComments(min(p.opLine, p.cursorY), max(p.opLine, p.cursorY))
+}
+
+// toggleComments comments the lines from start to end out, or back in if
+// they all are comments, as one undo step
+func (e *Editor) toggleComments(start, end int) {
+	p := e.active()
+	ft := p.options.String(options.Filetype)
+	markers, ok := comment.For(ft)
+	if !ok {
+		if ft == "" {
+			p.msgManager.SetError("No comment syntax for plain text")
+		} else {
+			p.msgManager.SetError(fmt.Sprintf("No comment syntax for filetype %s", ft))
+		}
+		return
+	}
+
+	lines := make([]string, end-start+1)
+	for i := range lines {
+		lines[i] = p.buffer.Line(start + i)
+	}
+	toggled := comment.Toggle(lines, markers)
+
+	p.buffer.UndoStack().BeginGroup()
+	for i, line := range toggled {
+		if line != lines[i] {
+			p.buffer.ReplaceText(start+i, 0, lineRuneCount(lines[i]), line)
+		}
+	}
+	p.buffer.UndoStack().EndGroup()
+
+	p.cursorY = start
+	e.clampCursor()
+	e.adjustScroll()
+	if n := end - start + 1; n > 1 {
+		p.msgManager.SetTransient(fmt.Sprintf("%d lines toggled", n))
+	}
+}
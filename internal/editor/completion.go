@@ -0,0 +1,193 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/completion"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// autoCompleteLen is how many word characters typed open the menu while
+// autocomplete is on
+const autoCompleteLen = 2
+
+// completers returns the sources of insert-mode completions
+func (e *Editor) completers() []completion.Provider {
+	if e.providers == nil {
+		e.providers = []completion.Provider{
+			completion.Words{Texts: e.completionTexts},
+			completion.Paths{},
+			completion.ProviderFunc(e.snippetCompletions),
+			completion.ProviderFunc(e.lspCompletions),
+		}
+	}
+	return e.providers
+}
+
+// completionTexts returns the buffers of all panes, the active one first
+func (e *Editor) completionTexts() []completion.Text {
+	texts := []completion.Text{e.active().buffer}
+	seen := map[*buffer.Buffer]bool{e.active().buffer: true}
+	for _, p := range e.panes {
+		if !seen[p.buffer] {
+			seen[p.buffer] = true
+			texts = append(texts, p.buffer)
+		}
+	}
+	return texts
+}
+
+// startCompletion opens the completion menu at the cursor and asks every
+// provider for items. Manual requests also offer words with nothing typed.
+func (e *Editor) startCompletion(manual bool) {
+	p := e.active()
+	dir := ""
+	if name := p.buffer.Filename(); name != "" {
+		if abs, err := filepath.Abs(name); err == nil {
+			dir = filepath.Dir(abs)
+		}
+	} else if cwd, err := os.Getwd(); err == nil {
+		dir = cwd
+	}
+	req := completion.Request{Line: p.buffer.Line(p.cursorY), Col: p.cursorX, Dir: dir, Manual: manual}
+	m := completion.NewMenu(p.cursorY)
+	m.Manual = manual
+	m.Update(req.Line, req.Col)
+	e.completion = m
+	for _, provider := range e.completers() {
+		provider.Complete(req, func(items []completion.Item) {
+			// Late results for a menu that was closed are dropped
+			if e.completion == m {
+				m.Add(items)
+			}
+		})
+	}
+	if manual && m.Len() == 0 && !e.hasLSP() {
+		p.msgManager.SetTransient("No completions")
+	}
+}
+
+// handleCompletionKey moves through and accepts completions while the menu
+// shows, and opens it with Ctrl+N, Ctrl+P or Ctrl+Space. It returns true if
+// it used the key.
+func (e *Editor) handleCompletionKey(ev *terminal.Event) bool {
+	if m := e.completion; m != nil && m.Len() > 0 {
+		switch ev.Key {
+		case tcell.KeyCtrlN, tcell.KeyDown:
+			m.Move(1)
+			return true
+		case tcell.KeyCtrlP, tcell.KeyUp:
+			m.Move(-1)
+			return true
+		case tcell.KeyEnter:
+			// A menu that opened by itself only takes Enter once an item was
+			// picked, so Enter at the end of a typed word still breaks the line
+			if !m.Manual && !m.Moved() {
+				return false
+			}
+			e.acceptCompletion()
+			return true
+		case tcell.KeyTab:
+			e.acceptCompletion()
+			return true
+		case tcell.KeyCtrlE:
+			e.completion = nil
+			return true
+		}
+	}
+	switch ev.Key {
+	case tcell.KeyCtrlN, tcell.KeyCtrlSpace:
+		e.startCompletion(true)
+		return true
+	case tcell.KeyCtrlP:
+		e.startCompletion(true)
+		if e.completion != nil {
+			e.completion.Move(-1)
+		}
+		return true
+	}
+	return false
+}
+
+// acceptCompletion inserts the selected item in place of what was typed
+func (e *Editor) acceptCompletion() {
+	p := e.active()
+	item, ok := e.completion.Selected()
+	e.completion = nil
+	if !ok {
+		return
+	}
+	if item.Accept != nil {
+		item.Accept()
+	} else {
+		e.insertCompletion(item)
+	}
+	p.renderCache.invalidate()
+	e.adjustScroll()
+	if strings.HasSuffix(item.Text, "/") && e.options.Bool(options.AutoComplete) {
+		// Carry on into a directory
+		e.startCompletion(false)
+	}
+}
+
+// insertCompletion replaces the text from the item's start to the cursor
+// with the item's text
+func (e *Editor) insertCompletion(item completion.Item) {
+	p := e.active()
+	start := min(item.Start, p.cursorX)
+	p.buffer.ReplaceText(p.cursorY, start, p.cursorX-start, item.Text)
+	p.cursorX = start + len([]rune(item.Text))
+}
+
+// updateCompletion refilters the menu after a key in insert mode, closing
+// it once the cursor leaves the completed text, and opens it as a word or
+// path is typed while autocomplete is on
+func (e *Editor) updateCompletion(ev *terminal.Event) {
+	p := e.active()
+	if m := e.completion; m != nil {
+		if p.mode != ModeInsert || p.cursorY != m.Line || p.cursorX < m.Start() ||
+			(ev.Rune != 0 && !completion.IsWordRune(ev.Rune)) || ev.Key == tcell.KeyTab {
+			e.completion = nil
+		} else {
+			m.Update(p.buffer.Line(p.cursorY), p.cursorX)
+			return
+		}
+	}
+	if p.mode != ModeInsert || ev.Key != tcell.KeyRune || !e.options.Bool(options.AutoComplete) {
+		return
+	}
+	req := completion.Request{Line: p.buffer.Line(p.cursorY), Col: p.cursorX}
+	switch {
+	case ev.Rune == '/', e.isTriggerCharacter(ev.Rune):
+		e.startCompletion(false)
+	case completion.IsWordRune(ev.Rune) && len([]rune(req.Prefix())) == autoCompleteLen:
+		e.startCompletion(false)
+	}
+}
+
+// renderCompletion draws the menu below the cursor, lined up with the text
+// it completes, or above the cursor when there is no room below
+func (e *Editor) renderCompletion() {
+	p := e.active()
+	m := e.completion
+	if m == nil || m.Len() == 0 || p.mode != ModeInsert {
+		return
+	}
+	width, height := m.Size()
+	gutterWidth := e.getGutterWidthFor(p)
+	cy, cx := e.getCursorScreenPosFor(p, gutterWidth, p.viewWidth-gutterWidth)
+	// One column of padding before the text; the start may be on an earlier
+	// row of a wrapped line, in which case the menu starts at the gutter
+	x := max(p.viewX+cx-(p.cursorX-m.Start())-1, p.viewX+gutterWidth)
+	x = max(min(x, e.width-width), 0)
+	y := p.viewY + cy + 1
+	if y+height > p.viewY+p.viewHeight {
+		y = max(p.viewY+cy-height, 0)
+	}
+	m.Render(e.term, x, y, width, height, e.theme.UI.Popup)
+}
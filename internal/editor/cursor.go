@@ -3,7 +3,7 @@ package editor
 import (
 	"unicode/utf8"
 
-	"github.com/Adelodunpeter25/vx/internal/wrap"
+	"github.com/Adelodunpeter25/vx/internal/options"
 )
 
 func lineRuneCount(line string) int {
@@ -27,20 +27,23 @@ func (e *Editor) clampCursor() {
 
 func (e *Editor) adjustScroll() {
 	p := e.active()
-	contentHeight := e.height - 1
+	contentHeight := e.paneAreaHeight()
 	gutterWidth := e.getGutterWidth()
-	maxWidth := e.width - gutterWidth
+	maxWidth := e.textWidth(gutterWidth)
+
+	lineWidth := wrapWidth(p, maxWidth)
+
+	// A cursor moved into a closed fold opens it
+	p.foldSet().Reveal(p.cursorY)
 
 	// Calculate visual line position of cursor
 	cursorVisualLine := 0
 	for lineNum := 0; lineNum < p.cursorY && lineNum < p.buffer.LineCount(); lineNum++ {
-		line := p.buffer.Line(lineNum)
-		cursorVisualLine += wrap.VisualLineCount(line, maxWidth)
+		cursorVisualLine += lineRows(p, lineNum, lineWidth)
 	}
 
 	// Find which wrapped segment contains the cursor
-	currentLine := p.buffer.Line(p.cursorY)
-	segments := wrap.WrapLine(currentLine, p.cursorY, maxWidth)
+	segments := lineSegments(p, p.cursorY, lineWidth)
 	for i, seg := range segments {
 		segEndCol := seg.StartCol + len([]rune(seg.Text))
 		if p.cursorX >= seg.StartCol && p.cursorX <= segEndCol {
@@ -67,17 +70,49 @@ func (e *Editor) adjustScroll() {
 	// Convert visual offset to buffer line offset for rendering
 	p.offsetY = e.findLineAtVisualRow(p.visualOffsetY, maxWidth)
 
-	// No horizontal scroll needed with wrapping
-	p.offsetX = 0
+	// Horizontal scroll is only needed when wrapping is off
+	if lineWidth > 0 {
+		p.offsetX = 0
+		return
+	}
+	// offsetX counts screen columns, which tabs make wider than runes
+	from, to := segments[0].X(p.cursorX), segments[0].X(p.cursorX+1)
+	if from < p.offsetX {
+		p.offsetX = from
+	}
+	if maxWidth > 0 && to > p.offsetX+maxWidth {
+		p.offsetX = to - maxWidth
+	}
+	if p.offsetX < 0 {
+		p.offsetX = 0
+	}
+}
+
+// textWidth returns the width of the active pane's text area as last drawn,
+// which sidebars and splits make narrower than the screen
+func (e *Editor) textWidth(gutterWidth int) int {
+	width := e.active().viewWidth
+	if width == 0 {
+		width = e.width
+	}
+	return width - gutterWidth
+}
+
+// wrapWidth returns the width lines wrap at, or 0 when wrapping is off
+func wrapWidth(p *Pane, maxWidth int) int {
+	if !p.options.Bool(options.Wrap) {
+		return 0
+	}
+	return maxWidth
 }
 
 // findLineAtVisualRow finds which buffer line contains the given visual row
 func (e *Editor) findLineAtVisualRow(targetVisual, maxWidth int) int {
 	p := e.active()
+	lineWidth := wrapWidth(p, maxWidth)
 	visualLine := 0
 	for lineNum := 0; lineNum < p.buffer.LineCount(); lineNum++ {
-		line := p.buffer.Line(lineNum)
-		lineVisualCount := wrap.VisualLineCount(line, maxWidth)
+		lineVisualCount := lineRows(p, lineNum, lineWidth)
 		if visualLine+lineVisualCount > targetVisual {
 			return lineNum
 		}
@@ -0,0 +1,163 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+	"github.com/Adelodunpeter25/vx/internal/lsp"
+	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
+	"github.com/Adelodunpeter25/vx/internal/wrap"
+	"github.com/gdamore/tcell/v2"
+)
+
+// diagnosticsFor returns the diagnostics of a pane's buffer, those the
+// language server published followed by those of its last lint
+func (e *Editor) diagnosticsFor(p *Pane) []diagnostic.Diagnostic {
+	var published []diagnostic.Diagnostic
+	if e.lsp != nil {
+		if d := e.lsp.docs[p.buffer]; d != nil {
+			published = d.diagnostics
+		}
+	}
+	linted := e.lintDiagnostics(p.buffer)
+	if len(linted) == 0 {
+		return published
+	}
+	if len(published) == 0 {
+		return linted
+	}
+	return append(append([]diagnostic.Diagnostic(nil), published...), linted...)
+}
+
+// lspDiagnostics converts the diagnostics a server published for a buffer
+// to rune columns
+func lspDiagnostics(buf *buffer.Buffer, encoding string, published []lsp.Diagnostic) []diagnostic.Diagnostic {
+	list := make([]diagnostic.Diagnostic, 0, len(published))
+	column := func(pos lsp.Position) int {
+		if pos.Line >= buf.LineCount() {
+			return pos.Character
+		}
+		return lsp.Column(encoding, buf.Line(pos.Line), pos.Character)
+	}
+	for _, pd := range published {
+		d := diagnostic.Diagnostic{
+			Line:     pd.Range.Start.Line,
+			Col:      column(pd.Range.Start),
+			EndLine:  pd.Range.End.Line,
+			EndCol:   column(pd.Range.End),
+			Severity: pd.Severity,
+			Source:   pd.Source,
+			Message:  pd.Message,
+		}
+		// Servers may leave the severity out
+		if d.Severity < diagnostic.Error || d.Severity > diagnostic.Hint {
+			d.Severity = diagnostic.Error
+		}
+		if d.EndLine < d.Line || d.EndLine == d.Line && d.EndCol <= d.Col {
+			line := ""
+			if d.Line < buf.LineCount() {
+				line = buf.Line(d.Line)
+			}
+			d.EndLine = d.Line
+			d.Col, d.EndCol = diagnosticSpan(line, d.Col)
+		}
+		list = append(list, d)
+	}
+	return list
+}
+
+// diagnosticSigns maps lines to the most severe diagnostic on them, or
+// returns nil when the buffer has none
+func (e *Editor) diagnosticSigns(p *Pane) map[int]int {
+	diagnostics := e.diagnosticsFor(p)
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	signs := make(map[int]int)
+	for _, d := range diagnostics {
+		if s, ok := signs[d.Line]; !ok || d.Severity < s {
+			signs[d.Line] = d.Severity
+		}
+	}
+	return signs
+}
+
+// lineDiagnostic returns the most severe diagnostic on a line
+func (e *Editor) lineDiagnostic(p *Pane, line int) (diagnostic.Diagnostic, bool) {
+	var found diagnostic.Diagnostic
+	ok := false
+	for _, d := range e.diagnosticsFor(p) {
+		if d.Line == line && (!ok || d.Severity < found.Severity) {
+			found, ok = d, true
+		}
+	}
+	return found, ok
+}
+
+// underlineDiagnosticsAt underlines the ranges of diagnostics in a segment
+// of a line, in the color of their severity, keeping the syntax colors
+func (e *Editor) underlineDiagnosticsAt(rect splitpane.Rect, screenRow, lineNum int, seg wrap.Line, gutterWidth int, diagnostics []diagnostic.Diagnostic) {
+	segEnd := seg.StartCol + len([]rune(seg.Text))
+	for _, d := range diagnostics {
+		if lineNum < d.Line || lineNum > d.EndLine {
+			continue
+		}
+		from, to := seg.StartCol, segEnd
+		if lineNum == d.Line {
+			from = max(from, d.Col)
+		}
+		if lineNum == d.EndLine {
+			to = min(to, d.EndCol)
+		}
+		color, _, _ := e.severityStyle(d.Severity).Decompose()
+		for x := gutterWidth + seg.X(from); x < gutterWidth+seg.X(to) && x < rect.Width; x++ {
+			r, _, style, _ := e.term.ScreenContent(rect.X+x, rect.Y+screenRow)
+			e.setCellAt(rect, x, screenRow, r, style.Underline(tcell.UnderlineStyleCurly, color))
+		}
+	}
+}
+
+// diagnosticCounts summarizes a buffer's diagnostics for the status bar,
+// e.g. " E2 W1 |"
+func (e *Editor) diagnosticCounts(p *Pane) string {
+	var counts [diagnostic.Hint + 1]int
+	for _, d := range e.diagnosticsFor(p) {
+		counts[d.Severity]++
+	}
+	var b strings.Builder
+	for s := diagnostic.Error; s <= diagnostic.Hint; s++ {
+		if counts[s] > 0 {
+			fmt.Fprintf(&b, " %s%d", severitySign(s), counts[s])
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return b.String() + " |"
+}
+
+func severitySign(severity int) string {
+	switch severity {
+	case diagnostic.Warning:
+		return "W"
+	case diagnostic.Info:
+		return "I"
+	case diagnostic.Hint:
+		return "H"
+	}
+	return "E"
+}
+
+func (e *Editor) severityStyle(severity int) tcell.Style {
+	switch severity {
+	case diagnostic.Warning:
+		return e.theme.UI.Warning
+	case diagnostic.Info:
+		return e.theme.UI.Info
+	case diagnostic.Hint:
+		return e.theme.UI.Hint
+	}
+	return e.theme.UI.Error
+}
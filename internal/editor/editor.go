@@ -2,32 +2,73 @@ package editor
 
 import (
 	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/completion"
 	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
+	"github.com/Adelodunpeter25/vx/internal/formatter"
+	"github.com/Adelodunpeter25/vx/internal/lint"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/outline"
+	"github.com/Adelodunpeter25/vx/internal/picker"
+	projectreplace "github.com/Adelodunpeter25/vx/internal/project-replace"
+	"github.com/Adelodunpeter25/vx/internal/quickfix"
+	"github.com/Adelodunpeter25/vx/internal/snippet"
 	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
+	"github.com/Adelodunpeter25/vx/internal/tags"
 	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/Adelodunpeter25/vx/internal/theme"
 	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
 )
 
 type Editor struct {
-	term        *terminal.Terminal
-	width       int
-	height      int
-	panes       []*Pane
-	activePane  int
-	splitRatio  float64
-	dragSplit   bool
-	dragBrowser bool
-	fileBrowser *filebrowser.State
-	cdPrompt    *filebrowser.CdPrompt
-	quit        bool
+	term           *terminal.Terminal
+	width          int
+	height         int
+	panes          []*Pane
+	activePane     int
+	splitRatio     float64
+	dragSplit      bool
+	dragBrowser    bool
+	fileBrowser    *filebrowser.State
+	cdPrompt       *filebrowser.CdPrompt
+	outline        *outline.Panel
+	options        *options.Registry
+	quickfix       *quickfix.List
+	grep           *grepJob
+	projectReplace *projectreplace.Session
+	tagIndex       *tags.Index
+	tagJob         *tagJob
+	tagPending     func(idx *tags.Index) // waiting for tagJob
+	jumps          []jump                // tag stack
+	picker         *picker.Picker
+	pickerAccept   func(i int) // runs with the chosen item
+	pickerMode     Mode        // mode to return to
+	lsp            *lspState
+	hover          []string              // hover text shown by K until the next key
+	completion     *completion.Menu      // insert-mode completion menu
+	providers      []completion.Provider // sources of completions
+	snippets       *snippet.Library      // snippet files, read on first use
+	snippet        *snippetState         // snippet being filled in
+	formatters     formatter.Config      // formatters by filetype, read on first use
+	linters        lint.Config           // linters by filetype, read on first use
+	lintJobs       map[*buffer.Buffer]*lintJob
+	lintResults    map[*buffer.Buffer]*lintDoc // from the last lint of each buffer
+	build          *makeJob                    // running :make
+	gitDocs        map[*buffer.Buffer]*gitDoc  // staged versions for the git gutter
+	gitLoads       chan gitLoad
+	gitLogs        map[*buffer.Buffer]*gitLog // :Glog lists by their buffers
+	blames         chan blameResult           // :blame runs finished in the background
+	status         *gitStatus                 // git status of the file browser's files
+	theme          *theme.Theme
+	quit           bool
 }
 
 func New(term *terminal.Terminal) *Editor {
 	width, height := term.Size()
+	opts := newOptions()
 	buf := buffer.New()
-	pane := NewPane(buf, "")
-	return &Editor{
+	pane := NewPane(buf, "", opts)
+	ed := &Editor{
 		term:        term,
 		width:       width,
 		height:      height,
@@ -35,17 +76,22 @@ func New(term *terminal.Terminal) *Editor {
 		activePane:  0,
 		splitRatio:  0.5,
 		fileBrowser: filebrowser.New(""),
+		options:     opts,
+		quickfix:    quickfix.New(),
 	}
+	ed.watchOptions()
+	return ed
 }
 
 func NewWithFile(term *terminal.Terminal, filename string) (*Editor, error) {
+	opts := newOptions()
 	buf, err := buffer.Load(filename)
 	if err != nil {
 		// Check if it's a partial load (recoverable error)
 		if buf != nil {
 			// File loaded with warnings
 			width, height := term.Size()
-			pane := NewPane(buf, filename)
+			pane := NewPane(buf, filename, opts)
 			pane.msgManager.SetError("Warning: " + utils.FormatLoadError(filename, err))
 			ed := &Editor{
 				term:        term,
@@ -55,14 +101,17 @@ func NewWithFile(term *terminal.Terminal, filename string) (*Editor, error) {
 				activePane:  0,
 				splitRatio:  0.5,
 				fileBrowser: filebrowser.New(""),
+				options:     opts,
+				quickfix:    quickfix.New(),
 			}
+			ed.watchOptions()
 			return ed, nil
 		}
 		return nil, err
 	}
 
 	width, height := term.Size()
-	pane := NewPane(buf, filename)
+	pane := NewPane(buf, filename, opts)
 	ed := &Editor{
 		term:        term,
 		width:       width,
@@ -71,16 +120,14 @@ func NewWithFile(term *terminal.Terminal, filename string) (*Editor, error) {
 		activePane:  0,
 		splitRatio:  0.5,
 		fileBrowser: filebrowser.New(""),
+		options:     opts,
+		quickfix:    quickfix.New(),
 	}
+	ed.watchOptions()
 
 	// Show file info message on load
 	ed.showFileInfo()
 
-	// Show warning if syntax highlighting disabled due to size
-	if ed.active().syntax.IsTooLarge() {
-		ed.active().msgManager.SetPersistent("File too large for syntax highlighting")
-	}
-
 	return ed, nil
 }
 
@@ -107,7 +154,7 @@ func (e *Editor) active() *Pane {
 }
 
 func (e *Editor) addPaneWithBuffer(buf *buffer.Buffer, filename string) {
-	pane := NewPane(buf, filename)
+	pane := NewPane(buf, filename, e.options)
 	e.panes = append(e.panes, pane)
 	e.activePane = len(e.panes) - 1
 }
@@ -155,7 +202,13 @@ func (e *Editor) handleMouseEventForPane(ev *terminal.Event) {
 	if ev.MouseY >= e.height-1 {
 		return
 	}
-	contentHeight := e.height - 1
+	contentHeight := e.paneAreaHeight()
+	if ev.MouseY >= contentHeight {
+		if e.projectReplace == nil && e.quickfixHeight() > 0 {
+			e.handleQuickfixMouse(ev, contentHeight, e.quickfixHeight())
+		}
+		return
+	}
 	contentX := 0
 	contentWidth := e.width
 	if e.fileBrowser != nil && e.fileBrowser.Open {
@@ -174,6 +227,8 @@ func (e *Editor) handleMouseEventForPane(ev *terminal.Event) {
 		if ev.MouseX < fbWidth && ev.MouseY < contentHeight {
 			if ev.Button == tcell.Button1 || ev.Button == tcell.WheelUp || ev.Button == tcell.WheelDown {
 				e.fileBrowser.Focused = true
+				e.quickfix.Focused = false
+				e.blurOutline()
 			}
 			action := e.fileBrowser.HandleMouse(ev, 0, 0, fbWidth, contentHeight)
 			if action.PreviewPath != "" {
@@ -190,6 +245,16 @@ func (e *Editor) handleMouseEventForPane(ev *terminal.Event) {
 		contentX = fbWidth + 1
 		contentWidth = e.width - contentX
 	}
+	if olWidth := e.outlineWidth(); olWidth > 0 {
+		if ev.MouseX >= e.width-olWidth {
+			e.handleOutlineMouse(ev, e.width-olWidth, contentHeight)
+			return
+		}
+		if ev.MouseX == e.width-olWidth-1 {
+			return
+		}
+		contentWidth -= olWidth + 1
+	}
 	rects, dividerX := splitpane.LayoutSideBySide(contentWidth, contentHeight, len(e.panes), e.splitRatio)
 	if len(rects) >= 2 && dividerX >= 0 {
 		if e.handleSplitterDrag(ev, contentX+dividerX, contentX, contentWidth) {
@@ -205,6 +270,8 @@ func (e *Editor) handleMouseEventForPane(ev *terminal.Event) {
 				if e.fileBrowser != nil {
 					e.fileBrowser.Focused = false
 				}
+				e.quickfix.Focused = false
+				e.blurOutline()
 			}
 			local := *ev
 			local.MouseX = ev.MouseX - rect.X
@@ -253,11 +320,13 @@ func (e *Editor) handleSplitterDrag(ev *terminal.Event, dividerX int, contentX i
 }
 
 func (e *Editor) Run() error {
+	e.initTheme()
 	e.render()
 
 	for !e.quit {
 		e.handleEvent()
 	}
+	e.stopLSP()
 	return nil
 }
 
@@ -274,9 +343,51 @@ func (e *Editor) handleEvent() {
 	case terminal.EventResize:
 		e.handleResize()
 	case terminal.EventMouse:
+		e.completion = nil
+		e.snippet = nil
 		e.handleMouseEventForPane(ev)
 		e.active().renderCache.invalidate()
 		e.render()
+	case terminal.EventWake:
+		e.handleWake()
+	}
+}
+
+// handleWake collects results from background work and redraws
+func (e *Editor) handleWake() {
+	changed := false
+	for _, p := range e.panes {
+		if e.pollSearch(p) {
+			changed = true
+		}
+	}
+	if e.pollGrep() {
+		changed = true
+	}
+	if e.pollTags() {
+		changed = true
+	}
+	if e.pollLSP() {
+		changed = true
+	}
+	if e.pollLint() {
+		changed = true
+	}
+	if e.pollMake() {
+		changed = true
+	}
+	if e.pollGit() {
+		changed = true
+	}
+	if e.pollBlame() {
+		changed = true
+	}
+	if e.pollGitStatus() {
+		changed = true
+	}
+	if changed {
+		e.active().renderCache.invalidate()
+		e.render()
 	}
 }
 
@@ -292,6 +403,15 @@ func (e *Editor) handleResize() {
 }
 
 func (e *Editor) handleKey(ev *terminal.Event) {
+	if e.hover != nil {
+		// Any key closes the hover popup; Esc does nothing else
+		e.hover = nil
+		if ev.Key == tcell.KeyEscape {
+			e.active().renderCache.invalidate()
+			e.render()
+			return
+		}
+	}
 	if e.fileBrowser != nil && e.fileBrowser.Open && e.fileBrowser.Focused {
 		action := e.fileBrowser.HandleKey(ev)
 		if action.PreviewPath != "" {
@@ -304,6 +424,24 @@ func (e *Editor) handleKey(ev *terminal.Event) {
 		e.render()
 		return
 	}
+	if e.projectReplace != nil {
+		e.handleProjectReplaceKey(ev)
+		e.active().renderCache.invalidate()
+		e.render()
+		return
+	}
+	if e.quickfix.Open && e.quickfix.Focused {
+		e.handleQuickfixAction(e.quickfix.HandleKey(ev))
+		e.active().renderCache.invalidate()
+		e.render()
+		return
+	}
+	if e.outline != nil && e.outline.Open && e.outline.Focused {
+		e.handleOutlineAction(e.outline.HandleKey(ev))
+		e.active().renderCache.invalidate()
+		e.render()
+		return
+	}
 	switch e.active().mode {
 	case ModeNormal:
 		e.handleNormalMode(ev)
@@ -322,6 +460,8 @@ func (e *Editor) handleKey(ev *terminal.Event) {
 		e.handleBufferPromptMode(tcellEv)
 	case ModeCdPrompt:
 		e.handleCdPrompt(ev)
+	case ModePicker:
+		e.handlePickerKey(ev)
 	}
 	e.active().renderCache.invalidate()
 	e.render()
@@ -331,7 +471,7 @@ func (e *Editor) handleKey(ev *terminal.Event) {
 func (e *Editor) ensurePaneCount() {
 	if len(e.panes) == 0 {
 		buf := buffer.New()
-		pane := NewPane(buf, "")
+		pane := NewPane(buf, "", e.options)
 		e.panes = []*Pane{pane}
 		e.activePane = 0
 	}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/Adelodunpeter25/vx/internal/buffer"
 	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
-	"github.com/Adelodunpeter25/vx/internal/syntax"
 	"github.com/Adelodunpeter25/vx/internal/terminal"
 	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
@@ -22,12 +21,7 @@ func (e *Editor) openFileInActivePane(path string) {
 		p.msgManager.SetError("Error: " + err.Error())
 		return
 	}
-	p.buffer = newBuf
-	p.syntax = syntax.New(newBuf.Filename())
-	p.cursorX = 0
-	p.cursorY = 0
-	p.offsetY = 0
-	p.renderCache.invalidate()
+	p.setBuffer(newBuf)
 	e.showFileInfo()
 }
 
@@ -46,12 +40,7 @@ func (e *Editor) previewFileInActivePane(path string) {
 		p.msgManager.SetError("Error: " + err.Error())
 		return
 	}
-	p.buffer = newBuf
-	p.syntax = syntax.New(newBuf.Filename())
-	p.cursorX = 0
-	p.cursorY = 0
-	p.offsetY = 0
-	p.renderCache.invalidate()
+	p.setBuffer(newBuf)
 	e.showFileInfo()
 }
 
@@ -62,6 +51,7 @@ func (e *Editor) toggleFileBrowser() {
 	e.fileBrowser.Open = !e.fileBrowser.Open
 	if e.fileBrowser.Open {
 		e.fileBrowser.Focused = true
+		e.blurOutline()
 	} else {
 		e.fileBrowser.Focused = false
 	}
@@ -128,7 +118,8 @@ func (e *Editor) handleCdPrompt(ev *terminal.Event) {
 			e.active().msgManager.SetError("Error: " + err.Error())
 		} else {
 			if e.fileBrowser != nil {
-				e.fileBrowser.SetRoot(path)
+				// path may be relative to the directory just left
+				e.fileBrowser.SetRoot("")
 			}
 			e.active().msgManager.SetTransient("Changed directory to " + abbreviateHome(path))
 		}
@@ -0,0 +1,37 @@
+package editor
+
+import (
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// detectLines is how many lines from the top of a file content detection reads
+const detectLines = 100
+
+// detectFiletype sets the filetype option from the buffer's name and content.
+// A filetype set by hand is kept.
+func (p *Pane) detectFiletype() {
+	if p.options.IsSet(options.Filetype) && p.options.String(options.Filetype) != p.autoFiletype {
+		return
+	}
+
+	count := p.buffer.LineCount()
+	head := make([]string, min(count, detectLines))
+	for i := range head {
+		head[i] = p.buffer.Line(i)
+	}
+	// The end of a lazily loaded file isn't read just for a modeline
+	var tail []string
+	if count > len(head) && count <= utils.LazyLoadThreshold {
+		for i := max(len(head), count-highlight.ModelineLines); i < count; i++ {
+			tail = append(tail, p.buffer.Line(i))
+		}
+	}
+
+	ft := highlight.Detect(p.buffer.Filename(), head, tail)
+	p.autoFiletype = ft
+	_ = p.options.Set(options.Filetype, ft)
+	p.syntax.SetLanguage(ft)
+	p.useFiletypeIndent()
+}
@@ -0,0 +1,138 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/Adelodunpeter25/vx/internal/fold"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
+	"github.com/Adelodunpeter25/vx/internal/wrap"
+)
+
+// foldSet returns the pane's folds, moved along with edits made since the
+// last call
+func (p *Pane) foldSet() *fold.Set {
+	p.syncFolds(false)
+	return p.folds
+}
+
+// syncFolds recomputes the folds after the buffer changed. Unless force is
+// set this waits until a fold is closed, since open folds don't show.
+func (p *Pane) syncFolds(force bool) {
+	version := p.buffer.ModVersion()
+	if version == p.foldVersion || (!force && !p.folds.HasClosed()) {
+		return
+	}
+	if changes, ok := p.buffer.ChangesSince(p.foldVersion); ok {
+		for _, c := range changes {
+			p.folds.Apply(c.Line, c.Delta)
+		}
+	} else {
+		p.folds.OpenAll()
+	}
+
+	if p.options.String(options.FoldMethod) == "bracket" {
+		p.folds.SetRanges(fold.Brackets(p.buffer))
+	} else {
+		tabstop, shiftwidth := p.options.Int(options.TabStop), p.options.Int(options.ShiftWidth)
+		p.folds.SetRanges(fold.Indent(p.buffer, func(line string) int {
+			return GetIndentLevel(line, tabstop, shiftwidth)
+		}))
+	}
+	p.foldVersion = version
+}
+
+// resetFolds recomputes the folds on next use, e.g. after the fold method
+// changed
+func (p *Pane) resetFolds() {
+	p.foldVersion = -1
+	if p.folds.HasClosed() {
+		p.syncFolds(true)
+	}
+}
+
+// handleFoldKey runs the z command ending in r
+func (e *Editor) handleFoldKey(r rune) {
+	p := e.active()
+	p.syncFolds(true)
+	folds := p.folds
+	gutterWidth := e.getGutterWidth()
+	row, _ := e.getCursorScreenPos(gutterWidth, e.textWidth(gutterWidth))
+
+	ok := true
+	switch r {
+	case 'a':
+		ok = folds.Toggle(p.cursorY)
+	case 'o':
+		ok = folds.Open(p.cursorY)
+	case 'c':
+		var closed fold.Range
+		if closed, ok = folds.Close(p.cursorY); ok {
+			p.cursorY = closed.Start
+		}
+	case 'R':
+		folds.OpenAll()
+	case 'M':
+		folds.CloseAll()
+	default:
+		return
+	}
+	if !ok {
+		p.msgManager.SetError("No fold found")
+		return
+	}
+	// The cursor moves to the start of a fold closed over it and keeps its
+	// place on screen
+	if r, hidden := folds.Hiding(p.cursorY); hidden {
+		p.cursorY = r.Start
+	}
+	e.clampCursor()
+	p.visualOffsetY = 0
+	visualRow, _ := e.getCursorScreenPos(gutterWidth, e.textWidth(gutterWidth))
+	p.visualOffsetY = max(0, visualRow-row)
+	e.adjustScroll()
+}
+
+// lineRows returns how many screen rows line n takes: none when a closed
+// fold hides it and one for the line a closed fold is shown on
+func lineRows(p *Pane, n, lineWidth int) int {
+	folds := p.foldSet()
+	if folds.Hidden(n) {
+		return 0
+	}
+	if _, ok := folds.ClosedAt(n); ok {
+		return 1
+	}
+	return wrap.VisualLineCount(p.buffer.Line(n), lineWidth, p.options.Int(options.TabStop))
+}
+
+// lineSegments wraps line n for display. A closed fold shows only the first
+// row of the line it starts on.
+func lineSegments(p *Pane, n, lineWidth int) []wrap.Line {
+	segments := wrap.WrapLine(p.buffer.Line(n), n, lineWidth, p.options.Int(options.TabStop))
+	if _, ok := p.foldSet().ClosedAt(n); ok {
+		segments = segments[:1]
+	}
+	return segments
+}
+
+// renderFoldAt draws the gutter marker and line count of a closed fold
+// after the text shown on its first line
+func (e *Editor) renderFoldAt(rect splitpane.Rect, r fold.Range, screenRow, textWidth, gutterWidth int) {
+	style := e.theme.UI.Folded
+	if gutterWidth > 0 {
+		e.setCellAt(rect, gutterWidth-1, screenRow, '▸', style)
+	}
+	text := fmt.Sprintf(" ··· %d lines", r.End-r.Start)
+	if r.End-r.Start == 1 {
+		text = " ··· 1 line"
+	}
+	x := gutterWidth + textWidth
+	for _, ch := range text {
+		if x >= rect.Width {
+			break
+		}
+		e.setCellAt(rect, x, screenRow, ch, style)
+		x++
+	}
+}
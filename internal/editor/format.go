@@ -0,0 +1,162 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/diff"
+	"github.com/Adelodunpeter25/vx/internal/formatter"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// formatterFor returns the formatter of the pane's filetype, or nil
+func (e *Editor) formatterFor(p *Pane) *formatter.Formatter {
+	if e.formatters == nil {
+		config, err := formatter.LoadConfig()
+		if err != nil {
+			p.msgManager.SetError(utils.FormatUserError(err))
+		}
+		e.formatters = config
+	}
+	return e.formatters[p.options.String(options.Filetype)]
+}
+
+// writeBuffer saves the active buffer, formatting it first while the
+// formatonsave option is on, and lints it after. A formatter that fails
+// stops the save, unless force is set, in which case the file is written
+// as it is and the message says why.
+func (e *Editor) writeBuffer(force bool) (string, error) {
+	p := e.active()
+	buf := p.buffer
+	if buf.Filename() == "" {
+		return "", fmt.Errorf("no file name")
+	}
+
+	var formatErr error
+	if f := e.formatterFor(p); f != nil && p.options.Bool(options.FormatOnSave) {
+		if err := e.formatPane(p, f); err != nil {
+			if !force {
+				return "", fmt.Errorf("%v (:w! writes without formatting)", err)
+			}
+			formatErr = err
+		}
+	}
+
+	if err := buf.Save(); err != nil {
+		return "", err
+	}
+	e.lintOnSave(p)
+	e.reloadGit(buf)
+	e.refreshGitStatus()
+	size, _ := buf.GetFileSize()
+	info := utils.FormatFileInfo(buf.Filename(), size, buf.LineCount())
+	if formatErr != nil {
+		// The file was saved, so :wq! still quits
+		return fmt.Sprintf("%s, written without formatting: %v", info, utils.FormatUserError(formatErr)), nil
+	}
+	return info, nil
+}
+
+// formatBuffer formats the active buffer for :format
+func (e *Editor) formatBuffer() (string, error) {
+	p := e.active()
+	f := e.formatterFor(p)
+	if f == nil {
+		if ft := p.options.String(options.Filetype); ft != "" {
+			return "", fmt.Errorf("no formatter for filetype %s", ft)
+		}
+		return "", fmt.Errorf("no formatter for plain text")
+	}
+	if err := e.formatPane(p, f); err != nil {
+		return "", err
+	}
+	return "Formatted with " + f.Name(), nil
+}
+
+// formatPane pipes the pane's buffer through f and changes only the lines
+// that come out different, as one undo step, so the cursor and everything
+// around the changes stays where it was
+func (e *Editor) formatPane(p *Pane, f *formatter.Formatter) error {
+	buf := p.buffer
+	lines := make([]string, buf.LineCount())
+	for i := range lines {
+		lines[i] = buf.Line(i)
+	}
+
+	p.msgManager.SetPersistent(fmt.Sprintf("Formatting with %s...", f.Name()))
+	e.render()
+	path := buf.Filename()
+	if abs, err := filepath.Abs(path); err == nil && path != "" {
+		path = abs
+	}
+	// Lines don't keep the newline ending the file, which formatters add
+	out, err := f.Run(path, strings.Join(lines, "\n")+"\n")
+	p.msgManager.Clear()
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSuffix(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	edits := diff.Lines(lines, strings.Split(out, "\n"))
+	if len(edits) == 0 {
+		return nil
+	}
+
+	buf.UndoStack().BeginGroup()
+	// From the bottom up, so the edits above keep their line numbers
+	for i := len(edits) - 1; i >= 0; i-- {
+		replaceLines(buf, edits[i])
+	}
+	buf.UndoStack().EndGroup()
+
+	// The cursor moves with its line, or stays as far into a changed block;
+	// on a line only indented anew it stays on the same text
+	oldLine := lines[min(p.cursorY, len(lines)-1)]
+	y, shift := p.cursorY, 0
+	for _, ed := range edits {
+		if ed.Start > y {
+			break
+		}
+		if ed.End > y {
+			y = ed.Start + min(y-ed.Start, max(len(ed.Lines)-1, 0))
+			break
+		}
+		shift += len(ed.Lines) - (ed.End - ed.Start)
+	}
+	p.cursorY = max(0, min(y+shift, buf.LineCount()-1))
+	newLine := buf.Line(p.cursorY)
+	if text := strings.TrimLeft(oldLine, " \t"); text == strings.TrimLeft(newLine, " \t") {
+		oldIndent := lineRuneCount(oldLine) - lineRuneCount(text)
+		newIndent := lineRuneCount(newLine) - lineRuneCount(text)
+		p.cursorX = max(newIndent, p.cursorX-oldIndent+newIndent)
+	}
+	e.clampCursor()
+	e.adjustScroll()
+	return nil
+}
+
+// replaceLines replaces the lines of an edit in buf
+func replaceLines(buf *buffer.Buffer, ed diff.Edit) {
+	text := strings.Join(ed.Lines, "\n")
+	count := buf.LineCount()
+	switch {
+	case ed.End > ed.Start && len(ed.Lines) > 0:
+		last := ed.End - 1
+		buf.ReplaceRange(ed.Start, 0, last, lineRuneCount(buf.Line(last)), text)
+	case ed.End > ed.Start && ed.End < count:
+		buf.ReplaceRange(ed.Start, 0, ed.End, 0, "")
+	case ed.End > ed.Start && ed.Start > 0:
+		// Lines deleted up to the end take the line break before them
+		last := ed.End - 1
+		buf.ReplaceRange(ed.Start-1, lineRuneCount(buf.Line(ed.Start-1)), last, lineRuneCount(buf.Line(last)), "")
+	case ed.End > ed.Start:
+		buf.ReplaceRange(0, 0, ed.End-1, lineRuneCount(buf.Line(ed.End-1)), "")
+	case ed.Start < count:
+		buf.ReplaceRange(ed.Start, 0, ed.Start, 0, text+"\n")
+	default:
+		last := count - 1
+		buf.ReplaceRange(last, lineRuneCount(buf.Line(last)), last, lineRuneCount(buf.Line(last)), "\n"+text)
+	}
+}
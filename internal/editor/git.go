@@ -0,0 +1,251 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/diff"
+	"github.com/Adelodunpeter25/vx/internal/git"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/gdamore/tcell/v2"
+)
+
+// gitDoc is what the git gutter knows about a buffer's file
+type gitDoc struct {
+	path    string   // file name the state was loaded for
+	file    git.File // zero while loading or when untracked
+	index   []string // the file's staged lines; nil while loading or when untracked
+	version int      // buffer version hunks were worked out at
+	hunks   []git.Hunk
+}
+
+// gitLoad is the staged version of a buffer's file, read in the background
+type gitLoad struct {
+	buf   *buffer.Buffer
+	path  string
+	file  git.File
+	index []string
+}
+
+// syncGit starts reading the staged version of the files shown in panes that
+// haven't been read yet
+func (e *Editor) syncGit() {
+	if !e.options.Bool(options.GitGutter) {
+		return
+	}
+	if e.gitDocs == nil {
+		e.gitDocs = make(map[*buffer.Buffer]*gitDoc)
+		e.gitLoads = make(chan gitLoad, 16)
+	}
+	for _, p := range e.panes {
+		buf := p.buffer
+		path := buf.Filename()
+		if path == "" || buf.IsScratch() {
+			continue
+		}
+		if d := e.gitDocs[buf]; d == nil || d.path != path {
+			e.gitDocs[buf] = &gitDoc{path: path, version: -1}
+			e.loadGit(buf, path)
+		}
+	}
+}
+
+// reloadGit reads a saved buffer's file from the index again, as it may
+// have been staged or committed, keeping the old signs until it's read
+func (e *Editor) reloadGit(buf *buffer.Buffer) {
+	if d := e.gitDocs[buf]; d != nil && d.path == buf.Filename() {
+		e.loadGit(buf, d.path)
+	}
+}
+
+// loadGit reads the staged version of path in the background
+func (e *Editor) loadGit(buf *buffer.Buffer, path string) {
+	go func() {
+		load := gitLoad{buf: buf, path: path}
+		if file, err := git.Find(path); err == nil {
+			if index, err := file.Index(); err == nil {
+				load.file, load.index = file, index
+			}
+		}
+		e.gitLoads <- load
+		e.term.Wake()
+	}()
+}
+
+// pollGit picks up the staged versions read in the background
+func (e *Editor) pollGit() bool {
+	changed := false
+	for {
+		select {
+		case load := <-e.gitLoads:
+			if d := e.gitDocs[load.buf]; d != nil && d.path == load.path {
+				d.file, d.index = load.file, load.index
+				d.version = -1
+				changed = true
+			}
+		default:
+			return changed
+		}
+	}
+}
+
+// gitHunks returns where a pane's buffer differs from the index, or nil
+// when its file isn't tracked. Diffing against the index rather than HEAD
+// keeps the hunks in line with what :hunk stage patches.
+func (e *Editor) gitHunks(p *Pane) []git.Hunk {
+	if !e.options.Bool(options.GitGutter) {
+		return nil
+	}
+	d := e.gitDocs[p.buffer]
+	if d == nil || d.index == nil {
+		return nil
+	}
+	if version := p.buffer.ModVersion(); version != d.version {
+		lines := make([]string, p.buffer.LineCount())
+		for i := range lines {
+			lines[i] = p.buffer.Line(i)
+		}
+		d.hunks = git.Hunks(d.index, lines)
+		d.version = version
+	}
+	return d.hunks
+}
+
+// gitTracked reports whether the pane shows a file whose staged version
+// was read, so it has a git sign column
+func (e *Editor) gitTracked(p *Pane) bool {
+	if !e.options.Bool(options.GitGutter) {
+		return false
+	}
+	d := e.gitDocs[p.buffer]
+	return d != nil && d.index != nil
+}
+
+// gitSigns maps lines to the kind of change marked on them, or returns nil
+// when the buffer has no git sign column
+func (e *Editor) gitSigns(p *Pane) map[int]git.Kind {
+	if !e.gitTracked(p) {
+		return nil
+	}
+	signs := make(map[int]git.Kind)
+	for _, h := range e.gitHunks(p) {
+		if h.Kind() == git.Removed {
+			if _, ok := signs[h.SignLine()]; !ok {
+				signs[h.SignLine()] = git.Removed
+			}
+			continue
+		}
+		for i := range h.New {
+			signs[h.NewStart+i] = h.Kind()
+		}
+	}
+	return signs
+}
+
+// gitSign returns the sign and style of a change: + for added lines, ~ for
+// changed ones and _ under the line removed ones followed
+func (e *Editor) gitSign(kind git.Kind) (rune, tcell.Style) {
+	switch kind {
+	case git.Added:
+		return '+', e.theme.UI.GitAdd
+	case git.Changed:
+		return '~', e.theme.UI.GitChange
+	}
+	return '_', e.theme.UI.GitDelete
+}
+
+// hunkAtCursor returns the hunk on the cursor line of the active pane
+func (e *Editor) hunkAtCursor() (git.Hunk, error) {
+	p := e.active()
+	if !e.gitTracked(p) {
+		return git.Hunk{}, fmt.Errorf("not a file tracked by git")
+	}
+	for _, h := range e.gitHunks(p) {
+		if h.Contains(p.cursorY) {
+			return h, nil
+		}
+	}
+	return git.Hunk{}, fmt.Errorf("no change on this line")
+}
+
+// jumpToHunk moves the cursor to the next unstaged change for ]c, or the
+// previous one for [c
+func (e *Editor) jumpToHunk(forward bool) {
+	p := e.active()
+	hunks := e.gitHunks(p)
+	if len(hunks) == 0 {
+		p.msgManager.SetTransient("No changes")
+		return
+	}
+	target := -1
+	if forward {
+		for _, h := range hunks {
+			if h.SignLine() > p.cursorY {
+				target = h.SignLine()
+				break
+			}
+		}
+		if target < 0 {
+			target = hunks[0].SignLine()
+		}
+	} else {
+		for i := len(hunks) - 1; i >= 0; i-- {
+			if hunks[i].SignLine() < p.cursorY {
+				target = hunks[i].SignLine()
+				break
+			}
+		}
+		if target < 0 {
+			target = hunks[len(hunks)-1].SignLine()
+		}
+	}
+	p.selection.Clear()
+	p.cursorY = min(target, p.buffer.LineCount()-1)
+	p.cursorX = 0
+	e.clampCursor()
+	e.adjustScroll()
+}
+
+// hunkCommand runs :hunk, which previews (the default), stages or reverts
+// the change on the cursor line
+func (e *Editor) hunkCommand(arg string) (string, error) {
+	h, err := e.hunkAtCursor()
+	if err != nil {
+		return "", err
+	}
+	p := e.active()
+	switch arg {
+	case "", "preview":
+		e.hover = h.Diff()
+		return "", nil
+	case "stage":
+		d := e.gitDocs[p.buffer]
+		if err := d.file.ApplyCached(h.Patch(d.file.Path)); err != nil {
+			return "", err
+		}
+		// The index now has the hunk's lines, so the hunk is no longer a
+		// change and the ones after it are worked out against the new index
+		index := append(append(slices.Clone(d.index[:h.OldStart]), h.New...), d.index[h.OldStart+len(h.Old):]...)
+		d.index, d.version = index, -1
+		e.refreshGitStatus()
+		return "Hunk staged", nil
+	case "revert", "undo":
+		e.revertHunk(p, h)
+		return "Hunk reverted", nil
+	}
+	return "", fmt.Errorf("unknown :hunk argument: %s (preview, stage or revert)", arg)
+}
+
+// revertHunk gives the lines of a hunk back their staged version, as one undo
+// step
+func (e *Editor) revertHunk(p *Pane, h git.Hunk) {
+	buf := p.buffer
+	buf.UndoStack().BeginGroup()
+	replaceLines(buf, diff.Edit{Start: h.NewStart, End: h.NewStart + len(h.New), Lines: h.Old})
+	buf.UndoStack().EndGroup()
+	p.cursorY = min(h.NewStart, buf.LineCount()-1)
+	p.cursorX = 0
+	e.clampCursor()
+	e.adjustScroll()
+}
@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/git"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// gitLog is a :Glog list of the commits of a file, one per line of its
+// read-only buffer
+type gitLog struct {
+	file    git.File
+	commits []git.Commit
+	source  *Pane // pane :Glog was run in, where commits open
+}
+
+// showGitLog lists the commits of the active buffer's file in a pane,
+// reusing the pane of an earlier list
+func (e *Editor) showGitLog() error {
+	p := e.active()
+	if p.buffer.Filename() == "" || p.buffer.IsScratch() {
+		return fmt.Errorf("no file name")
+	}
+	file, err := git.Find(p.buffer.Filename())
+	if err != nil {
+		return err
+	}
+	commits, err := file.Log()
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("%s has no commits", file.Path)
+	}
+
+	lines := make([]string, len(commits))
+	for i, c := range commits {
+		lines[i] = fmt.Sprintf("%s %s %-*s %s", c.Short(), c.Time.Format("2006-01-02"),
+			blameAuthorWidth, shortAuthor(c.Author), c.Subject)
+	}
+	buf := buffer.NewReadOnly("[log] "+file.Path, lines)
+	if e.gitLogs == nil {
+		e.gitLogs = make(map[*buffer.Buffer]*gitLog)
+	}
+	e.gitLogs[buf] = &gitLog{file: file, commits: commits, source: p}
+
+	if q := e.logPane(); q != nil {
+		delete(e.gitLogs, q.buffer)
+		q.setBuffer(buf)
+		e.focusPane(q)
+	} else {
+		e.addPaneWithBuffer(buf, buf.Filename())
+		e.invalidateAll()
+	}
+	n := "1 commit"
+	if len(commits) > 1 {
+		n = fmt.Sprintf("%d commits", len(commits))
+	}
+	e.active().msgManager.SetTransient(n + ", Enter opens one")
+	return nil
+}
+
+// logPane returns the pane showing a :Glog list, or nil
+func (e *Editor) logPane() *Pane {
+	for _, p := range e.panes {
+		if e.gitLogs[p.buffer] != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// focusPane makes p the active pane
+func (e *Editor) focusPane(p *Pane) {
+	for i, q := range e.panes {
+		if q == p {
+			e.activePane = i
+		}
+	}
+}
+
+// openLogEntry opens the commit under the cursor of a :Glog list, showing
+// the file as the commit left it, read-only, in the pane the list was made
+// from. It reports false when the active pane isn't a :Glog list.
+func (e *Editor) openLogEntry() bool {
+	p := e.active()
+	log := e.gitLogs[p.buffer]
+	if log == nil {
+		return false
+	}
+	if p.cursorY >= len(log.commits) {
+		return true
+	}
+	c := log.commits[p.cursorY]
+	lines, err := log.file.At(c)
+	if err != nil {
+		p.msgManager.SetError(utils.FormatUserError(err))
+		return true
+	}
+
+	target := p
+	for _, q := range e.panes {
+		if q == log.source && q != p {
+			target = q
+		}
+	}
+	target.setBuffer(buffer.NewReadOnly(c.Short()+":"+c.Path, lines))
+	e.focusPane(target)
+	target.msgManager.SetPersistent(fmt.Sprintf("%s at %s, %s (read-only)", c.Path, c.Short(), c.Subject))
+	return true
+}
+
+// shortAuthor cuts an author's name to the width of the author columns
+func shortAuthor(name string) string {
+	if runes := []rune(name); len(runes) > blameAuthorWidth {
+		return string(runes[:blameAuthorWidth])
+	}
+	return name
+}
@@ -0,0 +1,236 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
+	"github.com/Adelodunpeter25/vx/internal/git"
+	"github.com/Adelodunpeter25/vx/internal/options"
+)
+
+// watchInterval is how often the files shown in the file browser are
+// checked for changes made outside the editor
+const watchInterval = 2 * time.Second
+
+// gitStatus is the git status of the file browser's files, loaded in the
+// background
+type gitStatus struct {
+	root    string          // browser root the status is for
+	list    *git.StatusList // nil outside repositories and until loaded
+	running bool
+	again   bool // load again when the running load finishes
+	closed  bool // the browser was closed, so the watcher is stopped
+	results chan statusResult
+	watcher *fileWatcher
+}
+
+type statusResult struct {
+	root string
+	list *git.StatusList
+}
+
+// fileWatcher stats a set of paths now and then, to tell when files were
+// changed, created or deleted. It only polls while it has paths to check.
+type fileWatcher struct {
+	mu      sync.Mutex
+	paths   []string
+	reset   bool // the paths changed, so their fingerprint did too
+	changed chan struct{}
+	wake    func()
+	cancel  context.CancelFunc // stops the running poll, nil when stopped
+}
+
+// syncGitStatus loads the git status of a new file browser root, and
+// watches the files it shows while the browser is open
+func (e *Editor) syncGitStatus() {
+	fb := e.fileBrowser
+	if fb == nil || !fb.Open {
+		if e.status != nil && !e.status.closed {
+			e.status.closed = true
+			e.status.watcher.stop()
+		}
+		return
+	}
+	fb.Decorate = e.decorateNode
+	if e.status == nil {
+		e.status = &gitStatus{
+			results: make(chan statusResult, 1),
+			watcher: &fileWatcher{changed: make(chan struct{}, 1), wake: e.term.Wake},
+		}
+	}
+	if e.status.root != fb.RootPath {
+		e.status.watcher.stop()
+		e.status.root = fb.RootPath
+		e.status.list = nil
+		e.refreshGitStatus()
+	} else if e.status.closed {
+		// Files may have changed while the browser was closed
+		fb.Refresh()
+		e.refreshGitStatus()
+	}
+	e.status.closed = false
+	e.watchBrowserFiles()
+}
+
+// watchBrowserFiles has the watcher check the files the browser has read,
+// and the index and HEAD of their repository for commits and staging
+func (e *Editor) watchBrowserFiles() {
+	if e.status.closed {
+		return
+	}
+	paths := e.fileBrowser.LoadedPaths()
+	if l := e.status.list; l != nil {
+		paths = append(paths, filepath.Join(l.GitDir, "index"), filepath.Join(l.GitDir, "HEAD"))
+	}
+	e.status.watcher.watch(paths)
+}
+
+// refreshGitStatus loads the git status of the browser root again in the
+// background, after the one running if there is one
+func (e *Editor) refreshGitStatus() {
+	s := e.status
+	if s == nil || s.root == "" {
+		return
+	}
+	if s.running {
+		s.again = true
+		return
+	}
+	s.running = true
+	root := s.root
+	go func() {
+		list, _ := git.LoadStatus(root)
+		s.results <- statusResult{root: root, list: list}
+		e.term.Wake()
+	}()
+}
+
+// pollGitStatus picks up a loaded status, and reloads the browser and the
+// status when the watcher saw files change
+func (e *Editor) pollGitStatus() bool {
+	s := e.status
+	if s == nil {
+		return false
+	}
+	changed := false
+	select {
+	case res := <-s.results:
+		s.running = false
+		if res.root == s.root {
+			s.list = res.list
+			e.watchBrowserFiles()
+			changed = true
+		}
+		if s.again || res.root != s.root {
+			s.again = false
+			e.refreshGitStatus()
+		}
+	default:
+	}
+	select {
+	case <-s.watcher.changed:
+		if e.fileBrowser != nil {
+			e.fileBrowser.Refresh()
+			e.watchBrowserFiles()
+		}
+		e.refreshGitStatus()
+		changed = true
+	default:
+	}
+	return changed
+}
+
+// decorateNode colors a file browser node by its git status and gives it
+// the status letter, hiding ignored files unless showignored is on
+func (e *Editor) decorateNode(node *filebrowser.Node) filebrowser.Decoration {
+	if e.status == nil || e.status.list == nil {
+		return filebrowser.Decoration{}
+	}
+	status := e.status.list.Of(node.Path, node.IsDir)
+	d := filebrowser.Decoration{Code: status.Code()}
+	switch status {
+	case git.StatusIgnored:
+		d.Style = e.theme.UI.NonText
+		d.Hide = !e.options.Bool(options.ShowIgnored)
+	case git.StatusUntracked, git.StatusAdded:
+		d.Style = e.theme.UI.GitAdd
+	case git.StatusModified:
+		d.Style = e.theme.UI.GitChange
+	case git.StatusConflicted:
+		d.Style = e.theme.UI.Error
+	}
+	return d
+}
+
+// watch replaces the paths the watcher checks, starting the poll when
+// there are some and stopping it when there are none
+func (w *fileWatcher) watch(paths []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !slices.Equal(w.paths, paths) {
+		w.paths = paths
+		w.reset = true
+	}
+	switch {
+	case len(paths) == 0 && w.cancel != nil:
+		w.cancel()
+		w.cancel = nil
+	case len(paths) > 0 && w.cancel == nil:
+		ctx, cancel := context.WithCancel(context.Background())
+		w.cancel = cancel
+		go w.run(ctx)
+	}
+}
+
+// stop ends the poll until paths are watched again
+func (w *fileWatcher) stop() {
+	w.watch(nil)
+}
+
+// run checks the paths every watchInterval until ctx is cancelled,
+// signalling when their sizes or modification times changed
+func (w *fileWatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	var last uint64
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		w.mu.Lock()
+		paths, reset := w.paths, w.reset
+		w.reset = false
+		w.mu.Unlock()
+		sum := fingerprint(paths)
+		if !reset && last != 0 && sum != last {
+			select {
+			case w.changed <- struct{}{}:
+			default:
+			}
+			w.wake()
+		}
+		last = sum
+	}
+}
+
+// fingerprint hashes the paths with their sizes and modification times
+func fingerprint(paths []string) uint64 {
+	h := fnv.New64a()
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(h, "%s -\n", path)
+		}
+	}
+	return h.Sum64()
+}
@@ -1,63 +1,54 @@
 package editor
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"strings"
 
-// GetIndentLevel returns the indentation level of a line (number of leading tabs/spaces)
-func GetIndentLevel(line string) int {
-	level := 0
+	"github.com/Adelodunpeter25/vx/internal/options"
+	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
+	"github.com/Adelodunpeter25/vx/internal/wrap"
+)
+
+// GetIndentLevel returns the indentation level of a line. Tabs advance to the
+// next multiple of tabstop and every shiftwidth columns count as one level.
+func GetIndentLevel(line string, tabstop, shiftwidth int) int {
+	if tabstop < 1 {
+		tabstop = 1
+	}
+	if shiftwidth < 1 {
+		shiftwidth = 1
+	}
+	cols := 0
 	for _, r := range line {
 		if r == '\t' {
-			level++
+			cols += tabstop - cols%tabstop
 		} else if r == ' ' {
-			// Count 2 spaces as 1 indent level (configurable)
-			level++
-			if level%2 == 0 {
-				continue
-			}
+			cols++
 		} else {
 			break
 		}
 	}
-	return level / 2 // Normalize spaces to tab equivalents
-}
-
-// DrawIndentGuides draws vertical lines at indentation boundaries
-func (e *Editor) drawIndentGuides(y int, line string, maxIndent int, gutterWidth int) {
-	// Draw guides for each indent level
-	runes := []rune(line)
-	for i := 0; i < maxIndent; i++ {
-		x := i * 2 // 2 spaces per indent level
-		if x < len(runes) {
-			char := runes[x]
-			// Only draw guide if this position is whitespace
-			if char == ' ' || char == '\t' {
-				style := tcell.StyleDefault.Foreground(tcell.ColorGray).Dim(true)
-				screenX := x - e.active().offsetX + gutterWidth
-				if screenX >= gutterWidth && screenX < e.width {
-					e.term.SetCell(screenX, y, '│', style)
-				}
-			}
-		}
-	}
+	return cols / shiftwidth
 }
 
-// GetMaxIndentInView returns the maximum indent level in visible lines
-func (e *Editor) getMaxIndentInView() int {
-	p := e.active()
-	maxIndent := 0
-	contentHeight := e.height - 1
+// drawIndentGuidesAt draws a guide at every shiftwidth columns of a line's
+// leading whitespace. Guides are placed by display column, so tabs count
+// up to the next tabstop like they are drawn.
+func (e *Editor) drawIndentGuidesAt(rect splitpane.Rect, p *Pane, screenRow int, seg wrap.Line, gutterWidth int) {
+	line := p.buffer.Line(seg.LineNum)
+	tabstop := p.options.Int(options.TabStop)
+	shiftwidth := p.options.Int(options.ShiftWidth)
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	cells := wrap.Cells(line, tabstop)
 
-	for i := 0; i < contentHeight; i++ {
-		lineNum := p.offsetY + i
-		if lineNum >= p.buffer.LineCount() {
-			break
-		}
-		line := p.buffer.Line(lineNum)
-		indent := GetIndentLevel(line)
-		if indent > maxIndent {
-			maxIndent = indent
+	// The display column drawn at the segment's first cell, which horizontal
+	// scrolling moves right
+	base := cells[seg.StartCol] - seg.X(seg.StartCol)
+	style := e.theme.UI.IndentGuide
+	for col := 0; col < cells[indent]; col += shiftwidth {
+		x := col - base
+		if x < 0 || x >= seg.Width() || gutterWidth+x >= rect.Width {
+			continue
 		}
+		e.setCellAt(rect, gutterWidth+x, screenRow, '│', style)
 	}
-
-	return maxIndent
 }
@@ -16,6 +16,23 @@ func (e *Editor) handleInsertMode(ev *terminal.Event) {
 		return
 	}
 
+	// A snippet being filled in follows each key, whose edits and those to
+	// its mirrors undo together
+	if e.snippet != nil {
+		p.buffer.UndoStack().BeginGroup()
+		defer p.buffer.UndoStack().EndGroup()
+		defer e.syncSnippet()
+	}
+
+	// The completion menu takes its keys first, and follows what is typed
+	if e.handleCompletionKey(ev) {
+		return
+	}
+	if e.handleSnippetKey(ev) {
+		return
+	}
+	defer e.updateCompletion(ev)
+
 	if ev.Key == tcell.KeyEscape {
 		p.mode = ModeNormal
 		if p.cursorX > 0 {
@@ -25,34 +42,18 @@ func (e *Editor) handleInsertMode(ev *terminal.Event) {
 	}
 
 	if ev.Key == tcell.KeyTab {
-		p.buffer.InsertRune(p.cursorY, p.cursorX, '\t')
-		p.cursorX++
+		e.insertTab()
 		return
 	}
 
 	if ev.Key == tcell.KeyEnter {
-		// Get current line indentation
-		currentLine := p.buffer.Line(p.cursorY)
-		indent := getIndentation(currentLine)
-
-		p.buffer.SplitLine(p.cursorY, p.cursorX)
-		p.cursorY++
-		p.cursorX = 0
-
-		// Auto-indent: insert same indentation on new line
-		for _, r := range indent {
-			p.buffer.InsertRune(p.cursorY, p.cursorX, r)
-			p.cursorX++
-		}
-
-		e.adjustScroll()
+		e.insertNewline()
 		return
 	}
 
 	if ev.Key == tcell.KeyBackspace || ev.Key == tcell.KeyBackspace2 {
 		if p.cursorX > 0 {
-			p.buffer.DeleteRune(p.cursorY, p.cursorX)
-			p.cursorX--
+			e.deleteBefore()
 			e.adjustScroll()
 		} else if p.cursorY > 0 {
 			prevLen := lineRuneCount(p.buffer.Line(p.cursorY - 1))
@@ -80,14 +81,14 @@ func (e *Editor) handleInsertMode(ev *terminal.Event) {
 		return
 	case tcell.KeyUp:
 		if p.cursorY > 0 {
-			p.cursorY--
+			p.cursorY = p.foldSet().Prev(p.cursorY)
 			e.adjustScroll()
 			e.clampCursor()
 		}
 		return
 	case tcell.KeyDown:
-		if p.cursorY < p.buffer.LineCount()-1 {
-			p.cursorY++
+		if next := p.foldSet().Next(p.cursorY); next < p.buffer.LineCount() {
+			p.cursorY = next
 			e.adjustScroll()
 			e.clampCursor()
 		}
@@ -95,8 +96,7 @@ func (e *Editor) handleInsertMode(ev *terminal.Event) {
 	}
 
 	if ev.Rune != 0 {
-		p.buffer.InsertRune(p.cursorY, p.cursorX, ev.Rune)
-		p.cursorX++
+		e.insertRune(ev.Rune)
 		e.adjustScroll()
 	}
 }
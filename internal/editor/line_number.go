@@ -3,24 +3,45 @@ package editor
 import (
 	"fmt"
 
-	"github.com/gdamore/tcell/v2"
+	"github.com/Adelodunpeter25/vx/internal/options"
 )
 
 func (e *Editor) getGutterWidth() int {
 	return e.getGutterWidthFor(e.active())
 }
 
+// signWidth is the width of the diagnostic sign column, shown before the
+// line numbers while a buffer has diagnostics
+const signWidth = 2
+
+// gitSignWidth is the width of the git sign column, shown first for files
+// tracked in git
+const gitSignWidth = 1
+
 func (e *Editor) getGutterWidthFor(p *Pane) int {
 	if p == nil {
 		return 2
 	}
-	lineCount := p.buffer.LineCount()
-	return len(fmt.Sprintf("%d", lineCount)) + 1 // +1 for spacing
+	width := 0
+	if p.options.Bool(options.Number) {
+		lineCount := p.buffer.LineCount()
+		width = len(fmt.Sprintf("%d", lineCount)) + 1 // +1 for spacing
+	}
+	if len(e.diagnosticsFor(p)) > 0 {
+		width += signWidth
+	}
+	if e.gitTracked(p) {
+		width += gitSignWidth
+	}
+	if p.blame != nil {
+		width += blameWidth
+	}
+	return width
 }
 
 func (e *Editor) renderLineNumbers(contentHeight int) {
 	gutterWidth := e.getGutterWidth()
-	style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(100, 100, 100))
+	style := e.theme.UI.LineNumber
 
 	for i := 0; i < contentHeight; i++ {
 		lineNum := e.active().offsetY + i
@@ -37,7 +58,7 @@ func (e *Editor) renderLineNumbers(contentHeight int) {
 
 // renderLineNumber renders a single line number at the given screen row
 func (e *Editor) renderLineNumber(screenRow, lineNum, gutterWidth int) {
-	style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(100, 100, 100))
+	style := e.theme.UI.LineNumber
 	numStr := fmt.Sprintf("%*d ", gutterWidth-1, lineNum+1)
 	for x, r := range numStr {
 		e.term.SetCell(x, screenRow, r, style)
@@ -0,0 +1,254 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+	"github.com/Adelodunpeter25/vx/internal/lint"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// lintJob is a linter running on a saved buffer
+type lintJob struct {
+	pane    *Pane
+	name    string
+	manual  bool // run with :lint, which reports when nothing was found
+	version int  // buffer version of the saved file being linted
+	cancel  context.CancelFunc
+	result  chan lintResult
+}
+
+type lintResult struct {
+	issues []lint.Issue
+	err    error
+}
+
+// lintDoc holds the problems the last lint of a buffer found
+type lintDoc struct {
+	version     int // buffer version the diagnostics' lines are at
+	diagnostics []diagnostic.Diagnostic
+}
+
+// linterFor returns the linter of the pane's filetype, or nil
+func (e *Editor) linterFor(p *Pane) *lint.Linter {
+	if e.linters == nil {
+		config, err := lint.LoadConfig()
+		if err != nil {
+			p.msgManager.SetError(utils.FormatUserError(err))
+		}
+		e.linters = config
+	}
+	return e.linters[p.options.String(options.Filetype)]
+}
+
+// lintOnSave lints a pane's buffer after it was written, while the
+// lintonsave option is on
+func (e *Editor) lintOnSave(p *Pane) {
+	if l := e.linterFor(p); l != nil && p.options.Bool(options.LintOnSave) {
+		e.startLint(p, l, false)
+	}
+}
+
+// lintBuffer lints the active buffer for :lint, as it is on disk
+func (e *Editor) lintBuffer() (string, error) {
+	p := e.active()
+	l := e.linterFor(p)
+	if l == nil {
+		if ft := p.options.String(options.Filetype); ft != "" {
+			return "", fmt.Errorf("no linter for filetype %s", ft)
+		}
+		return "", fmt.Errorf("no linter for plain text")
+	}
+	if p.buffer.Filename() == "" {
+		return "", fmt.Errorf("no file name")
+	}
+	e.startLint(p, l, true)
+	msg := fmt.Sprintf("Linting with %s...", l.Name())
+	if p.buffer.IsModified() {
+		msg += " (unsaved changes are not linted)"
+	}
+	return msg, nil
+}
+
+// startLint runs a linter on the file of a pane's buffer in the background,
+// in place of one still running on it
+func (e *Editor) startLint(p *Pane, l *lint.Linter, manual bool) {
+	buf := p.buffer
+	path, err := filepath.Abs(buf.Filename())
+	if err != nil {
+		return
+	}
+	if e.lintJobs == nil {
+		e.lintJobs = make(map[*buffer.Buffer]*lintJob)
+	}
+	if job := e.lintJobs[buf]; job != nil {
+		job.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &lintJob{pane: p, name: l.Name(), manual: manual, version: buf.ModVersion(), cancel: cancel, result: make(chan lintResult, 1)}
+	e.lintJobs[buf] = job
+	go func() {
+		issues, err := l.Run(ctx, path)
+		job.result <- lintResult{issues, err}
+		e.term.Wake()
+	}()
+}
+
+// pollLint picks up the results of finished linters
+func (e *Editor) pollLint() bool {
+	changed := false
+	for buf, job := range e.lintJobs {
+		var res lintResult
+		select {
+		case res = <-job.result:
+		default:
+			continue
+		}
+		delete(e.lintJobs, buf)
+		changed = true
+		p := job.pane
+		if res.err != nil {
+			p.msgManager.SetError(utils.FormatUserError(res.err))
+			continue
+		}
+
+		if e.lintResults == nil {
+			e.lintResults = make(map[*buffer.Buffer]*lintDoc)
+		}
+		list := make([]diagnostic.Diagnostic, len(res.issues))
+		for i, issue := range res.issues {
+			list[i] = issueDiagnostic(buf, issue, job.name)
+		}
+		diagnostic.Sort(list)
+		e.lintResults[buf] = &lintDoc{version: job.version, diagnostics: list}
+		switch {
+		case !job.manual:
+		case len(list) == 0:
+			p.msgManager.SetTransient(fmt.Sprintf("%s found no problems", job.name))
+		case len(list) == 1:
+			p.msgManager.SetTransient(fmt.Sprintf("%s found 1 problem", job.name))
+		default:
+			p.msgManager.SetTransient(fmt.Sprintf("%s found %d problems", job.name, len(list)))
+		}
+	}
+	return changed
+}
+
+// lintDiagnostics returns the problems the last lint of a buffer found,
+// moved along with the lines added and removed since. Those on removed
+// lines are dropped, and all of them when the edits can't be followed.
+func (e *Editor) lintDiagnostics(buf *buffer.Buffer) []diagnostic.Diagnostic {
+	d := e.lintResults[buf]
+	if d == nil {
+		return nil
+	}
+	version := buf.ModVersion()
+	if version == d.version {
+		return d.diagnostics
+	}
+	changes, ok := buf.ChangesSince(d.version)
+	if !ok {
+		delete(e.lintResults, buf)
+		return nil
+	}
+	for _, c := range changes {
+		d.diagnostics = shiftDiagnostics(d.diagnostics, c)
+	}
+	d.version = version
+	return d.diagnostics
+}
+
+// shiftDiagnostics moves single-line diagnostics for an edit, dropping
+// those on the lines it removed. A deleted line is logged as a change to
+// the line taking its place, so removing lines drops that line's too.
+func shiftDiagnostics(list []diagnostic.Diagnostic, c buffer.Change) []diagnostic.Diagnostic {
+	removed := max(-c.Delta, 0)
+	shifted := make([]diagnostic.Diagnostic, 0, len(list))
+	for _, d := range list {
+		switch {
+		case d.Line < c.Line || d.Line == c.Line && removed == 0:
+		case d.Line <= c.Line+removed:
+			continue
+		default:
+			d.Line += c.Delta
+			d.EndLine += c.Delta
+		}
+		shifted = append(shifted, d)
+	}
+	return shifted
+}
+
+// issueDiagnostic places a linter's issue in the buffer. An issue with a
+// column covers the word there, one without covers its line.
+func issueDiagnostic(buf *buffer.Buffer, issue lint.Issue, source string) diagnostic.Diagnostic {
+	d := diagnostic.Diagnostic{
+		Line:     issue.Line,
+		EndLine:  issue.Line,
+		Severity: issue.Severity,
+		Source:   source,
+		Message:  issue.Message,
+	}
+	line := ""
+	if issue.Line < buf.LineCount() {
+		line = buf.Line(issue.Line)
+	}
+	if issue.Col < 0 {
+		d.Col = lineRuneCount(line) - lineRuneCount(strings.TrimLeft(line, " \t"))
+		d.EndCol = lineRuneCount(line)
+		return d
+	}
+	// Linters count columns in bytes
+	col := utf8.RuneCountInString(line[:min(issue.Col, len(line))])
+	d.Col, d.EndCol = diagnosticSpan(line, col)
+	return d
+}
+
+// diagnosticSpan returns the range a diagnostic given only a position
+// covers: the word there, or the character, or the last one when the
+// position is past the end of the line
+func diagnosticSpan(line string, col int) (start, end int) {
+	runes := []rune(line)
+	if len(runes) == 0 {
+		return 0, 0
+	}
+	col = min(col, len(runes)-1)
+	end = col + 1
+	if isWordRune(runes[col]) {
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+	}
+	return col, end
+}
+
+// jumpToDiagnostic moves the cursor to the next diagnostic for ]d, or the
+// previous one for [d, clearing the message so the status bar shows its own
+func (e *Editor) jumpToDiagnostic(forward bool) {
+	p := e.active()
+	list := append([]diagnostic.Diagnostic(nil), e.diagnosticsFor(p)...)
+	diagnostic.Sort(list)
+	var d diagnostic.Diagnostic
+	var ok bool
+	if forward {
+		d, ok = diagnostic.Next(list, p.cursorY, p.cursorX)
+	} else {
+		d, ok = diagnostic.Prev(list, p.cursorY, p.cursorX)
+	}
+	if !ok {
+		p.msgManager.SetTransient("No diagnostics")
+		return
+	}
+	p.selection.Clear()
+	p.msgManager.Clear()
+	p.cursorY = max(0, min(d.Line, p.buffer.LineCount()-1))
+	p.cursorX = d.Col
+	e.clampCursor()
+	e.adjustScroll()
+}
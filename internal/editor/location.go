@@ -0,0 +1,56 @@
+package editor
+
+import (
+	"path/filepath"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+)
+
+// jumpToLocation opens path in the active pane, unless it is already shown
+// there or is "", and puts the cursor at line/col
+func (e *Editor) jumpToLocation(path string, line, col int) bool {
+	p := e.active()
+	if path != "" && !samePath(p.buffer.Filename(), path) {
+		if p.buffer.IsModified() {
+			p.msgManager.SetError("No write since last change (use :e! to override)")
+			return false
+		}
+		newBuf, err := buffer.Load(path)
+		if err != nil {
+			p.msgManager.SetError("Error: " + err.Error())
+			return false
+		}
+		p.setBuffer(newBuf)
+	}
+	e.moveCursorTo(line, col)
+	return true
+}
+
+// moveCursorTo moves the cursor within the active buffer and scrolls to it
+func (e *Editor) moveCursorTo(line, col int) {
+	p := e.active()
+	p.selection.Clear()
+	p.cursorY = line
+	p.cursorX = col
+	if p.cursorY >= p.buffer.LineCount() {
+		p.cursorY = p.buffer.LineCount() - 1
+	}
+	if p.cursorY < 0 {
+		p.cursorY = 0
+	}
+	e.clampCursor()
+	e.adjustScroll()
+}
+
+// samePath reports whether two file names refer to the same file
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
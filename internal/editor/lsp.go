@@ -0,0 +1,412 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+	"github.com/Adelodunpeter25/vx/internal/lsp"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+const (
+	lspTimeout      = 10 * time.Second // for requests
+	lspStartTimeout = 30 * time.Second // for starting and initializing a server
+)
+
+// lspState holds the language servers and the buffers open in them
+type lspState struct {
+	config  lsp.Config
+	stopped bool                       // servers were turned off with :lsp stop
+	servers map[string]*lspServer      // by command and root
+	docs    map[*buffer.Buffer]*lspDoc // buffers open in a server
+
+	mu      sync.Mutex
+	replies []func() // results waiting to run on the main loop
+	dirty   bool     // diagnostics arrived
+}
+
+// lspServer is a language server started for a project root
+type lspServer struct {
+	name   string
+	root   string
+	client *lsp.Client // nil while starting
+	err    error
+}
+
+// lspDoc is a buffer open in a language server
+type lspDoc struct {
+	server      *lspServer
+	doc         *lsp.Document
+	path        string
+	filetype    string
+	version     int  // buffer version last sent
+	modified    bool // whether the buffer was modified at the last sync, to notice saves
+	diagnostics []diagnostic.Diagnostic
+}
+
+// lspState returns the language server state, loading the configuration
+// the first time
+func (e *Editor) lspState() *lspState {
+	if e.lsp != nil {
+		return e.lsp
+	}
+	config, err := lsp.LoadConfig()
+	if err != nil {
+		e.active().msgManager.SetError(utils.FormatUserError(err))
+	}
+	e.lsp = &lspState{
+		config:  config,
+		servers: make(map[string]*lspServer),
+		docs:    make(map[*buffer.Buffer]*lspDoc),
+	}
+	return e.lsp
+}
+
+// lspPost queues fn to run on the main loop
+func (e *Editor) lspPost(fn func()) {
+	s := e.lsp
+	s.mu.Lock()
+	s.replies = append(s.replies, fn)
+	s.mu.Unlock()
+	e.term.Wake()
+}
+
+// pollLSP runs the results of finished requests and picks up new
+// diagnostics
+func (e *Editor) pollLSP() bool {
+	s := e.lsp
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	replies, dirty := s.replies, s.dirty
+	s.replies, s.dirty = nil, false
+	s.mu.Unlock()
+
+	for _, fn := range replies {
+		fn()
+	}
+	if dirty {
+		for buf, d := range s.docs {
+			if c := d.server.client; c != nil {
+				d.diagnostics = lspDiagnostics(buf, c.Encoding(), c.Diagnostics(d.doc.URI))
+			}
+		}
+	}
+	return len(replies) > 0 || dirty
+}
+
+// syncLSP opens the buffers shown in panes in their language servers, sends
+// the edits made since the last sync and closes buffers no longer shown
+func (e *Editor) syncLSP() {
+	s := e.lspState()
+	shown := make(map[*buffer.Buffer]bool)
+	for _, p := range e.panes {
+		if !shown[p.buffer] {
+			shown[p.buffer] = true
+			e.syncDocument(p)
+		}
+	}
+	for buf, d := range s.docs {
+		if !shown[buf] {
+			s.closeDocument(buf, d)
+		}
+	}
+}
+
+// syncDocument brings a pane's buffer up to date in its language server and
+// returns it, or nil if the buffer has no running server
+func (e *Editor) syncDocument(p *Pane) *lspDoc {
+	s := e.lspState()
+	buf := p.buffer
+	filetype := p.syntax.Language()
+	d := s.docs[buf]
+	if d != nil && (d.filetype != filetype || d.path != buf.Filename() || d.server.client == nil) {
+		s.closeDocument(buf, d)
+		d = nil
+	}
+	if d == nil {
+		server := e.serverFor(buf.Filename(), filetype)
+		if server == nil || server.client == nil {
+			return nil
+		}
+		client := server.client
+		d = &lspDoc{
+			server:   server,
+			path:     buf.Filename(),
+			filetype: filetype,
+			version:  buf.ModVersion(),
+			modified: buf.IsModified(),
+		}
+		languageID := s.config[filetype].LanguageIDFor(filetype)
+		d.doc = client.Open(lsp.URIFromPath(buf.Filename()), languageID, buf)
+		d.diagnostics = lspDiagnostics(buf, client.Encoding(), client.Diagnostics(d.doc.URI))
+		s.docs[buf] = d
+		return d
+	}
+
+	client := d.server.client
+	if version := buf.ModVersion(); version != d.version {
+		from := 0
+		if changes, ok := buf.ChangesSince(d.version); ok {
+			from = buf.LineCount()
+			for _, c := range changes {
+				from = min(from, c.Line)
+			}
+		}
+		client.Change(d.doc, buf, from)
+		d.version = version
+	}
+	if d.modified && !buf.IsModified() {
+		client.Save(d.doc)
+	}
+	d.modified = buf.IsModified()
+	return d
+}
+
+func (s *lspState) closeDocument(buf *buffer.Buffer, d *lspDoc) {
+	if d.server.client != nil {
+		d.server.client.Close(d.doc)
+	}
+	delete(s.docs, buf)
+}
+
+// serverFor returns the server for a file, starting it the first time. It
+// returns nil if there is none or it is still starting.
+func (e *Editor) serverFor(path, filetype string) *lspServer {
+	s := e.lsp
+	config := s.config[filetype]
+	if s.stopped || path == "" || config == nil {
+		return nil
+	}
+	fallback, _ := e.grepRoot("")
+	root := config.FindRoot(path, fallback)
+	key := strings.Join(config.Command, " ") + "\x00" + root
+	server := s.servers[key]
+	if server == nil {
+		server = &lspServer{name: config.Name(), root: root}
+		s.servers[key] = server
+		e.startServer(key, server, config)
+	}
+	return server
+}
+
+// startServer starts and initializes a server in the background
+func (e *Editor) startServer(key string, server *lspServer, config *lsp.Server) {
+	s := e.lsp
+	go func() {
+		client, err := lsp.Start(config, server.root)
+		if err == nil {
+			client.OnDiagnostics = func(string) {
+				s.mu.Lock()
+				s.dirty = true
+				s.mu.Unlock()
+				e.term.Wake()
+			}
+			client.OnMessage = func(severity int, text string) {
+				if severity == 1 {
+					e.lspPost(func() {
+						e.active().msgManager.SetError(server.name + ": " + text)
+					})
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), lspStartTimeout)
+			err = client.Initialize(ctx, config.InitOptions)
+			cancel()
+			if err != nil {
+				client.Shutdown(context.Background())
+			}
+		}
+		e.lspPost(func() {
+			if s.servers[key] != server {
+				// Stopped while starting
+				if err == nil {
+					go client.Shutdown(context.Background())
+				}
+				return
+			}
+			if err != nil {
+				server.err = err
+				e.active().msgManager.SetError(fmt.Sprintf("%s: %v", server.name, err))
+				return
+			}
+			server.client = client
+		})
+	}()
+}
+
+// stopLSP shuts all language servers down
+func (e *Editor) stopLSP() {
+	s := e.lsp
+	if s == nil {
+		return
+	}
+	for buf, d := range s.docs {
+		s.closeDocument(buf, d)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for key, server := range s.servers {
+		delete(s.servers, key)
+		if server.client == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(c *lsp.Client) {
+			defer wg.Done()
+			c.Shutdown(ctx)
+		}(server.client)
+	}
+	wg.Wait()
+}
+
+// lspCommand runs :lsp, which shows the server of the buffer, and :lsp
+// restart and :lsp stop
+func (e *Editor) lspCommand(arg string) (string, error) {
+	s := e.lspState()
+	p := e.active()
+	switch arg {
+	case "":
+		if d := e.syncDocument(p); d != nil {
+			return fmt.Sprintf("%s running in %s", d.server.name, abbreviateHome(d.server.root)), nil
+		}
+		return "", fmt.Errorf("%s", e.lspUnavailable(p))
+	case "stop":
+		e.stopLSP()
+		s.stopped = true
+		e.invalidateAll()
+		return "Language servers stopped", nil
+	case "restart":
+		e.stopLSP()
+		config, err := lsp.LoadConfig()
+		if err != nil {
+			return "", err
+		}
+		s.config = config
+		s.stopped = false
+		e.syncLSP()
+		e.invalidateAll()
+		return "Restarting language servers...", nil
+	}
+	return "", fmt.Errorf("unknown lsp command: %s", arg)
+}
+
+// lspUnavailable explains why a pane's buffer has no running server
+func (e *Editor) lspUnavailable(p *Pane) string {
+	s := e.lspState()
+	filetype := p.syntax.Language()
+	switch {
+	case s.stopped:
+		return "Language servers are stopped (:lsp restart)"
+	case p.buffer.Filename() == "":
+		return "No language server for an unnamed buffer"
+	case filetype == "":
+		return "No language server: unknown filetype"
+	case s.config[filetype] == nil:
+		return "No language server for " + filetype
+	}
+	server := e.serverFor(p.buffer.Filename(), filetype)
+	if server.err != nil {
+		return fmt.Sprintf("%s: %v", server.name, server.err)
+	}
+	if server.client == nil {
+		return server.name + " is starting"
+	}
+	if err := server.client.Err(); err != nil {
+		return fmt.Sprintf("%s: %v", server.name, err)
+	}
+	return "No language server"
+}
+
+// lspRequest sends a request to the server of the active buffer in the
+// background. The function the request returns runs on the main loop once
+// it is done; errors are shown in the status bar.
+func (e *Editor) lspRequest(request func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error)) bool {
+	p := e.active()
+	d := e.syncDocument(p)
+	if d == nil {
+		p.msgManager.SetError(e.lspUnavailable(p))
+		return false
+	}
+	client, doc, name := d.server.client, d.doc, d.server.name
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lspTimeout)
+		defer cancel()
+		done, err := request(ctx, client, doc)
+		e.lspPost(func() {
+			if err != nil {
+				e.active().msgManager.SetError(name + ": " + err.Error())
+				return
+			}
+			if done != nil {
+				done()
+			}
+		})
+	}()
+	return true
+}
+
+// hasLSP reports whether the active buffer has a running language server
+func (e *Editor) hasLSP() bool {
+	return e.syncDocument(e.active()) != nil
+}
+
+// applyTextEdits applies text edits to a buffer as one undo step
+func applyTextEdits(buf *buffer.Buffer, encoding string, edits []lsp.TextEdit) {
+	buf.UndoStack().BeginGroup()
+	defer buf.UndoStack().EndGroup()
+	for _, ed := range lsp.SortEdits(edits) {
+		r := ed.Range
+		startCol := lsp.Column(encoding, buf.Line(r.Start.Line), r.Start.Character)
+		endCol := lsp.Column(encoding, buf.Line(r.End.Line), r.End.Character)
+		buf.ReplaceRange(r.Start.Line, startCol, r.End.Line, endCol, ed.NewText)
+	}
+}
+
+// applyWorkspaceEdit applies edits to several files. Files open in a pane
+// are edited in their buffers, others on disk.
+func (e *Editor) applyWorkspaceEdit(encoding string, edit *lsp.WorkspaceEdit) (edits, files int, failed []string) {
+	for uri, list := range edit.Edits() {
+		path := lsp.PathFromURI(uri)
+		if path == "" {
+			failed = append(failed, uri)
+			continue
+		}
+		if bufs := e.openBuffers(path); len(bufs) > 0 {
+			for _, buf := range bufs {
+				applyTextEdits(buf, encoding, list)
+			}
+		} else if err := applyTextEditsToFile(path, encoding, list); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", abbreviateHome(path), err))
+			continue
+		}
+		edits += len(list)
+		files++
+	}
+	for _, p := range e.panes {
+		p.renderCache.invalidate()
+	}
+	e.clampCursor()
+	e.adjustScroll()
+	return edits, files, failed
+}
+
+func applyTextEditsToFile(path, encoding string, edits []lsp.TextEdit) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := lsp.ApplyEdits(encoding, strings.Split(string(data), "\n"), edits)
+	return utils.WriteFileAtomic(path, []byte(strings.Join(lines, "\n")), info.Mode())
+}
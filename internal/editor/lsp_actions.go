@@ -0,0 +1,375 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/completion"
+	"github.com/Adelodunpeter25/vx/internal/lsp"
+	"github.com/Adelodunpeter25/vx/internal/picker"
+	"github.com/Adelodunpeter25/vx/internal/quickfix"
+	"github.com/Adelodunpeter25/vx/internal/snippet"
+)
+
+// Size limits of the hover popup
+const (
+	hoverMaxWidth  = 80
+	hoverMaxHeight = 15
+)
+
+// showHover shows what the language server knows about the symbol under the
+// cursor in a popup
+func (e *Editor) showHover() {
+	p := e.active()
+	line, col := p.cursorY, p.cursorX
+	e.lspRequest(func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error) {
+		text, err := c.Hover(ctx, doc, line, col)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if e.active() != p || p.cursorY != line || p.cursorX != col {
+				return
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				p.msgManager.SetTransient("No hover information")
+				return
+			}
+			e.hover = strings.Split(text, "\n")
+		}, nil
+	})
+}
+
+// renderHover draws the hover popup below the cursor, or above it when
+// there is no room
+func (e *Editor) renderHover() {
+	p := e.active()
+	if e.hover == nil || p.mode != ModeNormal {
+		return
+	}
+	width := 0
+	for _, line := range e.hover {
+		width = max(width, len([]rune(line)))
+	}
+	width = min(min(width+2, hoverMaxWidth), e.width)
+	lines := wrapHover(e.hover, width-2)
+	height := min(min(len(lines), hoverMaxHeight), e.paneAreaHeight())
+
+	gutterWidth := e.getGutterWidthFor(p)
+	cy, cx := e.getCursorScreenPosFor(p, gutterWidth, p.viewWidth-gutterWidth)
+	x := min(p.viewX+cx, e.width-width)
+	y := p.viewY + cy + 1
+	if y+height > e.paneAreaHeight() {
+		y = max(p.viewY+cy-height, 0)
+	}
+
+	style := e.theme.UI.Popup
+	for row := 0; row < height; row++ {
+		text := []rune(" " + lines[row])
+		for col := 0; col < width; col++ {
+			r := ' '
+			if col < len(text) {
+				r = text[col]
+			}
+			e.term.SetCell(x+col, y+row, r, style)
+		}
+	}
+}
+
+// wrapHover breaks hover text into lines of at most width runes
+func wrapHover(text []string, width int) []string {
+	var lines []string
+	for _, line := range text {
+		runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+		for len(runes) > width && width > 0 {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// gotoDefinition jumps to the definition of the symbol under the cursor,
+// using the symbol index when the buffer has no language server
+func (e *Editor) gotoDefinition() {
+	if !e.hasLSP() {
+		e.jumpToDefinition()
+		return
+	}
+	p := e.active()
+	line, col := p.cursorY, p.cursorX
+	e.lspRequest(func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error) {
+		locations, err := c.Definition(ctx, doc, line, col)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			switch len(locations) {
+			case 0:
+				e.active().msgManager.SetError("No definition found")
+			case 1:
+				e.jumpToLSPLocation(c.Encoding(), locations[0])
+			default:
+				e.openLocationPicker("Definitions", c.Root, c.Encoding(), locations)
+			}
+		}, nil
+	})
+}
+
+// jumpToLSPLocation opens the file of a location and puts the cursor at its
+// start. The position jumped from goes on the tag stack.
+func (e *Editor) jumpToLSPLocation(encoding string, loc lsp.Location) {
+	path := lsp.PathFromURI(loc.URI)
+	if path == "" {
+		e.active().msgManager.SetError("Cannot open " + loc.URI)
+		return
+	}
+	from := e.currentJump()
+	line := loc.Range.Start.Line
+	if !e.jumpToLocation(path, line, 0) {
+		return
+	}
+	e.pushJump(from)
+	p := e.active()
+	e.moveCursorTo(line, lsp.Column(encoding, p.buffer.Line(line), loc.Range.Start.Character))
+}
+
+// openLocationPicker offers locations in a picker that jumps to the chosen
+// one
+func (e *Editor) openLocationPicker(title, root, encoding string, locations []lsp.Location) {
+	lines := e.newLineReader()
+	items := make([]picker.Item, len(locations))
+	for i, loc := range locations {
+		path := lsp.PathFromURI(loc.URI)
+		items[i] = picker.Item{
+			Label:  fmt.Sprintf("%s:%d", relativeTo(root, path), loc.Range.Start.Line+1),
+			Detail: strings.TrimSpace(lines.line(path, loc.Range.Start.Line)),
+		}
+	}
+	e.openPicker(title, "", items, func(i int) {
+		e.jumpToLSPLocation(encoding, locations[i])
+	})
+}
+
+// showReferences lists the uses of the symbol under the cursor in the
+// quickfix list
+func (e *Editor) showReferences() {
+	p := e.active()
+	line, col := p.cursorY, p.cursorX
+	name := ""
+	if start, end, ok := wordAt(p.buffer.Line(line), col); ok {
+		name = string([]rune(p.buffer.Line(line))[start:end])
+	}
+	e.lspRequest(func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error) {
+		locations, err := c.References(ctx, doc, line, col)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if len(locations) == 0 {
+				e.active().msgManager.SetError("No references found")
+				return
+			}
+			lines := e.newLineReader()
+			items := make([]quickfix.Item, 0, len(locations))
+			for _, loc := range locations {
+				path := lsp.PathFromURI(loc.URI)
+				if path == "" {
+					continue
+				}
+				text := lines.line(path, loc.Range.Start.Line)
+				items = append(items, quickfix.Item{
+					Path: path,
+					Line: loc.Range.Start.Line,
+					Col:  lsp.Column(c.Encoding(), text, loc.Range.Start.Character),
+					Text: text,
+				})
+			}
+			e.quickfix.Set(strings.TrimSpace("References "+name), c.Root, items)
+			e.quickfix.SortByLocation()
+			e.openQuickfix()
+		}, nil
+	})
+}
+
+// renameSymbol renames the symbol under the cursor across the project
+func (e *Editor) renameSymbol(newName string) {
+	p := e.active()
+	buf, version := p.buffer, p.buffer.ModVersion()
+	line, col := p.cursorY, p.cursorX
+	e.lspRequest(func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error) {
+		edit, err := c.Rename(ctx, doc, line, col, newName)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if buf.ModVersion() != version {
+				e.active().msgManager.SetError("Buffer changed while renaming")
+				return
+			}
+			edits, files, failed := e.applyWorkspaceEdit(c.Encoding(), edit)
+			msg := fmt.Sprintf("Renamed to %s: %d edits in %d files", newName, edits, files)
+			if len(failed) > 0 {
+				e.active().msgManager.SetError(msg + "; skipped " + strings.Join(failed, ", "))
+				return
+			}
+			e.active().msgManager.SetPersistent(msg)
+		}, nil
+	})
+}
+
+// lspCompletions is the completion provider for buffers with a language
+// server. Items come once the server answers.
+func (e *Editor) lspCompletions(req completion.Request, add func([]completion.Item)) {
+	p := e.active()
+	if !req.Manual && req.Prefix() == "" && !e.isTriggerCharacter(lastRune(req.Line, req.Col)) {
+		return
+	}
+	if !e.hasLSP() {
+		return
+	}
+	line, start := p.cursorY, req.WordStart()
+	e.lspRequest(func(ctx context.Context, c *lsp.Client, doc *lsp.Document) (func(), error) {
+		items, err := c.Completion(ctx, doc, line, req.Col)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			add(e.completionItems(c.Encoding(), items, line, start))
+		}, nil
+	})
+}
+
+// completionItems converts a server's completions into menu items. Items
+// replace the word before the cursor unless the server gives a range on
+// the cursor line; snippets are expanded, and edits elsewhere, such as
+// imports, made on accepting.
+func (e *Editor) completionItems(encoding string, items []lsp.CompletionItem, line, start int) []completion.Item {
+	sort.SliceStable(items, func(i, j int) bool {
+		return sortText(items[i]) < sortText(items[j])
+	})
+	text := e.active().buffer.Line(line)
+	list := make([]completion.Item, len(items))
+	for i, item := range items {
+		it := completion.Item{
+			Text:   item.Text(),
+			Label:  item.Label,
+			Kind:   item.KindName(),
+			Filter: item.FilterText,
+			Start:  start,
+		}
+		if te := item.TextEdit; te != nil && te.Range.Start.Line == line && te.Range.End.Line == line {
+			it.Start = lsp.Column(encoding, text, te.Range.Start.Character)
+		}
+		body := ""
+		if item.InsertTextFormat == lsp.InsertTextSnippet {
+			body = it.Text
+			it.Text = snippet.Parse(body).Expand(nil, "").Text
+		}
+		if extra := item.AdditionalTextEdits; body != "" || len(extra) > 0 {
+			it.Accept = func() {
+				e.acceptWithEdits(encoding, it, body, extra)
+			}
+		}
+		list[i] = it
+	}
+	return list
+}
+
+func sortText(item lsp.CompletionItem) string {
+	if item.SortText != "" {
+		return item.SortText
+	}
+	return item.Label
+}
+
+// acceptWithEdits makes the server's other edits for a completion, then
+// inserts it, or expands body if it is a snippet, as one undo step
+func (e *Editor) acceptWithEdits(encoding string, item completion.Item, body string, edits []lsp.TextEdit) {
+	p := e.active()
+	buf := p.buffer
+	buf.UndoStack().BeginGroup()
+	defer buf.UndoStack().EndGroup()
+	for _, ed := range lsp.SortEdits(edits) {
+		r := ed.Range
+		if r.Start.Line >= p.cursorY {
+			// Only edits above the completion are expected
+			continue
+		}
+		startCol := lsp.Column(encoding, buf.Line(r.Start.Line), r.Start.Character)
+		endCol := lsp.Column(encoding, buf.Line(r.End.Line), r.End.Character)
+		buf.ReplaceRange(r.Start.Line, startCol, r.End.Line, endCol, ed.NewText)
+		p.cursorY += strings.Count(ed.NewText, "\n") - (r.End.Line - r.Start.Line)
+	}
+	if body != "" {
+		e.expandSnippet(item.Start, body)
+	} else {
+		e.insertCompletion(item)
+	}
+	e.clampCursor()
+}
+
+// isTriggerCharacter reports whether typing r asks the active buffer's
+// language server for completions
+func (e *Editor) isTriggerCharacter(r rune) bool {
+	if e.lsp == nil || r == 0 {
+		return false
+	}
+	d := e.lsp.docs[e.active().buffer]
+	if d == nil || d.server.client == nil {
+		return false
+	}
+	return slices.Contains(d.server.client.TriggerCharacters(), string(r))
+}
+
+// lastRune returns the rune before col in line, or 0
+func lastRune(line string, col int) rune {
+	runes := []rune(line)
+	if col <= 0 || col > len(runes) {
+		return 0
+	}
+	return runes[col-1]
+}
+
+// lineReader reads lines of files for locations, from open buffers where
+// the file is open
+type lineReader struct {
+	e     *Editor
+	files map[string][]string
+}
+
+func (e *Editor) newLineReader() *lineReader {
+	return &lineReader{e: e, files: make(map[string][]string)}
+}
+
+func (r *lineReader) line(path string, n int) string {
+	if bufs := r.e.openBuffers(path); len(bufs) > 0 {
+		return bufs[0].Line(n)
+	}
+	lines, ok := r.files[path]
+	if !ok {
+		if data, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		r.files[path] = lines
+	}
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[n], "\r")
+}
+
+// relativeTo shortens path to be relative to root when it is inside it
+func relativeTo(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && root != "" && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return abbreviateHome(path)
+}
@@ -0,0 +1,260 @@
+package editor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/errorformat"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/quickfix"
+)
+
+// makeBufferName names the scratch buffer :make writes its output to
+const makeBufferName = "[make]"
+
+// makeJob is a build started with :make, running in the background
+type makeJob struct {
+	name   string // the command's name, for messages
+	root   string // directory it runs in
+	format *errorformat.Format
+	pane   *Pane // shows the output
+	jump   bool  // jump to the first error when it finishes
+	cancel context.CancelFunc
+	lines  chan string
+	done   chan error // receives the exit status after the last line was sent
+}
+
+// makeProgram returns the build command for :make: the makeprg option, or
+// one suited to the project in root
+func makeProgram(p *Pane, root string) (string, error) {
+	if prg := strings.TrimSpace(p.options.String(options.MakePrg)); prg != "" {
+		return prg, nil
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	switch {
+	case exists("Makefile") || exists("makefile") || exists("GNUmakefile"):
+		return "make", nil
+	case exists("go.mod"):
+		return "go build ./...", nil
+	case exists("tsconfig.json"):
+		return "tsc --noEmit", nil
+	}
+	return "", fmt.Errorf("no makeprg set and no Makefile, go.mod or tsconfig.json in %s", root)
+}
+
+// startMake runs the build command with args in the file browser root,
+// streaming its output to a scratch pane and the errors in it to the
+// quickfix list. Unless noJump is set, the first error is opened when the
+// build finishes.
+func (e *Editor) startMake(args string, noJump bool) (string, error) {
+	p := e.active()
+	root, err := e.grepRoot("")
+	if err != nil {
+		return "", err
+	}
+	prg, err := makeProgram(p, root)
+	if err != nil {
+		return "", err
+	}
+	format, err := errorformat.Compile(p.options.String(options.ErrorFormat))
+	if err != nil {
+		return "", err
+	}
+	cmdline := prg
+	if args != "" {
+		cmdline += " " + args
+	}
+	e.stopMake()
+
+	out := buffer.NewScratch(makeBufferName)
+	out.AppendLines("$ " + cmdline)
+	outPane := e.makePane(out)
+
+	e.quickfix.Set(cmdline, root, nil)
+	e.quickfix.Status = "running…"
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &makeJob{
+		name:   strings.Fields(prg)[0],
+		root:   root,
+		format: format,
+		pane:   outPane,
+		jump:   !noJump,
+		cancel: cancel,
+		lines:  make(chan string, 256),
+		done:   make(chan error, 1),
+	}
+	e.build = job
+	go job.run(ctx, cmdline, e.term.Wake)
+	return "Running " + cmdline, nil
+}
+
+// makePane shows buf in the pane of the last build, or a new pane next to
+// the others, leaving the active pane as it is
+func (e *Editor) makePane(buf *buffer.Buffer) *Pane {
+	for _, p := range e.panes {
+		if p.buffer.IsScratch() && p.buffer.Filename() == makeBufferName {
+			p.setBuffer(buf)
+			return p
+		}
+	}
+	active := e.activePane
+	e.addPaneWithBuffer(buf, makeBufferName)
+	p := e.active()
+	e.activePane = active
+	e.invalidateAll()
+	return p
+}
+
+// run runs the build, sending its output line by line
+func (job *makeJob) run(ctx context.Context, cmdline string, wake func()) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdline)
+	cmd.Dir = job.root
+	// Don't wait on commands the build left running with the output open
+	cmd.WaitDelay = time.Second
+	out, err := cmd.StdoutPipe()
+	if err == nil {
+		cmd.Stderr = cmd.Stdout
+		err = cmd.Start()
+	}
+	if err != nil {
+		job.done <- err
+		wake()
+		return
+	}
+
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		select {
+		case job.lines <- scanner.Text():
+			wake()
+		case <-ctx.Done():
+		}
+	}
+	job.done <- cmd.Wait()
+	wake()
+}
+
+func (e *Editor) stopMake() {
+	if e.build != nil {
+		e.build.cancel()
+		e.build = nil
+	}
+}
+
+// pollMake adds the output that arrived since the last poll to the
+// scratch pane and the quickfix list, and reports on a finished build
+func (e *Editor) pollMake() bool {
+	job := e.build
+	if job == nil {
+		return false
+	}
+	var exitErr error
+	finished := false
+	select {
+	case exitErr = <-job.done:
+		finished = true
+	default:
+	}
+	var lines []string
+drain:
+	for {
+		select {
+		case line := <-job.lines:
+			lines = append(lines, line)
+		default:
+			break drain
+		}
+	}
+	if len(lines) > 0 {
+		e.addMakeOutput(job, lines)
+	}
+	if finished {
+		e.finishMake(job, exitErr)
+	}
+	return finished || len(lines) > 0
+}
+
+// addMakeOutput appends lines to the output pane, which keeps showing the
+// end of it unless its cursor was moved up, and their errors to the
+// quickfix list
+func (e *Editor) addMakeOutput(job *makeJob, lines []string) {
+	p := job.pane
+	follow := p.cursorY >= p.buffer.LineCount()-1
+	p.buffer.AppendLines(lines...)
+	if follow {
+		e.scrollToEnd(p)
+	}
+
+	for _, line := range lines {
+		entry, ok := job.format.Match(line)
+		if !ok {
+			continue
+		}
+		path := entry.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(job.root, path)
+		}
+		// Lines such as make's own "make: *** [Makefile:3: all] Error 1"
+		// can look like locations; only those in existing files count
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		text := entry.Message
+		if entry.Type != "" {
+			text = entry.Type + ": " + text
+		}
+		e.quickfix.Append(quickfix.Item{Path: filepath.Clean(path), Line: entry.Line, Col: entry.Col, Text: text})
+	}
+}
+
+// scrollToEnd puts the cursor of a pane, active or not, on its last line
+func (e *Editor) scrollToEnd(p *Pane) {
+	active := e.activePane
+	for i, q := range e.panes {
+		if q == p {
+			e.activePane = i
+			p.cursorY = p.buffer.LineCount() - 1
+			p.cursorX = 0
+			e.adjustScroll()
+		}
+	}
+	e.activePane = active
+}
+
+// finishMake reports how the build went and opens its first error
+func (e *Editor) finishMake(job *makeJob, exitErr error) {
+	e.build = nil
+	trailer := "[done]"
+	e.quickfix.Status = ""
+	if exitErr != nil {
+		trailer = "[" + exitErr.Error() + "]"
+		e.quickfix.Status = exitErr.Error()
+	}
+	e.addMakeOutput(job, []string{"", trailer})
+
+	p := e.active()
+	n := e.quickfix.Len()
+	switch {
+	case n > 0 && job.jump:
+		e.quickfixStep(true)
+	case n == 1:
+		p.msgManager.SetError(fmt.Sprintf("%s: 1 error", job.name))
+	case n > 1:
+		p.msgManager.SetError(fmt.Sprintf("%s: %d errors", job.name, n))
+	case exitErr != nil:
+		p.msgManager.SetError(fmt.Sprintf("%s: %v", job.name, exitErr))
+	default:
+		p.msgManager.SetTransient(fmt.Sprintf("%s: done", job.name))
+	}
+}
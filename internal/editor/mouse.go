@@ -2,7 +2,6 @@ package editor
 
 import (
 	"github.com/Adelodunpeter25/vx/internal/terminal"
-	"github.com/Adelodunpeter25/vx/internal/wrap"
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -14,7 +13,7 @@ func (e *Editor) handleMouseEvent(ev *terminal.Event) {
 		paneWidth = e.width
 	}
 	if paneHeight == 0 {
-		paneHeight = e.height - 1
+		paneHeight = e.paneAreaHeight()
 	}
 	if ev.MouseX < 0 || ev.MouseX >= paneWidth || ev.MouseY < 0 || ev.MouseY >= paneHeight {
 		return
@@ -48,8 +47,7 @@ func (e *Editor) handleMouseEvent(ev *terminal.Event) {
 			// Calculate total visual rows
 			totalVisualRows := 0
 			for i := 0; i < p.buffer.LineCount(); i++ {
-				line := p.buffer.Line(i)
-				totalVisualRows += wrap.VisualLineCount(line, maxWidth)
+				totalVisualRows += lineRows(p, i, wrapWidth(p, maxWidth))
 			}
 
 			// Only scroll if there's more content below
@@ -125,8 +123,7 @@ func (e *Editor) handleMouseEvent(ev *terminal.Event) {
 			} else if mouseY > contentHeight-3 {
 				totalVisualRows := 0
 				for i := 0; i < p.buffer.LineCount(); i++ {
-					line := p.buffer.Line(i)
-					totalVisualRows += wrap.VisualLineCount(line, maxWidth)
+					totalVisualRows += lineRows(p, i, wrapWidth(p, maxWidth))
 				}
 
 				if p.visualOffsetY+contentHeight < totalVisualRows {
@@ -161,19 +158,22 @@ func (e *Editor) bufferPosFromScreen(mouseX, mouseY, gutterWidth, maxWidth int)
 	currentVisualRow := 0
 	bufferY = 0
 	bufferX = mouseX - gutterWidth
+	lineWidth := wrapWidth(p, maxWidth)
+	scrollX := 0
+	if lineWidth == 0 {
+		scrollX = p.offsetX
+	}
 
 	for bufferY < p.buffer.LineCount() {
-		line := p.buffer.Line(bufferY)
-		segments := wrap.WrapLine(line, bufferY, maxWidth)
+		if r, hidden := p.foldSet().Hiding(bufferY); hidden {
+			bufferY = r.End + 1
+			continue
+		}
+		segments := lineSegments(p, bufferY, lineWidth)
 
 		for _, seg := range segments {
 			if currentVisualRow == clickedVisualRow {
-				bufferX = seg.StartCol + (mouseX - gutterWidth)
-				segLen := len([]rune(seg.Text))
-				if bufferX > seg.StartCol+segLen {
-					bufferX = seg.StartCol + segLen
-				}
-				return bufferY, bufferX
+				return bufferY, seg.Col(scrollX + mouseX - gutterWidth)
 			}
 			currentVisualRow++
 		}
@@ -183,6 +183,9 @@ func (e *Editor) bufferPosFromScreen(mouseX, mouseY, gutterWidth, maxWidth int)
 	if bufferY >= p.buffer.LineCount() {
 		bufferY = p.buffer.LineCount() - 1
 	}
+	if r, hidden := p.foldSet().Hiding(bufferY); hidden {
+		bufferY = r.Start
+	}
 	return bufferY, bufferX
 }
 
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/Adelodunpeter25/vx/internal/clipboard"
+	"github.com/Adelodunpeter25/vx/internal/search"
 	"github.com/Adelodunpeter25/vx/internal/terminal"
 	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
@@ -30,23 +31,17 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 	if ev.Key == tcell.KeyCtrlS {
 		if p.buffer.Filename() == "" {
 			p.msgManager.SetError("No filename specified")
+		} else if msg, err := e.writeBuffer(false); err != nil {
+			p.msgManager.SetError(utils.FormatSaveError(p.buffer.Filename(), err))
 		} else {
-			if err := p.buffer.Save(); err != nil {
-				p.msgManager.SetError(utils.FormatSaveError(p.buffer.Filename(), err))
-			} else {
-				size, _ := p.buffer.GetFileSize()
-				p.msgManager.SetPersistent(utils.FormatFileInfo(p.buffer.Filename(), size, p.buffer.LineCount()))
-			}
+			p.msgManager.SetPersistent(msg)
 		}
 		return
 	}
 
 	// Ctrl+F search
 	if ev.Key == tcell.KeyCtrlF {
-		p.mode = ModeSearch
-		p.searchBuf = ""
-		p.msgManager.Clear()
-		p.lastKey = 0
+		e.startSearchMode()
 		return
 	}
 
@@ -62,11 +57,43 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		return
 	}
 
+	// Ctrl+] jump to definition, Ctrl+T back
+	if ev.Key == tcell.KeyCtrlRightSq {
+		e.jumpToDefinition()
+		return
+	}
+	if ev.Key == tcell.KeyCtrlT {
+		e.popJump()
+		return
+	}
+
+	// Enter opens the commit under the cursor in a :Glog list
+	if ev.Key == tcell.KeyEnter && e.openLogEntry() {
+		return
+	}
+
+	// z commands open and close folds
+	if p.lastKey == 'z' {
+		p.lastKey = 0
+		e.handleFoldKey(ev.Rune)
+		return
+	}
+
+	// An operator such as gc takes the motion that follows it
+	if p.pendingOp != "" {
+		e.handleOperatorKey(ev)
+		return
+	}
+
 	switch ev.Rune {
 	case 'q':
 		e.quit = true
 	case 'i':
-		p.mode = ModeInsert
+		if p.buffer.IsReadOnly() {
+			p.msgManager.SetError("Buffer is read-only")
+		} else {
+			p.mode = ModeInsert
+		}
 		p.lastKey = 0
 	case ':':
 		p.mode = ModeCommand
@@ -74,25 +101,29 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		p.msgManager.Clear()
 		p.lastKey = 0
 	case '/':
-		p.mode = ModeSearch
-		p.searchBuf = ""
-		p.msgManager.Clear()
-		p.lastKey = 0
+		e.startSearchMode()
 	case 'H':
-		// Ctrl+H for replace
-		p.mode = ModeReplace
-		p.replace.Start()
-		p.msgManager.Clear()
-		p.lastKey = 0
+		// Shift+H for replace
+		e.startReplaceMode()
 	case 'n':
 		e.searchNext()
 		p.lastKey = 0
 	case 'N':
 		e.searchPrevious()
 		p.lastKey = 0
+	case '*', '#':
+		// * and # search for the word under the cursor, g* and g# for partial matches
+		e.searchWordUnderCursor(ev.Rune == '#', p.lastKey == 'g')
+		p.lastKey = 0
 	case 'c':
-		// Copy selection if active, otherwise copy current line
-		if p.selection.IsActive() {
+		// gc toggles comments; ]c and [c move to the next and previous
+		// unstaged git change; c copies the selection if active, otherwise
+		// the current line
+		if p.lastKey == 'g' {
+			e.startCommentOperator()
+		} else if p.lastKey == ']' || p.lastKey == '[' {
+			e.jumpToHunk(p.lastKey == ']')
+		} else if p.selection.IsActive() {
 			e.copySelection()
 		} else {
 			e.copyCurrentLine()
@@ -107,8 +138,15 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		}
 		p.lastKey = 0
 	case 'd':
-		// Handle dd (delete line)
-		if p.lastKey == 'd' {
+		// Handle dd (delete line); gd goes to the definition
+		if p.lastKey == 'g' {
+			e.gotoDefinition()
+			p.lastKey = 0
+		} else if p.lastKey == ']' || p.lastKey == '[' {
+			// ]d and [d move to the next and previous diagnostic
+			e.jumpToDiagnostic(p.lastKey == ']')
+			p.lastKey = 0
+		} else if p.lastKey == 'd' {
 			e.deleteCurrentLine()
 			p.lastKey = 0
 		} else {
@@ -126,7 +164,15 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		e.performUndo()
 		p.lastKey = 0
 	case 'r':
-		e.performRedo()
+		// gr lists references, r alone redoes
+		if p.lastKey == 'g' {
+			e.showReferences()
+		} else {
+			e.performRedo()
+		}
+		p.lastKey = 0
+	case 'K':
+		e.showHover()
 		p.lastKey = 0
 	case 'g':
 		// Handle gg (go to start of file)
@@ -136,6 +182,16 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		} else {
 			p.lastKey = 'g'
 		}
+	case 's':
+		// gs opens the project symbol picker
+		if p.lastKey == 'g' {
+			e.openSymbolPicker("")
+		}
+		p.lastKey = 0
+	case 'z':
+		p.lastKey = 'z'
+	case ']', '[':
+		p.lastKey = ev.Rune
 	case 'G':
 		// Go to end of file
 		e.jumpToEnd()
@@ -154,8 +210,8 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		p.selection.Clear()
 		p.lastKey = 0
 	case 'j':
-		if p.cursorY < p.buffer.LineCount()-1 {
-			p.cursorY++
+		if next := p.foldSet().Next(p.cursorY); next < p.buffer.LineCount() {
+			p.cursorY = next
 			e.adjustScroll()
 			e.clampCursor()
 		} else {
@@ -165,7 +221,7 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		p.lastKey = 0
 	case 'k':
 		if p.cursorY > 0 {
-			p.cursorY--
+			p.cursorY = p.foldSet().Prev(p.cursorY)
 			e.adjustScroll()
 			e.clampCursor()
 		} else {
@@ -212,7 +268,7 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		p.lastKey = 0
 	case tcell.KeyUp:
 		if p.cursorY > 0 {
-			p.cursorY--
+			p.cursorY = p.foldSet().Prev(p.cursorY)
 			e.adjustScroll()
 			e.clampCursor()
 		} else {
@@ -221,8 +277,8 @@ func (e *Editor) handleNormalMode(ev *terminal.Event) {
 		p.selection.Clear()
 		p.lastKey = 0
 	case tcell.KeyDown:
-		if p.cursorY < p.buffer.LineCount()-1 {
-			p.cursorY++
+		if next := p.foldSet().Next(p.cursorY); next < p.buffer.LineCount() {
+			p.cursorY = next
 			e.adjustScroll()
 			e.clampCursor()
 		} else {
@@ -328,34 +384,32 @@ func (e *Editor) pasteFromClipboard() {
 	p.msgManager.SetTransient("Pasted from clipboard")
 }
 
+// searchNext repeats the last search in its direction
 func (e *Editor) searchNext() {
-	p := e.active()
-	if !p.search.HasMatches() {
-		p.msgManager.SetTransient("No search results")
-		return
-	}
-
-	match := p.search.Next()
-	if match != nil {
-		p.cursorY = match.Line
-		p.cursorX = match.Col
-		e.adjustScroll()
-		p.msgManager.Clear()
-	}
+	e.searchStep(e.active().searchBack)
 }
 
+// searchPrevious repeats the last search in the opposite direction
 func (e *Editor) searchPrevious() {
+	e.searchStep(!e.active().searchBack)
+}
+
+func (e *Editor) searchStep(backward bool) {
 	p := e.active()
 	if !p.search.HasMatches() {
 		p.msgManager.SetTransient("No search results")
 		return
 	}
 
-	match := p.search.Previous()
+	p.searchFollow = false
+	var match *search.Match
+	if backward {
+		match = p.search.Previous()
+	} else {
+		match = p.search.Next()
+	}
 	if match != nil {
-		p.cursorY = match.Line
-		p.cursorX = match.Col
-		e.adjustScroll()
+		e.gotoMatch(p, match)
 		p.msgManager.Clear()
 	}
 }
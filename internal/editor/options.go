@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/Adelodunpeter25/vx/internal/errorformat"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/search"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// watchOptions subscribes the editor to option changes
+func (e *Editor) watchOptions() {
+	e.options.OnChange(e.handleOptionChange)
+}
+
+// handleOptionChange pushes new option values into the renderer and engines
+func (e *Editor) handleOptionChange(c options.Change) {
+	switch c.Name {
+	case options.IgnoreCase, options.SmartCase, options.Regex, options.WholeWord:
+		for _, p := range e.panes {
+			configureSearch(p.search, e.options)
+		}
+	case options.ShowHidden:
+		e.setFileBrowserHidden(e.options.Bool(options.ShowHidden))
+	}
+
+	for _, p := range e.panes {
+		if c.Local != nil && p.options != c.Local {
+			continue
+		}
+		if c.Name == options.Wrap {
+			p.offsetX = 0
+		}
+		if c.Name == options.FoldMethod || c.Name == options.TabStop || c.Name == options.ShiftWidth {
+			p.resetFolds()
+		}
+		if c.Name == options.Filetype {
+			p.syntax.SetLanguage(p.options.String(options.Filetype))
+			p.useFiletypeIndent()
+		}
+		p.renderCache.invalidate()
+	}
+
+	if e.active() != nil {
+		e.clampCursor()
+		e.adjustScroll()
+	}
+}
+
+// configureSearch copies the search options into a search engine
+func configureSearch(engine *search.Engine, opts *options.Registry) {
+	engine.SetIgnoreCase(opts.Bool(options.IgnoreCase))
+	engine.SetSmartCase(opts.Bool(options.SmartCase))
+	engine.SetRegex(opts.Bool(options.Regex))
+	engine.SetWholeWord(opts.Bool(options.WholeWord))
+}
+
+// newOptions returns the option registry with the built-in options and the
+// ones checked against editor features
+func newOptions() *options.Registry {
+	opts := options.NewDefault()
+	opts.Register(options.Option{Name: options.Filetype, Short: "ft", Kind: options.KindString, Scope: options.ScopeBuffer, Default: "", Check: checkFiletype})
+	opts.Register(options.Option{Name: options.ErrorFormat, Short: "efm", Kind: options.KindString, Scope: options.ScopeBuffer, Default: errorformat.Default, Check: checkErrorFormat})
+	return opts
+}
+
+// checkFiletype accepts any language the highlighter knows, or "" for plain text
+func checkFiletype(value any) error {
+	name := value.(string)
+	if _, ok := highlight.FindLanguage(name); name != "" && !ok {
+		return fmt.Errorf("unknown filetype: %s", name)
+	}
+	return nil
+}
+
+// checkErrorFormat accepts patterns errorformat can compile
+func checkErrorFormat(value any) error {
+	_, err := errorformat.Compile(value.(string))
+	return err
+}
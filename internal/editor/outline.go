@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/outline"
+	"github.com/Adelodunpeter25/vx/internal/syntax"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+	"github.com/gdamore/tcell/v2"
+)
+
+// outlineSource gives an outline the lines and tokens of a pane's buffer
+type outlineSource struct {
+	*buffer.Buffer
+	syntax *syntax.Engine
+}
+
+func (s outlineSource) Tokens(n int) []highlight.Token {
+	return s.syntax.Tokens(n, s.Buffer)
+}
+
+// syncOutline brings the pane's outline up to date with its buffer. Only
+// lines whose tokens changed since the last call are looked at again.
+func (p *Pane) syncOutline() {
+	if language := p.syntax.Language(); p.outline == nil || p.outline.Language() != language {
+		p.outline = outline.New(language)
+		p.outlineAt = -1
+	}
+	version := p.buffer.ModVersion()
+	if version == p.outlineAt {
+		return
+	}
+	if changes, ok := p.buffer.ChangesSince(p.outlineAt); ok {
+		for _, c := range changes {
+			p.outline.Apply(c.Line, c.Delta)
+		}
+	}
+	p.outline.Update(outlineSource{p.buffer, p.syntax})
+	p.outlineAt = version
+}
+
+// refreshOutline shows the active pane's symbols in the outline panel
+func (e *Editor) refreshOutline() {
+	p := e.active()
+	md, _ := highlight.FindLanguage("markdown")
+	if p.buffer.LineCount() > syntax.MaxHighlightLines && p.syntax.Language() != md {
+		e.outline.SetSymbols(nil)
+		e.outline.Message = "File too large"
+		return
+	}
+	p.syncOutline()
+	e.outline.SetSymbols(p.outline.Symbols())
+	e.outline.Message = "No symbols"
+	e.outline.SetCursor(p.cursorY)
+}
+
+func (e *Editor) toggleOutline() {
+	if e.outline == nil {
+		e.outline = outline.NewPanel()
+	}
+	e.outline.Open = !e.outline.Open
+	if e.outline.Open {
+		e.focusOutline()
+	} else {
+		e.outline.Focused = false
+	}
+	e.invalidateAll()
+}
+
+// focusOutline gives the outline panel focus, taking it from other panels
+func (e *Editor) focusOutline() {
+	e.outline.Focused = true
+	if e.fileBrowser != nil {
+		e.fileBrowser.Focused = false
+	}
+	e.quickfix.Focused = false
+}
+
+// blurOutline returns focus from the outline panel to the buffer
+func (e *Editor) blurOutline() {
+	if e.outline != nil {
+		e.outline.Focused = false
+	}
+}
+
+// outlineWidth returns the width of the open outline panel, kept within the
+// screen
+func (e *Editor) outlineWidth() int {
+	if e.outline == nil || !e.outline.Open {
+		return 0
+	}
+	return max(min(e.outline.Width, e.width-20), 10)
+}
+
+// handleOutlineAction applies the result of a key or click in the panel
+func (e *Editor) handleOutlineAction(action outline.Action) {
+	if action.Close {
+		e.invalidateAll()
+	}
+	if action.Jump == nil {
+		return
+	}
+	e.moveCursorTo(action.Jump.Line, action.Jump.Col)
+}
+
+// handleOutlineMouse handles clicks inside the outline panel at x
+func (e *Editor) handleOutlineMouse(ev *terminal.Event, x, height int) {
+	if ev.Button == tcell.Button1 {
+		e.focusOutline()
+	}
+	e.handleOutlineAction(e.outline.HandleMouse(ev, x, 0, e.outlineWidth(), height))
+}
@@ -2,6 +2,9 @@ package editor
 
 import (
 	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/fold"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/outline"
 	"github.com/Adelodunpeter25/vx/internal/preview"
 	"github.com/Adelodunpeter25/vx/internal/replace"
 	"github.com/Adelodunpeter25/vx/internal/search"
@@ -18,6 +21,12 @@ type Pane struct {
 	selection     *visual.Selection
 	renderCache   *RenderCache
 	msgManager    *MessageManager
+	options       *options.Local
+	folds         *fold.Set
+	foldVersion   int // buffer version the folds were computed at
+	outline       *outline.Outline
+	outlineAt     int        // buffer version the outline was updated at
+	blame         *blameView // :blame column, nil when closed
 	cursorX       int
 	cursorY       int
 	offsetX       int
@@ -26,7 +35,15 @@ type Pane struct {
 	mode          Mode
 	commandBuf    string
 	searchBuf     string
+	searchOriginX int
+	searchOriginY int
+	searchBack    bool
+	searchFollow  bool
+	replaceGroup  int    // undo group of the replace session's changes
+	autoFiletype  string // detected filetype, kept until overridden
 	lastKey       rune
+	pendingOp     string // operator waiting for its motion, such as "gc"
+	opLine        int    // line the pending operator was typed on
 	mouseDownX    int
 	mouseDownY    int
 	mouseDragging bool
@@ -36,8 +53,8 @@ type Pane struct {
 	viewHeight    int
 }
 
-func NewPane(buf *buffer.Buffer, filename string) *Pane {
-	return &Pane{
+func NewPane(buf *buffer.Buffer, filename string, opts *options.Registry) *Pane {
+	p := &Pane{
 		buffer:      buf,
 		syntax:      syntax.New(filename),
 		search:      search.New(),
@@ -46,6 +63,32 @@ func NewPane(buf *buffer.Buffer, filename string) *Pane {
 		selection:   visual.New(),
 		renderCache: newRenderCache(),
 		msgManager:  NewMessageManager(),
+		options:     opts.NewLocal(),
+		folds:       fold.New(),
+		foldVersion: -1,
+		outlineAt:   -1,
 		mode:        ModeNormal,
 	}
+	configureSearch(p.search, opts)
+	p.detectFiletype()
+	return p
+}
+
+// setBuffer replaces the pane's buffer and resets per-buffer state
+func (p *Pane) setBuffer(buf *buffer.Buffer) {
+	p.buffer = buf
+	p.syntax = syntax.New(buf.Filename())
+	p.options = p.options.Registry().NewLocal()
+	p.autoFiletype = ""
+	p.folds = fold.New()
+	p.foldVersion = -1
+	p.outline = nil
+	p.blame = nil
+	p.detectFiletype()
+	p.cursorX = 0
+	p.cursorY = 0
+	p.offsetX = 0
+	p.offsetY = 0
+	p.visualOffsetY = 0
+	p.renderCache.invalidate()
 }
@@ -0,0 +1,44 @@
+package editor
+
+import (
+	"github.com/Adelodunpeter25/vx/internal/picker"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+)
+
+// openPicker shows a fuzzy picker over items. accept runs with the index of
+// the chosen item, after the mode the picker was opened from is restored.
+func (e *Editor) openPicker(title, query string, items []picker.Item, accept func(i int)) {
+	p := e.active()
+	e.picker = picker.New(title, query)
+	e.picker.SetItems(items)
+	e.pickerAccept = accept
+	if p.mode != ModePicker {
+		e.pickerMode = p.mode
+	}
+	p.mode = ModePicker
+}
+
+// handlePickerKey handles keys while a picker is shown
+func (e *Editor) handlePickerKey(ev *terminal.Event) {
+	p := e.active()
+	action := e.picker.HandleKey(ev)
+	if !action.Cancel && action.Accept < 0 {
+		return
+	}
+	p.mode = e.pickerMode
+	accept := e.pickerAccept
+	e.picker = nil
+	e.pickerAccept = nil
+	e.tagPending = nil
+	if action.Accept >= 0 && accept != nil {
+		accept(action.Accept)
+	}
+}
+
+// pickerRect returns where the picker is drawn: a box near the top of the
+// screen
+func (e *Editor) pickerRect() (x, y, width, height int) {
+	width = min(e.width-4, 100)
+	height = min(e.paneAreaHeight()-2, 20)
+	return (e.width - width) / 2, 1, width, height
+}
@@ -0,0 +1,139 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	projectreplace "github.com/Adelodunpeter25/vx/internal/project-replace"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+)
+
+// projectReplaceHeight returns the rows used by the replace preview panel
+func (e *Editor) projectReplaceHeight() int {
+	height := (e.height - 1) * 2 / 3
+	if height < 3 {
+		return 0
+	}
+	return height
+}
+
+// startProjectReplace finds every match of pattern below path and shows
+// them for review before anything is changed
+func (e *Editor) startProjectReplace(pattern, replacement, path string) error {
+	root, err := e.grepRoot(path)
+	if err != nil {
+		return err
+	}
+	re, err := e.compileGrepPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	session := projectreplace.New(pattern, replacement, root, re, !e.options.Bool(options.Regex))
+	e.projectReplace = session
+	if e.fileBrowser != nil {
+		e.fileBrowser.Focused = false
+	}
+	e.quickfix.Focused = false
+	e.blurOutline()
+	e.invalidateAll()
+	e.runGrep(root, re, session.Add, func() {
+		session.Searching = false
+	})
+	return nil
+}
+
+func (e *Editor) handleProjectReplaceKey(ev *terminal.Event) {
+	action := e.projectReplace.HandleKey(ev)
+	if action.Cancel {
+		e.closeProjectReplace()
+		e.active().msgManager.SetTransient("Replace cancelled")
+	}
+	if action.Apply {
+		e.applyProjectReplace()
+	}
+}
+
+func (e *Editor) closeProjectReplace() {
+	e.stopGrep()
+	e.projectReplace = nil
+	e.invalidateAll()
+}
+
+// applyProjectReplace writes the accepted replacements. Files open in a pane
+// are edited in the buffer, as one undo step each; other files are changed
+// on disk.
+func (e *Editor) applyProjectReplace() {
+	edits := e.projectReplace.Edits()
+	e.closeProjectReplace()
+
+	replaced, files := 0, 0
+	var failed []string
+	for _, fe := range edits {
+		var err error
+		if bufs := e.openBuffers(fe.Path); len(bufs) > 0 {
+			for _, buf := range bufs {
+				if err = applyToBuffer(buf, fe); err != nil {
+					break
+				}
+			}
+		} else {
+			err = projectreplace.ApplyToFile(fe)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", fe.Path, err))
+			continue
+		}
+		replaced += len(fe.Edits)
+		files++
+	}
+
+	for _, p := range e.panes {
+		p.renderCache.invalidate()
+	}
+	e.clampCursor()
+	e.adjustScroll()
+
+	p := e.active()
+	msg := fmt.Sprintf("Replaced %d matches in %d files", replaced, files)
+	if len(failed) > 0 {
+		p.msgManager.SetError(msg + "; skipped " + strings.Join(failed, ", "))
+		return
+	}
+	p.msgManager.SetPersistent(msg)
+}
+
+// openBuffers returns the buffers of all panes showing path
+func (e *Editor) openBuffers(path string) []*buffer.Buffer {
+	var bufs []*buffer.Buffer
+	for _, p := range e.panes {
+		if !samePath(p.buffer.Filename(), path) {
+			continue
+		}
+		dup := false
+		for _, b := range bufs {
+			dup = dup || b == p.buffer
+		}
+		if !dup {
+			bufs = append(bufs, p.buffer)
+		}
+	}
+	return bufs
+}
+
+// applyToBuffer applies a file's edits through the buffer's undo stack
+func applyToBuffer(buf *buffer.Buffer, fe projectreplace.FileEdit) error {
+	for line, text := range fe.Lines {
+		if buf.Line(line) != text {
+			return fmt.Errorf("buffer changed since search")
+		}
+	}
+	buf.UndoStack().BeginGroup()
+	defer buf.UndoStack().EndGroup()
+	for _, ed := range fe.Edits {
+		buf.ReplaceText(ed.Line, ed.Col, ed.Len, ed.Text)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
+	"github.com/Adelodunpeter25/vx/internal/grep"
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/quickfix"
+	"github.com/Adelodunpeter25/vx/internal/search"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// grepJob is a project search running in the background
+type grepJob struct {
+	cancel  context.CancelFunc
+	results chan []grep.Hit
+	add     func(hits []grep.Hit)
+	done    func()
+}
+
+// quickfixHeight returns the rows used by the quickfix panel
+func (e *Editor) quickfixHeight() int {
+	if e.quickfix == nil || !e.quickfix.Open {
+		return 0
+	}
+	height := e.quickfix.Height
+	if max := (e.height - 1) / 2; height > max {
+		height = max
+	}
+	if height < 2 {
+		return 0
+	}
+	return height
+}
+
+// paneAreaHeight returns the rows available to the file browser and panes
+func (e *Editor) paneAreaHeight() int {
+	height := e.height - 1 - e.bottomPanelHeight()
+	if height < 1 {
+		height = 1
+	}
+	return height
+}
+
+// bottomPanelHeight returns the rows used by the panel below the panes
+func (e *Editor) bottomPanelHeight() int {
+	if e.projectReplace != nil {
+		return e.projectReplaceHeight()
+	}
+	return e.quickfixHeight()
+}
+
+// grepRoot resolves the directory a project search starts from: path, if
+// given, relative to the file browser root, or the root itself
+func (e *Editor) grepRoot(path string) (string, error) {
+	root := ""
+	if e.fileBrowser != nil {
+		root = e.fileBrowser.RootPath
+	}
+	if root == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			root = cwd
+		}
+	}
+	if path != "" {
+		path = filebrowser.ExpandHome(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		root = filepath.Clean(path)
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if info, err := os.Stat(root); err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", root)
+	}
+	return root, nil
+}
+
+// compileGrepPattern compiles a pattern with the same rules as / search
+func (e *Editor) compileGrepPattern(pattern string) (*regexp.Regexp, error) {
+	engine := search.New()
+	configureSearch(engine, e.options)
+	re, err := engine.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return re, nil
+}
+
+// runGrep searches root in the background. Hits are passed to add from the
+// UI goroutine as they arrive, and done is called when the search finishes.
+func (e *Editor) runGrep(root string, re *regexp.Regexp, add func([]grep.Hit), done func()) {
+	e.stopGrep()
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &grepJob{cancel: cancel, results: make(chan []grep.Hit, 64), add: add, done: done}
+	e.grep = job
+	opts := grep.Options{
+		Hidden:  e.options.Bool(options.ShowHidden),
+		Notify:  e.term.Wake,
+		Buffers: e.openBufferSources(),
+	}
+	go grep.Search(ctx, root, re, opts, job.results)
+}
+
+// openBufferSources snapshots every open buffer by absolute path
+func (e *Editor) openBufferSources() map[string]search.Source {
+	sources := make(map[string]search.Source)
+	for _, p := range e.panes {
+		name := p.buffer.Filename()
+		if name == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(name); err == nil {
+			sources[abs] = p.buffer.Snapshot()
+		}
+	}
+	return sources
+}
+
+// startGrep searches all files below path (the file browser root by default)
+// and shows the hits in the quickfix list
+func (e *Editor) startGrep(pattern, path string) error {
+	root, err := e.grepRoot(path)
+	if err != nil {
+		return err
+	}
+	re, err := e.compileGrepPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	e.quickfix.Set("grep "+pattern, root, nil)
+	e.quickfix.Status = "searching…"
+	e.openQuickfix()
+	e.runGrep(root, re, func(hits []grep.Hit) {
+		for _, h := range hits {
+			e.quickfix.Append(quickfix.Item{Path: h.Path, Line: h.Line, Col: h.Col, Text: h.Text})
+		}
+		e.quickfix.SortByLocation()
+	}, func() {
+		e.quickfix.Status = ""
+		if e.quickfix.Len() == 0 {
+			e.quickfix.Status = "no matches"
+		}
+	})
+	return nil
+}
+
+func (e *Editor) stopGrep() {
+	if e.grep != nil {
+		e.grep.cancel()
+		e.grep = nil
+	}
+}
+
+// pollGrep hands the grep results that arrived since the last poll to the
+// running job
+func (e *Editor) pollGrep() bool {
+	job := e.grep
+	if job == nil {
+		return false
+	}
+	var hits []grep.Hit
+	for {
+		select {
+		case batch, ok := <-job.results:
+			if !ok {
+				e.grep = nil
+				if len(hits) > 0 {
+					job.add(hits)
+				}
+				job.done()
+				return true
+			}
+			hits = append(hits, batch...)
+		default:
+			if len(hits) == 0 {
+				return false
+			}
+			job.add(hits)
+			return true
+		}
+	}
+}
+
+func (e *Editor) openQuickfix() {
+	e.quickfix.Open = true
+	e.quickfix.Focused = true
+	if e.fileBrowser != nil {
+		e.fileBrowser.Focused = false
+	}
+	e.blurOutline()
+	e.invalidateAll()
+}
+
+func (e *Editor) closeQuickfix() {
+	e.quickfix.Open = false
+	e.quickfix.Focused = false
+	e.invalidateAll()
+}
+
+// handleQuickfixAction applies the result of a key or click in the list
+func (e *Editor) handleQuickfixAction(action quickfix.Action) {
+	if action.Close {
+		e.closeQuickfix()
+	}
+	if action.Open != nil {
+		e.quickfix.Focused = false
+		e.openQuickfixItem(*action.Open)
+	}
+}
+
+// quickfixStep opens the next (or previous) item in the list
+func (e *Editor) quickfixStep(forward bool) {
+	p := e.active()
+	if e.quickfix.Len() == 0 {
+		p.msgManager.SetError("No quickfix list")
+		return
+	}
+	var item *quickfix.Item
+	var ok bool
+	if forward {
+		item, ok = e.quickfix.Next()
+	} else {
+		item, ok = e.quickfix.Prev()
+	}
+	if !ok {
+		if forward {
+			p.msgManager.SetError("No more items")
+		} else {
+			p.msgManager.SetError("Already at first item")
+		}
+		return
+	}
+	e.openQuickfixItem(*item)
+}
+
+func (e *Editor) openQuickfixItem(item quickfix.Item) {
+	if !e.jumpToLocation(item.Path, item.Line, item.Col) {
+		return
+	}
+	msg := fmt.Sprintf("(%d of %d) %s", e.quickfix.Current()+1, e.quickfix.Len(), strings.TrimSpace(item.Text))
+	e.active().msgManager.SetPersistent(msg)
+}
+
+// handleQuickfixMouse handles clicks inside the quickfix panel
+func (e *Editor) handleQuickfixMouse(ev *terminal.Event, y, height int) {
+	if ev.Button == tcell.Button1 {
+		e.quickfix.Focused = true
+		if e.fileBrowser != nil {
+			e.fileBrowser.Focused = false
+		}
+		e.blurOutline()
+	}
+	e.handleQuickfixAction(e.quickfix.HandleMouse(ev, 0, y, e.width, height))
+}
+
+func (e *Editor) invalidateAll() {
+	for _, p := range e.panes {
+		p.renderCache.invalidate()
+	}
+}
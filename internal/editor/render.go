@@ -4,15 +4,21 @@ import (
 	"fmt"
 
 	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
+	"github.com/Adelodunpeter25/vx/internal/git"
+	"github.com/Adelodunpeter25/vx/internal/options"
 	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
 	"github.com/Adelodunpeter25/vx/internal/wrap"
 	"github.com/gdamore/tcell/v2"
 )
 
 func (e *Editor) render() {
+	e.syncLSP()
+	e.syncGit()
+	e.syncBlame()
+	e.syncGitStatus()
 	e.term.Clear()
 
-	contentHeight := e.height - 1
+	contentHeight := e.paneAreaHeight()
 	cdPromptActive := e.active() != nil && e.active().mode == ModeCdPrompt
 	cdPromptRows := 0
 	if cdPromptActive {
@@ -36,6 +42,14 @@ func (e *Editor) render() {
 		contentX = e.fileBrowser.Width + 1
 		contentWidth = e.width - contentX
 	}
+	if olWidth := e.outlineWidth(); olWidth > 0 {
+		e.refreshOutline()
+		e.outline.Render(e.term, e.width-olWidth, 0, olWidth, contentHeight)
+		for y := 0; y < contentHeight; y++ {
+			e.term.SetCell(e.width-olWidth-1, y, '│', e.theme.UI.Divider)
+		}
+		contentWidth -= olWidth + 1
+	}
 	rects, dividerX := splitpane.LayoutSideBySide(contentWidth, contentHeight, len(e.panes), e.splitRatio)
 	for i, rect := range rects {
 		if i < len(e.panes) {
@@ -47,7 +61,7 @@ func (e *Editor) render() {
 
 	// Draw divider for 2-pane layout
 	if dividerX >= 0 {
-		style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+		style := e.theme.UI.Divider
 		if e.dragSplit {
 			style = style.Bold(true)
 		}
@@ -56,7 +70,7 @@ func (e *Editor) render() {
 		}
 	}
 	if e.fileBrowser != nil && e.fileBrowser.Open {
-		dividerStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+		dividerStyle := e.theme.UI.Divider
 		if e.dragBrowser {
 			dividerStyle = dividerStyle.Bold(true)
 		}
@@ -68,6 +82,20 @@ func (e *Editor) render() {
 		}
 	}
 
+	if e.projectReplace != nil {
+		e.projectReplace.Render(e.term, 0, e.paneAreaHeight(), e.width, e.bottomPanelHeight())
+	} else if qfHeight := e.quickfixHeight(); qfHeight > 0 {
+		e.quickfix.Render(e.term, 0, e.paneAreaHeight(), e.width, qfHeight)
+	}
+
+	e.renderHover()
+	e.renderCompletion()
+
+	if e.picker != nil && e.active().mode == ModePicker {
+		x, y, width, height := e.pickerRect()
+		e.picker.Render(e.term, x, y, width, height)
+	}
+
 	e.renderStatusLine()
 	if cdPromptActive {
 		promptY := e.height - 1 - cdPromptRows
@@ -108,16 +136,28 @@ func (e *Editor) renderPane(p *Pane, rect splitpane.Rect, isActive bool) {
 
 	screenRow := 0
 	lineNum := p.offsetY
+	lineWidth := wrapWidth(p, maxWidth)
 	visualRowsBeforeOffset := 0
 	for i := 0; i < p.offsetY; i++ {
-		line := p.buffer.Line(i)
-		visualRowsBeforeOffset += wrap.VisualLineCount(line, maxWidth)
+		visualRowsBeforeOffset += lineRows(p, i, lineWidth)
 	}
 	skipRows := p.visualOffsetY - visualRowsBeforeOffset
+	folds := p.foldSet()
+	marks := gutterMarks{blame: p.blame, changes: e.gitSigns(p), signs: e.diagnosticSigns(p)}
+	diagnostics := e.diagnosticsFor(p)
 
 	for screenRow < contentHeight && lineNum < p.buffer.LineCount() {
+		if r, hidden := folds.Hiding(lineNum); hidden {
+			lineNum = r.End + 1
+			continue
+		}
+		closed, isClosed := folds.ClosedAt(lineNum)
 		line := p.buffer.Line(lineNum)
-		segments := wrap.WrapLine(line, lineNum, maxWidth)
+		segments := lineSegments(p, lineNum, lineWidth)
+		if lineWidth == 0 {
+			// Without wrapping, only the horizontally scrolled window is drawn
+			segments = []wrap.Line{wrap.Window(line, lineNum, p.offsetX, maxWidth, p.options.Int(options.TabStop))}
+		}
 
 		for segIdx, seg := range segments {
 			if lineNum == p.offsetY && segIdx < skipRows {
@@ -129,19 +169,25 @@ func (e *Editor) renderPane(p *Pane, rect splitpane.Rect, isActive bool) {
 
 			// Line numbers
 			if segIdx == skipRows && lineNum == p.offsetY {
-				e.renderLineNumberAt(rect, screenRow, lineNum, gutterWidth)
+				e.renderLineNumberAt(rect, screenRow, lineNum, gutterWidth, marks)
 			} else if !seg.IsWrapped && lineNum > p.offsetY {
-				e.renderLineNumberAt(rect, screenRow, lineNum, gutterWidth)
+				e.renderLineNumberAt(rect, screenRow, lineNum, gutterWidth, marks)
 			}
 
 			e.renderWrappedSegmentAt(rect, p, screenRow, lineNum, seg, gutterWidth)
+			e.underlineDiagnosticsAt(rect, screenRow, lineNum, seg, gutterWidth, diagnostics)
 
 			if p.selection.IsActive() {
 				e.highlightSelectionAt(rect, p, screenRow, lineNum, seg, gutterWidth)
 			}
+			e.highlightSnippetAt(rect, p, screenRow, lineNum, seg, gutterWidth)
 
 			if matchLine == lineNum && matchCol >= seg.StartCol && matchCol < seg.StartCol+len([]rune(seg.Text)) {
-				e.highlightBracketWrappedAt(rect, matchCol-seg.StartCol, screenRow, gutterWidth, line, matchCol)
+				e.highlightBracketWrappedAt(rect, seg.X(matchCol), screenRow, gutterWidth, line, matchCol)
+			}
+
+			if isClosed {
+				e.renderFoldAt(rect, closed, screenRow, seg.Width(), gutterWidth)
 			}
 
 			screenRow++
@@ -152,7 +198,7 @@ func (e *Editor) renderPane(p *Pane, rect splitpane.Rect, isActive bool) {
 
 	// Fill remaining rows with ~
 	for screenRow < contentHeight {
-		e.drawTextAt(rect, gutterWidth, screenRow, "~", tcell.StyleDefault.Foreground(tcell.ColorBlue))
+		e.drawTextAt(rect, gutterWidth, screenRow, "~", e.theme.UI.NonText)
 		screenRow++
 	}
 
@@ -161,7 +207,7 @@ func (e *Editor) renderPane(p *Pane, rect splitpane.Rect, isActive bool) {
 		e.setCellAt(rect, cursorScreenX, cursorScreenY, ' ', tcell.StyleDefault.Reverse(true))
 		currentLine := []rune(p.buffer.Line(p.cursorY))
 		if p.cursorX < len(currentLine) && isBracket(currentLine[p.cursorX]) {
-			style := tcell.StyleDefault.Background(tcell.NewRGBColor(255, 200, 0)).Foreground(tcell.ColorBlack).Bold(true)
+			style := e.theme.UI.MatchBracket
 			e.setCellAt(rect, cursorScreenX, cursorScreenY, currentLine[p.cursorX], style)
 		}
 	}
@@ -181,12 +227,50 @@ func (e *Editor) drawTextAt(rect splitpane.Rect, x, y int, text string, style tc
 	}
 }
 
-func (e *Editor) renderLineNumberAt(rect splitpane.Rect, screenRow, lineNum, gutterWidth int) {
-	style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(100, 100, 100))
-	numStr := fmt.Sprintf("%*d ", gutterWidth-1, lineNum+1)
-	for x, r := range numStr {
-		e.setCellAt(rect, x, screenRow, r, style)
+// gutterMarks is what the gutter of a pane shows besides line numbers
+type gutterMarks struct {
+	blame   *blameView       // nil without a blame column
+	changes map[int]git.Kind // nil without a git sign column
+	signs   map[int]int      // diagnostic severities, nil without a sign column
+}
+
+// renderLineNumberAt draws the gutter of a line: its blame annotation, git
+// sign and diagnostic sign, if the pane has those columns, then its number
+func (e *Editor) renderLineNumberAt(rect splitpane.Rect, screenRow, lineNum, gutterWidth int, marks gutterMarks) {
+	if gutterWidth <= 0 {
+		return
+	}
+	x := 0
+	if marks.blame != nil {
+		text := ""
+		if lineNum < len(marks.blame.lines) {
+			text = blameText(marks.blame.lines[lineNum])
+		}
+		e.drawTextAt(rect, 0, screenRow, fmt.Sprintf("%-*s", blameWidth, text), e.theme.UI.LineNumber)
+		x = blameWidth
+	}
+	if marks.changes != nil {
+		sign, style := ' ', e.theme.UI.LineNumber
+		if kind, ok := marks.changes[lineNum]; ok {
+			sign, style = e.gitSign(kind)
+		}
+		e.setCellAt(rect, x, screenRow, sign, style)
+		x += gitSignWidth
 	}
+	if marks.signs != nil {
+		sign, style := "  ", e.theme.UI.LineNumber
+		if severity, ok := marks.signs[lineNum]; ok {
+			sign, style = severitySign(severity)+" ", e.severityStyle(severity)
+		}
+		e.drawTextAt(rect, x, screenRow, sign, style)
+		x += signWidth
+	}
+	if gutterWidth-x <= 0 {
+		return
+	}
+	style := e.theme.UI.LineNumber
+	numStr := fmt.Sprintf("%*d ", gutterWidth-x-1, lineNum+1)
+	e.drawTextAt(rect, x, screenRow, numStr, style)
 }
 
 func (e *Editor) getCursorScreenPos(gutterWidth, maxWidth int) (screenY, screenX int) {
@@ -198,24 +282,35 @@ func (e *Editor) getCursorScreenPosFor(p *Pane, gutterWidth, maxWidth int) (scre
 		return 0, 0
 	}
 	// Calculate cursor's visual row position
+	lineWidth := wrapWidth(p, maxWidth)
 	cursorVisualLine := 0
 	for lineNum := 0; lineNum < p.cursorY && lineNum < p.buffer.LineCount(); lineNum++ {
-		line := p.buffer.Line(lineNum)
-		cursorVisualLine += wrap.VisualLineCount(line, maxWidth)
+		cursorVisualLine += lineRows(p, lineNum, lineWidth)
 	}
 
 	// Find which wrapped segment contains the cursor
-	currentLine := p.buffer.Line(p.cursorY)
-	segments := wrap.WrapLine(currentLine, p.cursorY, maxWidth)
+	segments := lineSegments(p, p.cursorY, lineWidth)
 
+	found := false
 	for i, seg := range segments {
 		segEndCol := seg.StartCol + len([]rune(seg.Text))
 		if p.cursorX >= seg.StartCol && p.cursorX <= segEndCol {
 			cursorVisualLine += i
-			screenX = (p.cursorX - seg.StartCol) + gutterWidth
+			screenX = seg.X(p.cursorX) + gutterWidth
+			found = true
 			break
 		}
 	}
+	if !found && len(segments) > 0 {
+		// Past the first row of a closed fold
+		seg := segments[len(segments)-1]
+		cursorVisualLine += len(segments) - 1
+		screenX = seg.Width() + gutterWidth
+	}
+
+	if lineWidth == 0 {
+		screenX -= p.offsetX
+	}
 
 	// Convert to screen position relative to visual offset
 	screenY = cursorVisualLine - p.visualOffsetY
@@ -241,13 +336,28 @@ func (e *Editor) renderWrappedSegmentAt(rect splitpane.Rect, p *Pane, screenRow,
 			style = styledRunes[bufferCol].Style
 		}
 
-		e.setCellAt(rect, gutterWidth+i, screenRow, r, style)
+		e.drawRuneAt(rect, seg, screenRow, gutterWidth, bufferCol, r, style)
+	}
+
+	if !seg.IsWrapped && e.options.Bool(options.IndentGuides) {
+		e.drawIndentGuidesAt(rect, p, screenRow, seg, gutterWidth)
 	}
 
 	// Highlight search matches
 	e.highlightSearchMatchesWrappedAt(rect, p, screenRow, lineNum, seg, gutterWidth)
 }
 
+// drawRuneAt draws the rune at column col of a segment's line over every
+// cell it takes, so a tab fills the cells up to the next tabstop
+func (e *Editor) drawRuneAt(rect splitpane.Rect, seg wrap.Line, screenRow, gutterWidth, col int, r rune, style tcell.Style) {
+	if r == '\t' {
+		r = ' '
+	}
+	for x := gutterWidth + seg.X(col); x < gutterWidth+seg.X(col+1) && x < rect.Width; x++ {
+		e.setCellAt(rect, x, screenRow, r, style)
+	}
+}
+
 func (e *Editor) highlightBracketWrapped(screenCol, screenRow, gutterWidth int, line string, bufferCol int) {
 	e.highlightBracketWrappedAt(splitpane.Rect{X: 0, Y: 0, Width: e.width, Height: e.height - 1}, screenCol, screenRow, gutterWidth, line, bufferCol)
 }
@@ -255,7 +365,7 @@ func (e *Editor) highlightBracketWrapped(screenCol, screenRow, gutterWidth int,
 func (e *Editor) highlightBracketWrappedAt(rect splitpane.Rect, screenCol, screenRow, gutterWidth int, line string, bufferCol int) {
 	runes := []rune(line)
 	if bufferCol < len(runes) {
-		style := tcell.StyleDefault.Background(tcell.NewRGBColor(255, 200, 0)).Foreground(tcell.ColorBlack).Bold(true)
+		style := e.theme.UI.MatchBracket
 		e.setCellAt(rect, gutterWidth+screenCol, screenRow, runes[bufferCol], style)
 	}
 }
@@ -272,21 +382,10 @@ func (e *Editor) highlightSearchMatchesWrappedAt(rect splitpane.Rect, p *Pane, s
 
 	line := []rune(p.buffer.Line(lineNum))
 
-	highlightStyle := tcell.StyleDefault.
-		Background(tcell.NewRGBColor(80, 80, 80)).
-		Foreground(tcell.ColorWhite).
-		Bold(true)
-
-	currentStyle := tcell.StyleDefault.
-		Background(tcell.NewRGBColor(0, 200, 200)).
-		Foreground(tcell.ColorBlack).
-		Bold(true)
-
-	for _, match := range p.search.GetMatches() {
-		if match.Line != lineNum {
-			continue
-		}
+	highlightStyle := e.theme.UI.SearchMatch
+	currentStyle := e.theme.UI.SearchCurrent
 
+	for _, match := range p.search.LineMatches(lineNum) {
 		isCurrent := p.search.Current() != nil &&
 			match.Line == p.search.Current().Line &&
 			match.Col == p.search.Current().Col
@@ -300,8 +399,7 @@ func (e *Editor) highlightSearchMatchesWrappedAt(rect splitpane.Rect, p *Pane, s
 		for i := 0; i < match.Len && match.Col+i < len(line); i++ {
 			bufferCol := match.Col + i
 			if bufferCol >= seg.StartCol && bufferCol < seg.StartCol+len([]rune(seg.Text)) {
-				screenCol := bufferCol - seg.StartCol
-				e.setCellAt(rect, gutterWidth+screenCol, screenRow, line[bufferCol], style)
+				e.drawRuneAt(rect, seg, screenRow, gutterWidth, bufferCol, line[bufferCol], style)
 			}
 		}
 	}
@@ -313,7 +411,7 @@ func (e *Editor) highlightBracket(x, y, gutterWidth int) {
 	line := p.buffer.Line(p.offsetY + y)
 	runes := []rune(line)
 	if x < len(runes) {
-		style := tcell.StyleDefault.Background(tcell.NewRGBColor(255, 200, 0)).Foreground(tcell.ColorBlack).Bold(true)
+		style := e.theme.UI.MatchBracket
 		e.term.SetCell(gutterWidth+x, y, runes[x], style)
 	}
 }
@@ -355,17 +453,8 @@ func (e *Editor) highlightSearchMatches(y, lineNum int, line string, gutterWidth
 		return
 	}
 
-	// All matches: dark gray background with white text
-	highlightStyle := tcell.StyleDefault.
-		Background(tcell.NewRGBColor(80, 80, 80)).
-		Foreground(tcell.ColorWhite).
-		Bold(true)
-
-	// Current match: orange background with black text (better contrast than pure yellow)
-	currentStyle := tcell.StyleDefault.
-		Background(tcell.NewRGBColor(255, 180, 0)).
-		Foreground(tcell.ColorBlack).
-		Bold(true)
+	highlightStyle := e.theme.UI.SearchMatch
+	currentStyle := e.theme.UI.SearchCurrent
 
 	for _, match := range p.search.GetMatches() {
 		if match.Line == lineNum {
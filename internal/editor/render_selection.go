@@ -3,7 +3,6 @@ package editor
 import (
 	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
 	"github.com/Adelodunpeter25/vx/internal/wrap"
-	"github.com/gdamore/tcell/v2"
 )
 
 // highlightSelection highlights the selected text on the given screen row
@@ -53,14 +52,11 @@ func (e *Editor) highlightSelectionAt(rect splitpane.Rect, p *Pane, screenRow, l
 	}
 
 	// Apply highlight style to selected characters
-	selectionStyle := tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorBlack)
+	selectionStyle := e.theme.UI.Selection
 	line := []rune(p.buffer.Line(lineNum))
 
 	for col := highlightStart; col < highlightEnd && col < len(line); col++ {
-		screenX := gutterWidth + (col - segStart)
-		if screenX >= gutterWidth && screenX < rect.Width {
-			e.setCellAt(rect, screenX, screenRow, line[col], selectionStyle)
-		}
+		e.drawRuneAt(rect, seg, screenRow, gutterWidth, col, line[col], selectionStyle)
 	}
 }
 
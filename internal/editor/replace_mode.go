@@ -1,20 +1,54 @@
 package editor
 
 import (
+	"fmt"
+
+	"github.com/Adelodunpeter25/vx/internal/options"
 	"github.com/Adelodunpeter25/vx/internal/replace"
 	"github.com/gdamore/tcell/v2"
 )
 
+// startReplaceMode begins find and replace. Matches are limited to the
+// visual selection when one is active.
+func (e *Editor) startReplaceMode() {
+	p := e.active()
+	p.mode = ModeReplace
+	p.replace.Start()
+	p.replaceGroup = 0
+	p.replace.SetInSelection(p.selection.IsActive())
+	p.replace.SetPreserveCase(e.options.Bool(options.PreserveCase))
+	p.msgManager.Clear()
+	p.lastKey = 0
+}
+
 func (e *Editor) handleReplaceMode(ev *tcell.EventKey) {
 	p := e.active()
 	state := p.replace.GetState()
 
 	switch ev.Key() {
 	case tcell.KeyEscape:
-		p.replace.Cancel()
-		p.mode = ModeNormal
+		e.finishReplace("")
 		p.msgManager.Clear()
-		p.renderCache.invalidate()
+		return
+
+	case tcell.KeyCtrlL:
+		// Toggle limiting matches to the selection
+		if state == replace.StateSearchInput || state == replace.StateReplaceInput {
+			if !p.selection.IsActive() {
+				p.msgManager.SetTransient("No selection")
+				return
+			}
+			p.replace.SetInSelection(!p.replace.InSelection())
+		}
+		return
+
+	case tcell.KeyCtrlK:
+		// Toggle case-preserving replacement
+		if state == replace.StateSearchInput || state == replace.StateReplaceInput {
+			preserve := !p.replace.PreservesCase()
+			_ = e.options.Set(options.PreserveCase, preserve)
+			p.replace.SetPreserveCase(preserve)
+		}
 		return
 
 	case tcell.KeyEnter:
@@ -25,8 +59,17 @@ func (e *Editor) handleReplaceMode(ev *tcell.EventKey) {
 				lines[i] = p.buffer.Line(i)
 			}
 			matches := p.search.Search(lines, p.replace.GetSearchTerm())
+			if p.replace.InSelection() {
+				if startLine, startCol, endLine, endCol, ok := p.selection.GetRange(); ok {
+					matches = replace.InRange(matches, startLine, startCol, endLine, endCol)
+				}
+			}
 			p.replace.ConfirmSearch(matches)
-			if len(matches) == 0 {
+			if err := p.search.Err(); err != nil {
+				p.msgManager.SetError("Invalid pattern: " + err.Error())
+				p.mode = ModeNormal
+				p.replace.Cancel()
+			} else if len(matches) == 0 {
 				p.msgManager.SetTransient("No matches found")
 				p.mode = ModeNormal
 				p.replace.Cancel()
@@ -35,12 +78,7 @@ func (e *Editor) handleReplaceMode(ev *tcell.EventKey) {
 		} else if state == replace.StateReplaceInput {
 			// Start confirmation
 			p.replace.ConfirmReplace()
-			match := p.replace.GetCurrentMatch()
-			if match != nil {
-				p.cursorY = match.Line
-				p.cursorX = match.Col
-				e.adjustScroll()
-			}
+			e.moveToReplaceMatch()
 			p.renderCache.invalidate()
 		}
 		return
@@ -58,65 +96,37 @@ func (e *Editor) handleReplaceMode(ev *tcell.EventKey) {
 		r := ev.Rune()
 
 		if state == replace.StateConfirm {
-			// Handle y/n/q during confirmation
+			// Handle y/n/a/l/q during confirmation
 			switch r {
 			case 'y':
-				// Replace current match
-				match := p.replace.GetCurrentMatch()
-				if match != nil {
-					// Delete old text and insert new text
-					searchLen := len(p.replace.GetSearchTerm())
-
-					// Delete characters one by one from the end
-					for i := 0; i < searchLen; i++ {
-						p.buffer.DeleteRune(match.Line, match.Col+searchLen-i)
-					}
-
-					// Insert replacement text character by character
-					replaceTerm := p.replace.GetReplaceTerm()
-					for i, r := range replaceTerm {
-						p.buffer.InsertRune(match.Line, match.Col+i, r)
-					}
-				}
-				// Move to next match
-				if !p.replace.NextMatch() {
-					p.msgManager.SetTransient("Replace complete")
-					p.mode = ModeNormal
-					p.search.Clear() // Clear search highlights
-				} else {
-					match = p.replace.GetCurrentMatch()
-					if match != nil {
-						p.cursorY = match.Line
-						p.cursorX = match.Col
-						e.adjustScroll()
-					}
-				}
-				p.renderCache.invalidate()
+				// Replace current match and move to the next one
+				e.replaceCurrentMatch()
+				e.nextReplaceMatch()
 
 			case 'n':
 				// Skip to next match
-				if !p.replace.NextMatch() {
-					p.msgManager.SetTransient("Replace complete")
-					p.mode = ModeNormal
-					p.search.Clear() // Clear search highlights
-				} else {
-					match := p.replace.GetCurrentMatch()
-					if match != nil {
-						p.cursorY = match.Line
-						p.cursorX = match.Col
-						e.adjustScroll()
+				e.nextReplaceMatch()
+
+			case 'a':
+				// Replace this and all remaining matches
+				for {
+					e.replaceCurrentMatch()
+					if !p.replace.NextMatch() {
+						break
 					}
 				}
-				p.renderCache.invalidate()
+				e.finishReplace("Replace complete")
+
+			case 'l':
+				// Replace this match and stop
+				e.replaceCurrentMatch()
+				e.finishReplace("Replace complete")
 
 			case 'q':
 				// Quit replace
-				p.replace.Cancel()
-				p.mode = ModeNormal
-				p.search.Clear() // Clear search highlights
-				p.msgManager.SetTransient("Replace cancelled")
-				p.renderCache.invalidate()
+				e.finishReplace("Replace cancelled")
 			}
+			p.renderCache.invalidate()
 		} else if state == replace.StateSearchInput {
 			p.replace.AppendToSearch(r)
 			p.renderCache.invalidate()
@@ -126,3 +136,65 @@ func (e *Editor) handleReplaceMode(ev *tcell.EventKey) {
 		}
 	}
 }
+
+// replaceCurrentMatch replaces the match being confirmed. All replacements
+// of a session go into one undo group, which is only open while a
+// replacement is made so nothing else can end up in it.
+func (e *Editor) replaceCurrentMatch() {
+	p := e.active()
+	match := p.replace.GetCurrentMatch()
+	if match == nil {
+		return
+	}
+	runes := []rune(p.buffer.Line(match.Line))
+	if match.Col+match.Len > len(runes) {
+		return
+	}
+	text := p.replace.ReplacementFor(string(runes[match.Col : match.Col+match.Len]))
+
+	stack := p.buffer.UndoStack()
+	p.replaceGroup = stack.ResumeGroup(p.replaceGroup)
+	defer stack.EndGroup()
+	p.buffer.ReplaceText(match.Line, match.Col, match.Len, text)
+	p.replace.RecordReplacement(lineRuneCount(text))
+	p.cursorY = match.Line
+	p.cursorX = match.Col
+}
+
+// nextReplaceMatch moves to the next match, or ends the session
+func (e *Editor) nextReplaceMatch() {
+	if !e.active().replace.NextMatch() {
+		e.finishReplace("Replace complete")
+		return
+	}
+	e.moveToReplaceMatch()
+}
+
+func (e *Editor) moveToReplaceMatch() {
+	p := e.active()
+	if match := p.replace.GetCurrentMatch(); match != nil {
+		p.cursorY = match.Line
+		p.cursorX = match.Col
+		e.adjustScroll()
+	}
+}
+
+// finishReplace ends the session
+func (e *Editor) finishReplace(msg string) {
+	p := e.active()
+	p.replaceGroup = 0
+	replaced := p.replace.Replaced()
+	p.replace.Cancel()
+	p.mode = ModeNormal
+	p.search.Clear() // Clear search highlights
+	e.clampCursor()
+	e.adjustScroll()
+	p.renderCache.invalidate()
+	if msg == "" {
+		return
+	}
+	if replaced > 0 {
+		msg = fmt.Sprintf("%s: %d replaced", msg, replaced)
+	}
+	p.msgManager.SetTransient(msg)
+}
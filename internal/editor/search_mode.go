@@ -2,11 +2,25 @@ package editor
 
 import (
 	"fmt"
+	"unicode"
 
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/search"
 	"github.com/Adelodunpeter25/vx/internal/terminal"
 	"github.com/gdamore/tcell/v2"
 )
 
+// startSearchMode enters incremental search from the current cursor position
+func (e *Editor) startSearchMode() {
+	p := e.active()
+	p.mode = ModeSearch
+	p.searchBuf = ""
+	p.searchOriginX = p.cursorX
+	p.searchOriginY = p.cursorY
+	p.msgManager.Clear()
+	p.lastKey = 0
+}
+
 func (e *Editor) handleSearchMode(ev *terminal.Event) {
 	p := e.active()
 	if ev.Key == tcell.KeyEscape {
@@ -14,18 +28,37 @@ func (e *Editor) handleSearchMode(ev *terminal.Event) {
 		p.searchBuf = ""
 		p.search.Clear()
 		p.msgManager.Clear()
+		// Return to where the search started
+		p.cursorX = p.searchOriginX
+		p.cursorY = p.searchOriginY
+		e.clampCursor()
+		e.adjustScroll()
 		return
 	}
 
 	if ev.Key == tcell.KeyEnter {
-		// Just exit search mode, results already visible
-		if p.search.HasMatches() {
-			p.msgManager.SetPersistent(fmt.Sprintf("/%s [%d/%d]", p.searchBuf, p.search.CurrentIndex(), p.search.MatchCount()))
+		// Just exit search mode, results already visible (or still streaming in)
+		if err := p.search.Err(); err != nil {
+			p.msgManager.SetError("Invalid pattern: " + err.Error())
+		} else if p.search.HasMatches() || p.search.Searching() {
+			e.updateSearchMessage(p)
 		}
+		p.searchBack = false
 		p.mode = ModeNormal
 		return
 	}
 
+	// Ctrl+R toggles regex mode, Ctrl+W toggles whole-word matching
+	if ev.Key == tcell.KeyCtrlR || ev.Key == tcell.KeyCtrlW {
+		name := options.Regex
+		if ev.Key == tcell.KeyCtrlW {
+			name = options.WholeWord
+		}
+		_ = e.options.Set(name, !e.options.Bool(name))
+		e.performIncrementalSearch()
+		return
+	}
+
 	if ev.Key == tcell.KeyBackspace || ev.Key == tcell.KeyBackspace2 {
 		if len(p.searchBuf) > 0 {
 			p.searchBuf = p.searchBuf[:len(p.searchBuf)-1]
@@ -50,63 +83,124 @@ func (e *Editor) performIncrementalSearch() {
 		return
 	}
 
-	// Get all lines from buffer
-	lines := make([]string, p.buffer.LineCount())
-	for i := 0; i < p.buffer.LineCount(); i++ {
-		lines[i] = p.buffer.Line(i)
-	}
-
-	// Perform search
-	matches := p.search.Search(lines, p.searchBuf)
+	e.startSearch(p, p.searchBuf, p.searchOriginY, p.searchOriginX, false)
 
-	if len(matches) == 0 {
-		p.msgManager.SetPersistent(fmt.Sprintf("Pattern not found: %s", p.searchBuf))
+	// Invalid patterns are reported inline by the search status line
+	if p.search.Err() != nil {
 		return
 	}
+	e.updateSearchMessage(p)
+}
 
-	// Jump to first match
-	match := p.search.Current()
-	if match != nil {
-		p.cursorY = match.Line
-		p.cursorX = match.Col
-		e.adjustScroll()
+// startSearch runs query in the background, starting with the visible lines.
+// The cursor jumps to the first match found from line/col.
+func (e *Editor) startSearch(p *Pane, query string, line, col int, backward bool) {
+	height := p.viewHeight
+	if height <= 0 {
+		height = e.paneAreaHeight()
 	}
-
-	p.msgManager.SetPersistent(fmt.Sprintf("/%s [%d/%d]", p.searchBuf, p.search.CurrentIndex(), p.search.MatchCount()))
+	p.searchFollow = true
+	p.search.SetNotify(e.term.Wake)
+	p.search.Start(p.buffer.Snapshot(), query, search.View{
+		First:    p.offsetY,
+		Last:     p.offsetY + height - 1,
+		Line:     line,
+		Col:      col,
+		Backward: backward,
+	})
 }
 
-func (e *Editor) performSearch() {
+// searchWordUnderCursor implements * and # (whole word) and g* and g#
+// (partial matches) using the keyword under or after the cursor
+func (e *Editor) searchWordUnderCursor(backward, partial bool) {
 	p := e.active()
-	if p.searchBuf == "" {
-		p.mode = ModeNormal
-		p.search.Clear()
-		p.msgManager.Clear()
+	start, end, ok := wordAt(p.buffer.Line(p.cursorY), p.cursorX)
+	if !ok {
+		p.msgManager.SetError("No identifier under cursor")
 		return
 	}
+	word := string([]rune(p.buffer.Line(p.cursorY))[start:end])
 
-	// Get all lines from buffer
-	lines := make([]string, p.buffer.LineCount())
-	for i := 0; i < p.buffer.LineCount(); i++ {
-		lines[i] = p.buffer.Line(i)
+	query := word
+	if !partial {
+		query = `\<` + word + `\>`
+	}
+	// Like vi, * and # ignore smartcase
+	if e.options.Bool(options.IgnoreCase) && e.options.Bool(options.SmartCase) && hasUpperRune(word) {
+		query = `\c` + query
 	}
 
-	// Perform search
-	matches := p.search.Search(lines, p.searchBuf)
-
-	if len(matches) == 0 {
-		p.msgManager.SetPersistent(fmt.Sprintf("Pattern not found: %s", p.searchBuf))
-		p.mode = ModeNormal
+	// Skip the occurrence under the cursor
+	col := start + 1
+	if backward {
+		col = start
+	}
+	p.searchBack = backward
+	e.startSearch(p, query, p.cursorY, col, backward)
+	if err := p.search.Err(); err != nil {
+		p.msgManager.SetError("Invalid pattern: " + err.Error())
 		return
 	}
+	e.updateSearchMessage(p)
+}
 
-	// Jump to first match
-	match := p.search.Current()
-	if match != nil {
-		p.cursorY = match.Line
-		p.cursorX = match.Col
-		e.adjustScroll()
+func hasUpperRune(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
 	}
+	return false
+}
 
-	p.msgManager.SetPersistent(fmt.Sprintf("/%s [%d/%d]", p.searchBuf, p.search.CurrentIndex(), p.search.MatchCount()))
-	p.mode = ModeNormal
+// gotoMatch moves the cursor to a match, applying the search offset
+func (e *Editor) gotoMatch(p *Pane, match *search.Match) {
+	line, col := p.search.Offset().Apply(*match)
+	if line >= p.buffer.LineCount() {
+		line = p.buffer.LineCount() - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+	if col < 0 {
+		col = 0
+	}
+	p.cursorY = line
+	p.cursorX = col
+	e.clampCursor()
+	e.adjustScroll()
+}
+
+// pollSearch picks up background search results for a pane
+func (e *Editor) pollSearch(p *Pane) bool {
+	wasSearching := p.search.Searching()
+	if !p.search.Poll() {
+		return false
+	}
+
+	// Jump to the first match once the search has picked one
+	if p == e.active() && p.searchFollow {
+		if match := p.search.Current(); match != nil {
+			p.searchFollow = false
+			e.gotoMatch(p, match)
+		}
+	}
+	if wasSearching {
+		e.updateSearchMessage(p)
+	}
+	p.renderCache.invalidate()
+	return true
+}
+
+// updateSearchMessage shows search progress or the final result in the status bar
+func (e *Editor) updateSearchMessage(p *Pane) {
+	query := p.search.Query()
+	switch {
+	case p.search.Searching():
+		p.msgManager.SetPersistent(fmt.Sprintf("/%s searching… %d matches so far", query, p.search.MatchCount()))
+	case p.search.HasMatches():
+		p.msgManager.SetPersistent(fmt.Sprintf("/%s [%d/%d]", query, p.search.CurrentIndex(), p.search.MatchCount()))
+	default:
+		p.msgManager.SetPersistent(fmt.Sprintf("Pattern not found: %s", query))
+	}
 }
@@ -0,0 +1,435 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/internal/clipboard"
+	"github.com/Adelodunpeter25/vx/internal/completion"
+	"github.com/Adelodunpeter25/vx/internal/snippet"
+	splitpane "github.com/Adelodunpeter25/vx/internal/split-pane"
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+	"github.com/Adelodunpeter25/vx/internal/wrap"
+	"github.com/gdamore/tcell/v2"
+)
+
+// snippetState is a snippet whose tab stops are being filled in
+type snippetState struct {
+	*snippet.Session
+	pane     *Pane
+	selected bool // typing replaces the placeholder of the current tab stop
+
+	// The snippet's lines and the cursor as the last key left them
+	version int
+	top     int
+	lines   []string
+	cursor  snippet.Pos
+}
+
+// snippetsFor returns the snippets for a pane's filetype
+func (e *Editor) snippetsFor(p *Pane) []*snippet.Snippet {
+	if e.snippets == nil {
+		e.snippets = snippet.NewLibrary(snippet.Dir())
+	}
+	list, err := e.snippets.For(p.syntax.Language())
+	if err != nil {
+		p.msgManager.SetError(utils.FormatUserError(err))
+	}
+	return list
+}
+
+// snippetsCommand runs :snippets reload, which reads the snippet files again
+// rather than waiting for the library to notice they changed
+func (e *Editor) snippetsCommand(arg string) (string, error) {
+	if arg != "reload" {
+		return "", fmt.Errorf("unknown :snippets argument: %s (reload)", arg)
+	}
+	if e.snippets == nil {
+		e.snippets = snippet.NewLibrary(snippet.Dir())
+	}
+	if err := e.snippets.Reload(); err != nil {
+		return "", err
+	}
+	return "Snippets reloaded", nil
+}
+
+// snippetCompletions offers the snippets whose prefixes match the word
+// before the cursor; accepting one expands it
+func (e *Editor) snippetCompletions(req completion.Request, add func([]completion.Item)) {
+	if req.Prefix() == "" && !req.Manual {
+		return
+	}
+	start := req.WordStart()
+	var items []completion.Item
+	for _, s := range e.snippetsFor(e.active()) {
+		body := s.Body
+		for _, prefix := range s.Prefixes {
+			items = append(items, completion.Item{
+				Text:   prefix,
+				Kind:   "snippet",
+				Start:  start,
+				Accept: func() { e.expandSnippet(start, body) },
+			})
+		}
+	}
+	add(items)
+}
+
+// handleSnippetKey expands the snippet whose prefix is before the cursor
+// with Tab, and moves between tab stops with Tab and Shift+Tab while one is
+// being filled in. It returns true if it used the key.
+func (e *Editor) handleSnippetKey(ev *terminal.Event) bool {
+	p := e.active()
+	if ev.Key == tcell.KeyTab {
+		if s, start, ok := snippet.Match(e.snippetsFor(p), p.buffer.Line(p.cursorY), p.cursorX); ok {
+			e.expandSnippet(start, s.Body)
+			return true
+		}
+		if e.snippet != nil {
+			e.snippet.Next()
+			e.selectStop()
+			return true
+		}
+		return false
+	}
+
+	s := e.snippet
+	if s == nil {
+		return false
+	}
+	if ev.Key == tcell.KeyBacktab {
+		s.Prev()
+		e.selectStop()
+		return true
+	}
+	if !s.selected {
+		return false
+	}
+	// A selected placeholder goes when typed over or deleted
+	s.selected = false
+	switch {
+	case ev.Key == tcell.KeyBackspace, ev.Key == tcell.KeyBackspace2, ev.Key == tcell.KeyDelete:
+		e.clearPlaceholder()
+		return true
+	case ev.Rune != 0, ev.Key == tcell.KeyEnter:
+		e.clearPlaceholder()
+	}
+	return false
+}
+
+// expandSnippet replaces the text from column start to the cursor with a
+// snippet as one undo step, and goes to its first tab stop
+func (e *Editor) expandSnippet(start int, body string) {
+	p := e.active()
+	buf := p.buffer
+	line := p.cursorY
+	start = min(start, p.cursorX)
+	x := snippet.Parse(body).Expand(e.snippetVariable, getIndentation(buf.Line(line)))
+
+	buf.UndoStack().BeginGroup()
+	buf.ReplaceRange(line, start, line, p.cursorX, x.Text)
+	buf.UndoStack().EndGroup()
+
+	e.snippet = &snippetState{Session: snippet.NewSession(x, line, start), pane: p}
+	e.selectStop()
+	p.renderCache.invalidate()
+}
+
+// selectStop moves the cursor to the end of the current tab stop's
+// placeholder, which typing replaces, offering its choices if it has them.
+// Reaching the final tab stop ends the snippet.
+func (e *Editor) selectStop() {
+	p := e.active()
+	s := e.snippet
+	stop := s.Stop()
+	r := stop.Ranges[0]
+	p.cursorY, p.cursorX = r.End.Line, r.End.Col
+	s.selected = r.Start != r.End
+	e.completion = nil
+	e.adjustScroll()
+	if s.Final() {
+		e.snippet = nil
+		return
+	}
+	if len(stop.Choices) > 0 {
+		e.offerChoices(stop)
+	}
+	e.markSnippet()
+}
+
+// offerChoices opens the completion menu on the choices of a tab stop, with
+// the one in the text selected. Accepting a choice goes on to the next tab
+// stop.
+func (e *Editor) offerChoices(stop *snippet.Stop) {
+	p := e.active()
+	r := stop.Ranges[0]
+	current := rangeText(p.buffer, r)
+	m := completion.NewMenu(r.Start.Line)
+	m.Update(p.buffer.Line(r.Start.Line), r.Start.Col)
+	items := make([]completion.Item, len(stop.Choices))
+	selected := 0
+	for i, choice := range stop.Choices {
+		item := completion.Item{Text: choice, Kind: "choice", Start: r.Start.Col}
+		item.Accept = func() {
+			e.insertCompletion(item)
+			e.syncSnippet()
+			if e.snippet != nil {
+				e.snippet.Next()
+				e.selectStop()
+			}
+		}
+		items[i] = item
+		if choice == current {
+			selected = i
+		}
+	}
+	m.Add(items)
+	m.Move(selected)
+	e.completion = m
+}
+
+// clearPlaceholder deletes the placeholder of the current tab stop
+func (e *Editor) clearPlaceholder() {
+	p := e.active()
+	r := e.snippet.Stop().Ranges[0]
+	p.buffer.ReplaceRange(r.Start.Line, r.Start.Col, r.End.Line, r.End.Col, "")
+	p.cursorY, p.cursorX = r.Start.Line, r.Start.Col
+}
+
+// markSnippet notes the snippet's lines and the cursor, for syncSnippet to
+// compare the next key's edits with
+func (e *Editor) markSnippet() {
+	s := e.snippet
+	p := s.pane
+	top, bottom := s.Lines()
+	s.version = p.buffer.ModVersion()
+	s.top = top
+	s.lines = s.lines[:0]
+	for l := top; l <= bottom && l < p.buffer.LineCount(); l++ {
+		s.lines = append(s.lines, p.buffer.Line(l))
+	}
+	s.cursor = snippet.Pos{Line: p.cursorY, Col: p.cursorX}
+}
+
+// syncSnippet follows the edits of a key through the snippet, copying the
+// current placeholder into its mirrors. The snippet ends once the cursor
+// leaves it or insert mode.
+func (e *Editor) syncSnippet() {
+	s := e.snippet
+	if s == nil {
+		return
+	}
+	p := s.pane
+	if e.active() != p || p.mode != ModeInsert || !e.trackSnippetEdit() ||
+		!s.Contains(snippet.Pos{Line: p.cursorY, Col: p.cursorX}) {
+		e.snippet = nil
+		return
+	}
+	e.mirrorSnippet()
+	e.markSnippet()
+}
+
+// trackSnippetEdit moves the tab stops for the edits made since
+// markSnippet. Lines may have been added or removed above the snippet; the
+// rest is taken to be one edit around the cursor, found by comparing the
+// snippet's lines. It returns false when the edits cannot be followed.
+func (e *Editor) trackSnippetEdit() bool {
+	s := e.snippet
+	p := s.pane
+	buf := p.buffer
+	changes, ok := buf.ChangesSince(s.version)
+	if !ok {
+		return false
+	}
+	if len(changes) == 0 {
+		return true
+	}
+	top, bottom := s.top, s.top+len(s.lines)-1
+	for _, c := range changes {
+		switch {
+		case c.Line < top-1 || (c.Line == top-1 && c.Delta >= 0):
+			top += c.Delta
+			bottom += c.Delta
+		case c.Line < top:
+			// The first line was joined to the one above
+			return false
+		case c.Line <= bottom:
+			bottom += c.Delta
+		}
+	}
+	if top < 0 || bottom < top || bottom >= buf.LineCount() || p.cursorY < top || p.cursorY > bottom {
+		return false
+	}
+	if top != s.top {
+		s.MoveLines(top - s.top)
+	}
+
+	lines := make([]string, 0, bottom-top+1)
+	for l := top; l <= bottom; l++ {
+		lines = append(lines, buf.Line(l))
+	}
+	old := []rune(strings.Join(s.lines, "\n"))
+	cur := []rune(strings.Join(lines, "\n"))
+	c0 := min(runeOffset(s.lines, s.cursor.Line-s.top, s.cursor.Col), len(old))
+	c1 := min(runeOffset(lines, p.cursorY-top, p.cursorX), len(cur))
+
+	// What is the same before and after the cursor is not part of the edit
+	head := 0
+	for head < min(c0, c1) && old[head] == cur[head] {
+		head++
+	}
+	tail := 0
+	for tail < min(len(old)-c0, len(cur)-c1) && old[len(old)-1-tail] == cur[len(cur)-1-tail] {
+		tail++
+	}
+	s.Apply(snippet.Edit{
+		Start:  offsetPos(s.lines, top, head),
+		End:    offsetPos(s.lines, top, len(old)-tail),
+		NewEnd: offsetPos(lines, top, len(cur)-tail),
+	}, &s.Stop().Ranges[0])
+	return true
+}
+
+// mirrorSnippet copies the current placeholder into its mirrors
+func (e *Editor) mirrorSnippet() {
+	s := e.snippet
+	p := s.pane
+	stop := s.Stop()
+	text := rangeText(p.buffer, stop.Ranges[0])
+	for i := 1; i < len(stop.Ranges); i++ {
+		r := stop.Ranges[i]
+		want := r.Transform.Apply(text)
+		if rangeText(p.buffer, r) == want {
+			continue
+		}
+		line, col := p.buffer.ReplaceRange(r.Start.Line, r.Start.Col, r.End.Line, r.End.Col, want)
+		ed := snippet.Edit{Start: r.Start, End: r.End, NewEnd: snippet.Pos{Line: line, Col: col}}
+		s.Apply(ed, &stop.Ranges[i])
+		cursor := ed.Move(snippet.Pos{Line: p.cursorY, Col: p.cursorX}, false)
+		p.cursorY, p.cursorX = cursor.Line, cursor.Col
+	}
+}
+
+// rangeText returns the text of a range of a buffer
+func rangeText(buf *buffer.Buffer, r snippet.Range) string {
+	var b strings.Builder
+	for l := r.Start.Line; l <= r.End.Line && l < buf.LineCount(); l++ {
+		runes := []rune(buf.Line(l))
+		from, to := 0, len(runes)
+		if l == r.Start.Line {
+			from = min(r.Start.Col, len(runes))
+		}
+		if l == r.End.Line {
+			to = min(r.End.Col, len(runes))
+		}
+		if l > r.Start.Line {
+			b.WriteByte('\n')
+		}
+		b.WriteString(string(runes[from:max(from, to)]))
+	}
+	return b.String()
+}
+
+// runeOffset returns the offset of line and col in lines joined by newlines
+func runeOffset(lines []string, line, col int) int {
+	off := 0
+	for i := 0; i < line && i < len(lines); i++ {
+		off += utf8.RuneCountInString(lines[i]) + 1
+	}
+	return off + col
+}
+
+// offsetPos returns the position of an offset in lines joined by newlines,
+// the first of which is line top
+func offsetPos(lines []string, top, off int) snippet.Pos {
+	for i, line := range lines {
+		n := utf8.RuneCountInString(line)
+		if off <= n || i == len(lines)-1 {
+			return snippet.Pos{Line: top + i, Col: off}
+		}
+		off -= n + 1
+	}
+	return snippet.Pos{Line: top}
+}
+
+// snippetVariable returns the value of a snippet variable for the cursor
+// in the active buffer
+func (e *Editor) snippetVariable(name string) (string, bool) {
+	p := e.active()
+	path := p.buffer.Filename()
+	if abs, err := filepath.Abs(path); err == nil && path != "" {
+		path = abs
+	}
+	root, _ := e.grepRoot("")
+	line := p.buffer.Line(p.cursorY)
+	switch name {
+	case "TM_FILENAME", "TM_FILENAME_BASE", "TM_DIRECTORY", "TM_FILEPATH", "RELATIVE_FILEPATH":
+		if path == "" {
+			return "", true
+		}
+		switch name {
+		case "TM_FILENAME":
+			return filepath.Base(path), true
+		case "TM_FILENAME_BASE":
+			base := filepath.Base(path)
+			return strings.TrimSuffix(base, filepath.Ext(base)), true
+		case "TM_DIRECTORY":
+			return filepath.Dir(path), true
+		case "TM_FILEPATH":
+			return path, true
+		}
+		return relativeTo(root, path), true
+	case "WORKSPACE_NAME":
+		return filepath.Base(root), true
+	case "WORKSPACE_FOLDER":
+		return root, true
+	case "TM_LINE_INDEX":
+		return strconv.Itoa(p.cursorY), true
+	case "TM_LINE_NUMBER":
+		return strconv.Itoa(p.cursorY + 1), true
+	case "TM_CURRENT_LINE":
+		return line, true
+	case "TM_CURRENT_WORD":
+		if start, end, ok := wordAt(line, p.cursorX); ok && start <= p.cursorX {
+			return string([]rune(line)[start:end]), true
+		}
+		return "", true
+	case "TM_SELECTED_TEXT":
+		return "", true
+	case "CLIPBOARD":
+		text, err := clipboard.Paste()
+		return text, err == nil
+	}
+	return snippet.Variable(name, time.Now())
+}
+
+// highlightSnippetAt shows the placeholder that typing will replace
+func (e *Editor) highlightSnippetAt(rect splitpane.Rect, p *Pane, screenRow, lineNum int, seg wrap.Line, gutterWidth int) {
+	s := e.snippet
+	if s == nil || s.pane != p || !s.selected {
+		return
+	}
+	line := []rune(p.buffer.Line(lineNum))
+	segEnd := seg.StartCol + len([]rune(seg.Text))
+	for _, r := range s.Stop().Ranges {
+		if lineNum < r.Start.Line || lineNum > r.End.Line {
+			continue
+		}
+		from, to := seg.StartCol, segEnd
+		if lineNum == r.Start.Line {
+			from = max(from, r.Start.Col)
+		}
+		if lineNum == r.End.Line {
+			to = min(to, r.End.Col)
+		}
+		for col := from; col < to && col < len(line); col++ {
+			e.drawRuneAt(rect, seg, screenRow, gutterWidth, col, line[col], e.theme.UI.Selection)
+		}
+	}
+}
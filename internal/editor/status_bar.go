@@ -5,14 +5,17 @@ import (
 	"os"
 	"strings"
 
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+	"github.com/Adelodunpeter25/vx/internal/options"
 	"github.com/Adelodunpeter25/vx/internal/replace"
+	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
 )
 
 func (e *Editor) renderStatusLine() {
 	p := e.active()
 	y := e.height - 1
-	style := tcell.StyleDefault.Reverse(true)
+	style := e.theme.UI.StatusBar
 
 	// Clear status line
 	for x := 0; x < e.width; x++ {
@@ -45,24 +48,62 @@ func (e *Editor) renderCommandStatus(y int, style tcell.Style) {
 }
 
 func (e *Editor) renderSearchStatus(y int, style tcell.Style) {
-	search := "/" + e.active().searchBuf
+	p := e.active()
+	search := "/" + p.searchBuf
 	e.term.DrawText(0, y, search, style)
+	x := len([]rune(search)) + 1
+
+	// Live pattern error while typing
+	if err := p.search.Err(); err != nil {
+		errStyle := e.theme.UI.StatusError
+		e.term.DrawText(x, y, "E: "+err.Error(), errStyle)
+	}
+
+	// Progress and active search flags on the right
+	flags := ""
+	if p.search.Searching() {
+		flags += fmt.Sprintf(" searching… %d matches so far", p.search.MatchCount())
+	}
+	if e.options.Bool(options.Regex) {
+		flags += " [regex]"
+	}
+	if e.options.Bool(options.WholeWord) {
+		flags += " [word]"
+	}
+	if flags != "" {
+		flags += " "
+		e.term.DrawText(e.width-len([]rune(flags)), y, flags, style)
+	}
 }
 
 func (e *Editor) renderReplaceStatus(y int, style tcell.Style) {
-	state := e.active().replace.GetState()
+	r := e.active().replace
+	state := r.GetState()
 
 	switch state {
 	case replace.StateSearchInput:
-		prompt := "Find: " + e.active().replace.GetSearchTerm()
+		prompt := "Find: " + r.GetSearchTerm()
 		e.term.DrawText(0, y, prompt, style)
 	case replace.StateReplaceInput:
-		prompt := fmt.Sprintf("Find: %s | Replace: %s", e.active().replace.GetSearchTerm(), e.active().replace.GetReplaceTerm())
+		prompt := fmt.Sprintf("Find: %s | Replace: %s", r.GetSearchTerm(), r.GetReplaceTerm())
 		e.term.DrawText(0, y, prompt, style)
 	case replace.StateConfirm:
-		prompt := fmt.Sprintf("Replace? [y/n/q] (%d/%d)", e.active().replace.GetCurrentIndex(), e.active().replace.GetMatchCount())
+		prompt := fmt.Sprintf("Replace? [y/n/a/l/q] (%d/%d)", r.GetCurrentIndex(), r.GetMatchCount())
 		e.term.DrawText(0, y, prompt, style)
 	}
+
+	// Flags on the right, toggled with Ctrl+L and Ctrl+K
+	flags := ""
+	if r.InSelection() {
+		flags += "[selection]"
+	}
+	if r.PreservesCase() {
+		flags += "[keep case]"
+	}
+	if flags != "" {
+		flags += " "
+		e.term.DrawText(e.width-len([]rune(flags)), y, flags, style)
+	}
 }
 
 func (e *Editor) renderNormalStatus(y int, style tcell.Style) {
@@ -76,6 +117,8 @@ func (e *Editor) renderNormalStatus(y int, style tcell.Style) {
 	focus := "Editor"
 	if e.fileBrowser != nil && e.fileBrowser.Open && e.fileBrowser.Focused {
 		focus = "Files"
+	} else if e.outline != nil && e.outline.Open && e.outline.Focused {
+		focus = "Outline"
 	}
 	prefix := " " + mode + " | " + focus + " "
 	e.term.DrawText(0, y, prefix, style)
@@ -113,7 +156,15 @@ func (e *Editor) renderFileInfo(y int, style tcell.Style, modeWidth int) {
 		modified = " [+]"
 	}
 	info := filename + modified
+	if d, ok := e.lineDiagnostic(p, p.cursorY); ok {
+		// The problem on the cursor line takes the place of the filename
+		info = severitySign(d.Severity) + ": " + utils.FirstLine(d.Message)
+		if d.Severity == diagnostic.Error {
+			style = e.theme.UI.StatusError
+		}
+	}
 	e.term.DrawText(modeWidth+1, y, info, style)
+	style = e.theme.UI.StatusBar
 
 	// Show pane count and cursor position
 	e.renderRightInfo(y, style)
@@ -128,7 +179,7 @@ func (e *Editor) renderRightInfo(y int, style tcell.Style) {
 
 		// Don't show cursor position in preview mode
 		if !p.preview.IsEnabled() {
-			pos := fmt.Sprintf(" %d,%d ", p.cursorY+1, p.cursorX+1)
+			pos := e.diagnosticCounts(p) + positionInfo(p)
 			paneInfoX -= len(pos)
 			e.term.DrawText(e.width-len(pos), y, pos, style)
 		}
@@ -138,12 +189,21 @@ func (e *Editor) renderRightInfo(y int, style tcell.Style) {
 		// Don't show cursor position in preview mode
 		p := e.active()
 		if !p.preview.IsEnabled() {
-			pos := fmt.Sprintf(" %d,%d ", p.cursorY+1, p.cursorX+1)
+			pos := e.diagnosticCounts(p) + positionInfo(p)
 			e.term.DrawText(e.width-len(pos), y, pos, style)
 		}
 	}
 }
 
+// positionInfo shows the filetype and cursor position
+func positionInfo(p *Pane) string {
+	pos := fmt.Sprintf(" %d,%d ", p.cursorY+1, p.cursorX+1)
+	if ft := p.syntax.Language(); ft != "" {
+		pos = " " + ft + " |" + pos
+	}
+	return pos
+}
+
 func (e *Editor) renderFileInfoMessage(y int, style tcell.Style, modeWidth int, message string) {
 	// Parse message: "filename" size, lines
 	parts := strings.SplitN(message, "\"", 3)
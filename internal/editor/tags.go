@@ -0,0 +1,273 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/options"
+	"github.com/Adelodunpeter25/vx/internal/picker"
+	"github.com/Adelodunpeter25/vx/internal/syntax"
+	"github.com/Adelodunpeter25/vx/internal/tags"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// maxJumps is the depth of the tag stack
+const maxJumps = 100
+
+// tagJob is a symbol index being built in the background
+type tagJob struct {
+	root   string
+	cancel context.CancelFunc
+	result chan *tags.Index
+}
+
+// jump is where the cursor was before jumping to a tag
+type jump struct {
+	path      string
+	line, col int
+}
+
+// withTags runs then with the symbol index of the project. The index is
+// loaded from a tags file in the file browser root if there is one, and
+// built in the background otherwise, in which case then runs once it is
+// ready.
+func (e *Editor) withTags(then func(idx *tags.Index)) {
+	p := e.active()
+	root, err := e.grepRoot("")
+	if err != nil {
+		p.msgManager.SetError(utils.FormatUserError(err))
+		return
+	}
+	if e.tagIndex != nil && e.tagIndex.Root == root {
+		e.refreshBufferTags()
+		then(e.tagIndex)
+		return
+	}
+	e.tagIndex = nil
+	e.tagPending = then
+	if e.tagJob != nil && e.tagJob.root == root {
+		return
+	}
+	e.stopTagJob()
+
+	if file := tags.FindFile(root); file != "" {
+		idx, err := tags.LoadFile(file)
+		if err != nil {
+			e.tagPending = nil
+			p.msgManager.SetError(utils.FormatUserError(err))
+			return
+		}
+		e.tagIndex = idx
+		e.tagPending = nil
+		then(idx)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &tagJob{root: root, cancel: cancel, result: make(chan *tags.Index, 1)}
+	e.tagJob = job
+	hidden := e.options.Bool(options.ShowHidden)
+	go func() {
+		job.result <- tags.Build(ctx, root, hidden)
+		e.term.Wake()
+	}()
+	p.msgManager.SetPersistent("Indexing symbols...")
+}
+
+func (e *Editor) stopTagJob() {
+	if e.tagJob != nil {
+		e.tagJob.cancel()
+		e.tagJob = nil
+	}
+}
+
+// reindexTags drops the symbol index and builds it again
+func (e *Editor) reindexTags() {
+	e.tagIndex = nil
+	e.stopTagJob()
+	e.withTags(func(idx *tags.Index) {
+		e.active().msgManager.SetPersistent(indexSummary(idx))
+	})
+}
+
+// pollTags picks up a finished symbol index and runs what was waiting on it
+func (e *Editor) pollTags() bool {
+	job := e.tagJob
+	if job == nil {
+		return false
+	}
+	select {
+	case idx := <-job.result:
+		e.tagJob = nil
+		if idx == nil {
+			return false
+		}
+		e.tagIndex = idx
+		e.active().msgManager.SetPersistent(indexSummary(idx))
+		if then := e.tagPending; then != nil {
+			e.tagPending = nil
+			e.refreshBufferTags()
+			then(idx)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func indexSummary(idx *tags.Index) string {
+	if idx.Source != "" {
+		return fmt.Sprintf("Loaded %d tags from %s", idx.Len(), filepath.Base(idx.Source))
+	}
+	return fmt.Sprintf("Indexed %d symbols", idx.Len())
+}
+
+// refreshBufferTags replaces the indexed symbols of open files with those of
+// their buffers, so unsaved and saved edits are found. Indexes loaded from
+// a tags file are left as ctags wrote them.
+func (e *Editor) refreshBufferTags() {
+	if e.tagIndex == nil || e.tagIndex.Source != "" {
+		return
+	}
+	for _, p := range e.panes {
+		name := p.buffer.Filename()
+		if name == "" || p.buffer.LineCount() > syntax.MaxHighlightLines {
+			continue
+		}
+		path, err := filepath.Abs(name)
+		if err != nil {
+			continue
+		}
+		p.syncOutline()
+		e.tagIndex.Replace(path, tags.FromSymbols(path, p.outline.Symbols()))
+	}
+}
+
+// jumpToDefinition jumps to the definition of the word under the cursor
+func (e *Editor) jumpToDefinition() {
+	p := e.active()
+	line := p.buffer.Line(p.cursorY)
+	start, end, ok := wordAt(line, p.cursorX)
+	if !ok {
+		p.msgManager.SetError("No identifier under cursor")
+		return
+	}
+	e.jumpToTagName(string([]rune(line)[start:end]))
+}
+
+// jumpToTagName jumps to the definition of name. When there are several,
+// they are offered in a picker.
+func (e *Editor) jumpToTagName(name string) {
+	e.withTags(func(idx *tags.Index) {
+		p := e.active()
+		current, _ := filepath.Abs(p.buffer.Filename())
+		found := idx.Lookup(name, current)
+		switch len(found) {
+		case 0:
+			p.msgManager.SetError("Tag not found: " + name)
+		case 1:
+			e.jumpToTag(found[0])
+		default:
+			e.openTagPicker(fmt.Sprintf("Definitions of %s", name), "", found)
+		}
+	})
+}
+
+// jumpToTag opens the file of a tag and puts the cursor on its name. The
+// position jumped from goes on the tag stack.
+func (e *Editor) jumpToTag(tag tags.Tag) {
+	from := e.currentJump()
+	if !e.jumpToLocation(tag.Path, max(tag.Line, 0), 0) {
+		return
+	}
+	e.pushJump(from)
+	p := e.active()
+	line := tag.Find(p.buffer)
+	if line < 0 {
+		p.msgManager.SetError("Tag not found in file: " + tag.Name)
+		return
+	}
+	name := tag.Name
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 && i < len(name)-1 {
+		name = name[i+1:]
+	}
+	col := 0
+	if i := strings.Index(p.buffer.Line(line), name); i >= 0 {
+		col = len([]rune(p.buffer.Line(line)[:i]))
+	}
+	e.moveCursorTo(line, col)
+}
+
+// currentJump returns the cursor position for the tag stack
+func (e *Editor) currentJump() jump {
+	p := e.active()
+	path := p.buffer.Filename()
+	if abs, err := filepath.Abs(path); err == nil && path != "" {
+		path = abs
+	}
+	return jump{path: path, line: p.cursorY, col: p.cursorX}
+}
+
+// pushJump puts the position a jump started from on the tag stack
+func (e *Editor) pushJump(from jump) {
+	e.jumps = append(e.jumps, from)
+	if len(e.jumps) > maxJumps {
+		e.jumps = e.jumps[len(e.jumps)-maxJumps:]
+	}
+}
+
+// popJump returns to where the last tag jump started
+func (e *Editor) popJump() {
+	if len(e.jumps) == 0 {
+		e.active().msgManager.SetError("Tag stack empty")
+		return
+	}
+	j := e.jumps[len(e.jumps)-1]
+	if e.jumpToLocation(j.path, j.line, j.col) {
+		e.jumps = e.jumps[:len(e.jumps)-1]
+	}
+}
+
+// openSymbolPicker offers every symbol in the project in a fuzzy picker
+func (e *Editor) openSymbolPicker(query string) {
+	e.openTagPicker("Symbols", query, nil)
+	if e.tagIndex == nil {
+		e.picker.Status = "indexing..."
+	}
+	e.withTags(func(idx *tags.Index) {
+		if e.picker != nil {
+			e.setPickerTags(idx.All())
+		}
+	})
+}
+
+// openTagPicker shows a picker over tags that jumps to the chosen one
+func (e *Editor) openTagPicker(title, query string, list []tags.Tag) {
+	e.openPicker(title, query, nil, nil)
+	e.setPickerTags(list)
+}
+
+func (e *Editor) setPickerTags(list []tags.Tag) {
+	root := ""
+	if e.tagIndex != nil {
+		root = e.tagIndex.Root
+	}
+	items := make([]picker.Item, len(list))
+	for i, t := range list {
+		path := t.Path
+		if rel, err := filepath.Rel(root, path); err == nil && root != "" && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		if t.Line >= 0 {
+			path = fmt.Sprintf("%s:%d", path, t.Line+1)
+		}
+		items[i] = picker.Item{Label: t.Name, Detail: strings.TrimSpace(t.Kind + " " + path)}
+	}
+	e.picker.Status = ""
+	e.picker.SetItems(items)
+	e.pickerAccept = func(i int) {
+		e.jumpToTag(list[i])
+	}
+}
@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"os"
+
+	filebrowser "github.com/Adelodunpeter25/vx/internal/file-browser"
+	"github.com/Adelodunpeter25/vx/internal/theme"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// colorSchemeEnv names the color scheme to start with
+const colorSchemeEnv = "VX_COLORSCHEME"
+
+// initTheme applies the startup color scheme
+func (e *Editor) initTheme() {
+	name := os.Getenv(colorSchemeEnv)
+	if name == "" {
+		e.applyTheme(theme.Default())
+		return
+	}
+	if err := e.setColorScheme(name); err != nil {
+		e.applyTheme(theme.Default())
+		e.active().msgManager.SetError(err.Error())
+	}
+}
+
+// setColorScheme switches to a color scheme by name or theme file path
+func (e *Editor) setColorScheme(name string) error {
+	t, err := theme.Load(filebrowser.ExpandHome(name))
+	if err != nil {
+		return err
+	}
+	e.applyTheme(t)
+	return nil
+}
+
+// applyTheme makes t the current theme and redraws everything with it
+func (e *Editor) applyTheme(t *theme.Theme) {
+	t.Fit(e.term.Colors())
+	e.theme = t
+	e.term.SetBaseStyle(t.UI.Normal)
+	highlight.SetStyle(t.TokenStyle)
+	for _, p := range e.panes {
+		p.syntax.InvalidateCache()
+		p.renderCache.invalidate()
+	}
+}
@@ -75,3 +75,32 @@ func (e *Editor) moveWordBackward() {
 
 	e.adjustScroll()
 }
+
+// isWordRune reports whether r can be part of a keyword for * and #
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordAt returns the bounds of the keyword under col, or the first one after
+// it on the line
+func wordAt(line string, col int) (start, end int, ok bool) {
+	runes := []rune(line)
+	if col < 0 {
+		col = 0
+	}
+	start = col
+	for start < len(runes) && !isWordRune(runes[start]) {
+		start++
+	}
+	if start >= len(runes) {
+		return 0, 0, false
+	}
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+	end = start
+	for end < len(runes) && isWordRune(runes[end]) {
+		end++
+	}
+	return start, end, true
+}
@@ -0,0 +1,149 @@
+// Package errorformat reads the locations of compiler errors out of build
+// output with patterns in the style of Vim's errorformat, e.g. "%f:%l:%c: %m".
+package errorformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default covers Go, gcc and clang style file:line:col: messages and the
+// file(line,col): messages of tsc
+const Default = `%f:%l:%c: %t: %m,%f:%l:%c: %m,%f:%l: %t: %m,%f:%l: %m,%f(%l\,%c): %t %m`
+
+// Entry is a location found in a line of output
+type Entry struct {
+	Path    string // as printed, often relative to where the build ran
+	Line    int    // 0-based
+	Col     int    // 0-based, 0 when the line has no column
+	Type    string // "error", "warning", ... or "" when the line says none
+	Message string
+}
+
+// Format is a compiled list of patterns, tried in order
+type Format struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	re     *regexp.Regexp
+	fields []byte // the directive of each group: f, l, c, t or m
+}
+
+// directives are the regular expressions the % directives stand for
+var directives = map[byte]string{
+	'f': `(\S.*?)`,
+	'l': `(\d+)`,
+	'c': `(\d+)`,
+	't': `([Ee]rror|[Ww]arning|[Nn]ote|[Ii]nfo|[Hh]int)`,
+	'm': `(.*)`,
+}
+
+// Compile parses a comma-separated list of patterns. In a pattern %f is the
+// file, %l the line, %c the column, %t a word such as error or warning, %m
+// the message and %% a percent sign; "\," is a comma, and everything else
+// matches itself. Each pattern needs a %f and a %l.
+func Compile(efm string) (*Format, error) {
+	f := &Format{}
+	for _, src := range splitList(efm) {
+		if src == "" {
+			continue
+		}
+		p, err := compilePattern(src)
+		if err != nil {
+			return nil, err
+		}
+		f.patterns = append(f.patterns, p)
+	}
+	if len(f.patterns) == 0 {
+		return nil, fmt.Errorf("errorformat is empty")
+	}
+	return f, nil
+}
+
+// splitList splits efm at the commas not escaped with a backslash
+func splitList(efm string) []string {
+	var list []string
+	var cur strings.Builder
+	for i := 0; i < len(efm); i++ {
+		switch {
+		case efm[i] == '\\' && i+1 < len(efm) && efm[i+1] == ',':
+			cur.WriteByte(',')
+			i++
+		case efm[i] == ',':
+			list = append(list, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(efm[i])
+		}
+	}
+	return append(list, cur.String())
+}
+
+func compilePattern(src string) (pattern, error) {
+	var p pattern
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(src); i++ {
+		if src[i] != '%' {
+			expr.WriteString(regexp.QuoteMeta(src[i : i+1]))
+			continue
+		}
+		if i+1 == len(src) {
+			return p, fmt.Errorf("errorformat %q ends in %%", src)
+		}
+		i++
+		d := src[i]
+		if d == '%' {
+			expr.WriteString("%")
+			continue
+		}
+		re, ok := directives[d]
+		if !ok {
+			return p, fmt.Errorf("errorformat %q: unknown %%%c", src, d)
+		}
+		expr.WriteString(re)
+		p.fields = append(p.fields, d)
+	}
+	expr.WriteString("$")
+	fields := string(p.fields)
+	if !strings.Contains(fields, "f") || !strings.Contains(fields, "l") {
+		return p, fmt.Errorf("errorformat %q needs %%f and %%l", src)
+	}
+	p.re = regexp.MustCompile(expr.String())
+	return p, nil
+}
+
+// Match returns the location in a line of output, from the first pattern
+// that matches it
+func (f *Format) Match(line string) (Entry, bool) {
+	line = strings.TrimRight(line, "\r")
+	for _, p := range f.patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var e Entry
+		for i, d := range p.fields {
+			v := m[i+1]
+			switch d {
+			case 'f':
+				e.Path = v
+			case 'l':
+				n, _ := strconv.Atoi(v)
+				e.Line = max(n-1, 0)
+			case 'c':
+				n, _ := strconv.Atoi(v)
+				e.Col = max(n-1, 0)
+			case 't':
+				e.Type = strings.ToLower(v)
+			case 'm':
+				e.Message = v
+			}
+		}
+		return e, true
+	}
+	return Entry{}, false
+}
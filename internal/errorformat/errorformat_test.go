@@ -0,0 +1,80 @@
+package errorformat
+
+import "testing"
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		efm     string
+		want    int // patterns
+		wantErr string
+	}{
+		{Default, 5, ""},
+		{"%f:%l", 1, ""},
+		{"%f:%l,,%f(%l)", 2, ""},
+		{`%f:%l\,%c`, 1, ""},
+		{"100%%: %f:%l", 1, ""},
+		{"", 0, "errorformat is empty"},
+		{",", 0, "errorformat is empty"},
+		{"%f:%c", 0, `errorformat "%f:%c" needs %f and %l`},
+		{"%l: %m", 0, `errorformat "%l: %m" needs %f and %l`},
+		{"%f:%l:%", 0, `errorformat "%f:%l:%" ends in %`},
+		{"%f:%l:%x", 0, `errorformat "%f:%l:%x": unknown %x`},
+		{"%f:%l,%[", 0, `errorformat "%[": unknown %[`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.efm, func(t *testing.T) {
+			f, err := Compile(tt.efm)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Compile(%q) error = %v, want %q", tt.efm, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.efm, err)
+			}
+			if len(f.patterns) != tt.want {
+				t.Errorf("Compile(%q) has %d patterns, want %d", tt.efm, len(f.patterns), tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		efm  string
+		line string
+		want Entry
+		ok   bool
+	}{
+		{"go", Default, "./main.go:12:5: undefined: x", Entry{"./main.go", 11, 4, "", "undefined: x"}, true},
+		{"gcc", Default, "src/a.c:3:1: warning: unused variable", Entry{"src/a.c", 2, 0, "warning", "unused variable"}, true},
+		{"gcc capitalized", Default, "a.c:3:1: Error: bad", Entry{"a.c", 2, 0, "error", "bad"}, true},
+		{"no column", Default, "Makefile:7: missing separator", Entry{"Makefile", 6, 0, "", "missing separator"}, true},
+		{"no column type", Default, "x.py:2: note: here", Entry{"x.py", 1, 0, "note", "here"}, true},
+		{"tsc", Default, "src/app.ts(4,10): error TS2304: Cannot find name", Entry{"src/app.ts", 3, 9, "error", "TS2304: Cannot find name"}, true},
+		{"windows path", Default, `C:\src\a.go:1:2: bad`, Entry{`C:\src\a.go`, 0, 1, "", "bad"}, true},
+		{"carriage return", Default, "a.go:1:1: bad\r", Entry{"a.go", 0, 0, "", "bad"}, true},
+		{"line zero", Default, "a.go:0: bad", Entry{"a.go", 0, 0, "", "bad"}, true},
+		{"not a location", Default, "ok  	example.com/pkg	0.01s", Entry{}, false},
+		{"no file", Default, ":1: bad", Entry{}, false},
+		{"blank", Default, "", Entry{}, false},
+		{"first pattern wins", "%f:%l:%c: %m,%f:%l: %m", "a.go:1:2: x", Entry{"a.go", 0, 1, "", "x"}, true},
+		{"file name takes what the rest leaves", "%f:%l: %m", "a.go:1:2: x", Entry{"a.go:1", 1, 0, "", "x"}, true},
+		{"literal percent", "%f:%l: 100%% %m", "a:1: 100% done", Entry{"a", 0, 0, "", "done"}, true},
+		{"literal regexp chars", "[%f] line %l", "[x.go] line 3", Entry{"x.go", 2, 0, "", ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Compile(tt.efm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := f.Match(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Match(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/Adelodunpeter25/vx/internal/utils"
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -26,12 +27,20 @@ type State struct {
 	Focused  bool
 	RootPath string
 	Root     *Node
+	Decorate func(node *Node) Decoration // marks nodes, e.g. with their git status
 
 	selected int
 	scroll   int
 	showHidden bool
 }
 
+// Decoration is how a node is marked in the tree
+type Decoration struct {
+	Code  rune        // letter shown at the right edge, 0 for none
+	Style tcell.Style // of the name
+	Hide  bool        // leaves the node out of the tree
+}
+
 type Action struct {
 	OpenPath    string
 	PreviewPath string
@@ -46,7 +55,7 @@ func New(root string) *State {
 	if root == "" {
 		root = "."
 	}
-	root = filepath.Clean(root)
+	root = utils.CanonicalPath(root)
 	state := &State{
 		Open:     true,
 		Width:    30,
@@ -69,7 +78,7 @@ func (s *State) SetRoot(root string) {
 			root = cwd
 		}
 	}
-	root = filepath.Clean(root)
+	root = utils.CanonicalPath(root)
 	s.RootPath = root
 	s.Root = &Node{
 		Name:  filepath.Base(root),
@@ -103,6 +112,9 @@ func (s *State) appendVisible(out *[]*Node, node *Node, depth int) {
 	if node == nil {
 		return
 	}
+	if s.Decorate != nil && s.Decorate(node).Hide {
+		return
+	}
 	*out = append(*out, node)
 	if node.IsDir && node.Expanded {
 		if !node.Loaded {
@@ -157,6 +169,48 @@ func (s *State) SetShowHidden(show bool) {
 	}
 }
 
+// LoadedPaths returns the paths of the nodes read from disk so far, those
+// whose changes the tree shows
+func (s *State) LoadedPaths() []string {
+	var paths []string
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		paths = append(paths, node.Path)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	if s.Root != nil {
+		walk(s.Root)
+	}
+	return paths
+}
+
+// Refresh reads the directories loaded so far again, for files created or
+// deleted since, keeping those that still exist as they were
+func (s *State) Refresh() {
+	var refresh func(node *Node)
+	refresh = func(node *Node) {
+		if !node.IsDir || !node.Loaded {
+			return
+		}
+		old := make(map[string]*Node, len(node.Children))
+		for _, child := range node.Children {
+			old[child.Name] = child
+		}
+		s.loadChildren(node)
+		for i, child := range node.Children {
+			if prev, ok := old[child.Name]; ok && prev.IsDir == child.IsDir {
+				node.Children[i] = prev
+				refresh(prev)
+			}
+		}
+	}
+	if s.Root != nil {
+		refresh(s.Root)
+	}
+}
+
 func (s *State) Render(term *terminal.Terminal, x, y, width, height int) {
 	if !s.Open || term == nil || width <= 0 || height <= 0 {
 		return
@@ -208,14 +262,27 @@ func (s *State) Render(term *terminal.Terminal, x, y, width, height int) {
 			label += "/"
 		}
 		style := tcell.StyleDefault
+		var code rune
+		if s.Decorate != nil {
+			d := s.Decorate(node)
+			style, code = d.Style, d.Code
+		}
 		if idx == s.selected {
 			style = style.Reverse(true)
 		}
 		if s.Focused && idx == s.selected {
 			style = style.Bold(true)
 		}
-		if len(label) > width {
-			label = label[:width]
+		labelWidth := width
+		if code != 0 && width > 2 {
+			// The code keeps the last two columns, after a space
+			labelWidth = width - 2
+		}
+		if len(label) > labelWidth {
+			label = label[:labelWidth]
+		}
+		if code != 0 && labelWidth < width {
+			label = padRight(label, labelWidth) + " " + string(code)
 		}
 		term.DrawText(x, y+row, padRight(label, width), style)
 	}
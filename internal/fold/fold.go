@@ -0,0 +1,112 @@
+package fold
+
+import "sort"
+
+// Range is a foldable block of lines. Start stays visible when the fold is
+// closed; the lines after it up to End are hidden.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Lines is the text folds are computed from
+type Lines interface {
+	LineCount() int
+	Line(n int) string
+}
+
+// Indent returns a fold for every line followed by more deeply indented
+// lines. Blank lines inside a block belong to it.
+func Indent(lines Lines, level func(line string) int) []Range {
+	type header struct{ line, level int }
+	var ranges []Range
+	var stack []header
+	last := -1 // last non-blank line
+	closeTo := func(lvl int) {
+		for len(stack) > 0 && stack[len(stack)-1].level >= lvl {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if last > top.line {
+				ranges = append(ranges, Range{Start: top.line, End: last})
+			}
+		}
+	}
+
+	for n := 0; n < lines.LineCount(); n++ {
+		line := lines.Line(n)
+		if isBlank(line) {
+			continue
+		}
+		lvl := level(line)
+		closeTo(lvl)
+		stack = append(stack, header{line: n, level: lvl})
+		last = n
+	}
+	closeTo(-1)
+	return sorted(ranges)
+}
+
+// Brackets returns a fold for every line that opens a bracket closed on a
+// later line. The fold ends above the closing line, so it stays visible.
+// Like the bracket matcher, brackets in strings and comments count.
+func Brackets(lines Lines) []Range {
+	type open struct {
+		r    rune
+		line int
+	}
+	ends := make(map[int]int)
+	var stack []open
+	for n := 0; n < lines.LineCount(); n++ {
+		for _, r := range lines.Line(n) {
+			switch r {
+			case '(', '[', '{':
+				stack = append(stack, open{r: r, line: n})
+			case ')', ']', '}':
+				i := len(stack) - 1
+				for i >= 0 && stack[i].r != opening(r) {
+					i--
+				}
+				if i < 0 {
+					continue
+				}
+				start := stack[i].line
+				stack = stack[:i]
+				if end := n - 1; end > start && end > ends[start] {
+					ends[start] = end
+				}
+			}
+		}
+	}
+
+	ranges := make([]Range, 0, len(ends))
+	for start, end := range ends {
+		ranges = append(ranges, Range{Start: start, End: end})
+	}
+	return sorted(ranges)
+}
+
+func opening(closing rune) rune {
+	switch closing {
+	case ')':
+		return '('
+	case ']':
+		return '['
+	}
+	return '{'
+}
+
+func isBlank(line string) bool {
+	for _, r := range line {
+		if r != ' ' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func sorted(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges
+}
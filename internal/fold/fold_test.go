@@ -0,0 +1,146 @@
+package fold
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type lines []string
+
+func (l lines) LineCount() int    { return len(l) }
+func (l lines) Line(n int) string { return l[n] }
+
+// spaces is the indent level of a line: its leading spaces
+func spaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines lines
+		want  []Range
+	}{
+		{"flat", lines{"a", "b", "c"}, nil},
+		{"one block", lines{"a", " b", " c", "d"}, []Range{{0, 2}}},
+		{"nested", lines{"a", " b", "  c", " d", "e"}, []Range{{0, 3}, {1, 2}}},
+		{"at end", lines{"a", " b", "  c"}, []Range{{0, 2}, {1, 2}}},
+		{"blank inside", lines{"a", " b", "", " c", "d"}, []Range{{0, 3}}},
+		{"blank after", lines{"a", " b", "", "", "c"}, []Range{{0, 1}}},
+		{"blank at end", lines{"a", " b", "  "}, []Range{{0, 1}}},
+		{"dedent below start", lines{"  a", "   b", "c", " d"}, []Range{{0, 1}, {2, 3}}},
+		{"siblings", lines{"a", " b", "c", " d"}, []Range{{0, 1}, {2, 3}}},
+		{"empty", lines{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Indent(tt.lines, spaces); !slices.Equal(got, tt.want) {
+				t.Errorf("Indent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrackets(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines lines
+		want  []Range
+	}{
+		{"one line", lines{"f(x) { y }"}, nil},
+		{"block", lines{"func f() {", "  x", "  y", "}"}, []Range{{0, 2}}},
+		{"close on next line", lines{"{", "}"}, nil},
+		{"nested", lines{"{", "  [", "    1,", "  ]", "}"}, []Range{{0, 3}, {1, 2}}},
+		{"reopened on one line", lines{"if a {", "  x", "} else {", "  y", "}"}, []Range{{0, 1}, {2, 3}}},
+		{"longest from a line", lines{"f({", "  x", "}, [", "  y", "])"}, []Range{{0, 3}, {2, 3}}},
+		{"mismatched closer skipped", lines{"{", "  )", "  x", "}"}, []Range{{0, 2}}},
+		{"unclosed", lines{"{", "  x"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Brackets(tt.lines); !slices.Equal(got, tt.want) {
+				t.Errorf("Brackets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	// 0 a
+	// 1  b      fold 1-2, inside fold 0-4
+	// 2   c
+	// 3  d
+	// 4  e
+	// 5 f
+	s := New()
+	s.SetRanges([]Range{{0, 4}, {1, 2}})
+
+	if r, ok := s.Close(2); !ok || r != (Range{1, 2}) {
+		t.Fatalf("Close(2) = %v, %v, want the inner fold", r, ok)
+	}
+	for n, want := range []bool{false, false, true, false, false, false} {
+		if got := s.Hidden(n); got != want {
+			t.Errorf("inner closed: Hidden(%d) = %v, want %v", n, got, want)
+		}
+	}
+	if got := s.Next(1); got != 3 {
+		t.Errorf("Next(1) = %d, want 3", got)
+	}
+	if got := s.Prev(3); got != 1 {
+		t.Errorf("Prev(3) = %d, want 1", got)
+	}
+
+	if r, ok := s.Close(1); !ok || r != (Range{0, 4}) {
+		t.Fatalf("Close(1) = %v, %v, want the outer fold", r, ok)
+	}
+	if r, ok := s.ClosedAt(0); !ok || r != (Range{0, 4}) {
+		t.Errorf("ClosedAt(0) = %v, %v, want the outer fold", r, ok)
+	}
+	if got := s.Next(0); got != 5 {
+		t.Errorf("Next(0) = %d, want 5", got)
+	}
+
+	// Revealing a line opens both folds hiding it
+	s.Reveal(2)
+	if s.Hidden(2) || s.HasClosed() {
+		t.Errorf("Reveal(2) left folds closed")
+	}
+}
+
+func TestSetApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		line, delta int
+		want        []Range
+		closed      []int // starts of the folds still closed
+	}{
+		{"insert above", 0, 2, []Range{{4, 6}, {9, 10}}, []int{4, 9}},
+		{"insert inside", 3, 1, []Range{{2, 5}, {8, 9}}, []int{8}},
+		{"insert below", 9, 3, []Range{{2, 4}, {7, 8}}, []int{2, 7}},
+		{"remove above", 0, -1, []Range{{1, 3}, {6, 7}}, []int{1, 6}},
+		{"remove inside", 2, -1, []Range{{2, 3}, {6, 7}}, []int{6}},
+		{"remove across", 1, -2, []Range{{1, 2}, {5, 6}}, []int{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.SetRanges([]Range{{2, 4}, {7, 8}})
+			s.Close(2)
+			s.Close(7)
+			s.Apply(tt.line, tt.delta)
+			if !slices.Equal(s.Ranges(), tt.want) {
+				t.Errorf("ranges = %v, want %v", s.Ranges(), tt.want)
+			}
+			var closed []int
+			for _, r := range s.Ranges() {
+				if _, ok := s.ClosedAt(r.Start); ok {
+					closed = append(closed, r.Start)
+				}
+			}
+			if !slices.Equal(closed, tt.closed) {
+				t.Errorf("closed = %v, want %v", closed, tt.closed)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package fold
+
+import "sort"
+
+// Set holds the folds of a buffer and which of them are closed
+type Set struct {
+	ranges []Range      // sorted by Start, at most one per line
+	closed map[int]bool // Start of each closed fold
+	hidden []Range      // closed folds not inside another closed fold
+}
+
+func New() *Set {
+	return &Set{closed: make(map[int]bool)}
+}
+
+// SetRanges replaces the folds. Closed folds stay closed if a fold still
+// starts on their line.
+func (s *Set) SetRanges(ranges []Range) {
+	s.ranges = ranges
+	closed := make(map[int]bool, len(s.closed))
+	for _, r := range ranges {
+		if s.closed[r.Start] {
+			closed[r.Start] = true
+		}
+	}
+	s.closed = closed
+	s.update()
+}
+
+// Ranges returns all folds, sorted by their first line
+func (s *Set) Ranges() []Range {
+	return s.ranges
+}
+
+// HasClosed reports whether any fold is closed
+func (s *Set) HasClosed() bool {
+	return len(s.closed) > 0
+}
+
+// Apply moves the folds for an edit to line that inserted delta lines after
+// it, or removed them when delta is negative. Closed folds the edit touches
+// are opened.
+func (s *Set) Apply(line, delta int) {
+	removed := max(-delta, 0)
+	move := func(n int) int {
+		switch {
+		case n <= line:
+			return n
+		case delta >= 0:
+			return n + delta
+		case n > line+removed:
+			return n - removed
+		}
+		return line
+	}
+
+	closed := make(map[int]bool, len(s.closed))
+	for i, r := range s.ranges {
+		if s.closed[r.Start] && !(r.Start <= line+removed && line <= r.End) {
+			closed[move(r.Start)] = true
+		}
+		s.ranges[i] = Range{Start: move(r.Start), End: move(r.End)}
+	}
+	s.closed = closed
+	s.update()
+}
+
+// Hidden reports whether a closed fold hides line n
+func (s *Set) Hidden(n int) bool {
+	_, ok := s.Hiding(n)
+	return ok
+}
+
+// Hiding returns the closed fold that hides line n
+func (s *Set) Hiding(n int) (Range, bool) {
+	i := sort.Search(len(s.hidden), func(i int) bool { return s.hidden[i].Start >= n })
+	if i > 0 && n <= s.hidden[i-1].End {
+		return s.hidden[i-1], true
+	}
+	return Range{}, false
+}
+
+// ClosedAt returns the closed fold shown on line n, if any
+func (s *Set) ClosedAt(n int) (Range, bool) {
+	i := sort.Search(len(s.hidden), func(i int) bool { return s.hidden[i].Start >= n })
+	if i < len(s.hidden) && s.hidden[i].Start == n {
+		return s.hidden[i], true
+	}
+	return Range{}, false
+}
+
+// Next returns the first visible line after line n
+func (s *Set) Next(n int) int {
+	if r, ok := s.ClosedAt(n); ok {
+		return r.End + 1
+	}
+	if r, ok := s.Hiding(n + 1); ok {
+		return r.End + 1
+	}
+	return n + 1
+}
+
+// Prev returns the first visible line before line n
+func (s *Set) Prev(n int) int {
+	if r, ok := s.Hiding(n - 1); ok {
+		return r.Start
+	}
+	return n - 1
+}
+
+// Open opens the closed fold shown on line n
+func (s *Set) Open(n int) bool {
+	if _, ok := s.ClosedAt(n); !ok {
+		return false
+	}
+	delete(s.closed, n)
+	s.update()
+	return true
+}
+
+// Close closes the innermost open fold containing line n and returns it
+func (s *Set) Close(n int) (Range, bool) {
+	best := -1
+	for i, r := range s.ranges {
+		if r.Start > n {
+			break
+		}
+		if n <= r.End && !s.closed[r.Start] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Range{}, false
+	}
+	r := s.ranges[best]
+	s.closed[r.Start] = true
+	s.update()
+	return r, true
+}
+
+// Toggle opens the closed fold shown on line n, or closes the innermost
+// fold containing it
+func (s *Set) Toggle(n int) bool {
+	if s.Open(n) {
+		return true
+	}
+	_, ok := s.Close(n)
+	return ok
+}
+
+// Reveal opens every closed fold hiding line n
+func (s *Set) Reveal(n int) {
+	if !s.Hidden(n) {
+		return
+	}
+	for _, r := range s.ranges {
+		if r.Start < n && n <= r.End {
+			delete(s.closed, r.Start)
+		}
+	}
+	s.update()
+}
+
+// OpenAll opens every fold
+func (s *Set) OpenAll() {
+	s.closed = make(map[int]bool)
+	s.update()
+}
+
+// CloseAll closes every fold
+func (s *Set) CloseAll() {
+	for _, r := range s.ranges {
+		s.closed[r.Start] = true
+	}
+	s.update()
+}
+
+// update rebuilds the list of outermost closed folds
+func (s *Set) update() {
+	s.hidden = s.hidden[:0]
+	for _, r := range s.ranges {
+		if !s.closed[r.Start] {
+			continue
+		}
+		if n := len(s.hidden); n > 0 && r.Start <= s.hidden[n-1].End {
+			continue
+		}
+		s.hidden = append(s.hidden, r)
+	}
+}
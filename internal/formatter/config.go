@@ -0,0 +1,50 @@
+// Package formatter pipes buffers through external formatters such as
+// gofmt, prettier or black.
+package formatter
+
+import "github.com/Adelodunpeter25/vx/internal/tool"
+
+// ConfigFile is the name of the formatter configuration in the config directory
+const ConfigFile = "formatters.json"
+
+// Formatter is a command that reads a file on stdin and writes it formatted
+// to stdout
+type Formatter struct {
+	tool.Tool
+}
+
+// Config maps filetypes to formatters
+type Config map[string]*Formatter
+
+// defaults are the formatters used when they are installed, unless the
+// config file says otherwise
+func defaults() tool.Config {
+	prettier := []string{"prettier", "--stdin-filepath", "${file}"}
+	clangFormat := []string{"clang-format", "--assume-filename=${file}"}
+	config := tool.Config{
+		"go":     {Command: []string{"gofmt"}},
+		"python": {Command: []string{"black", "--quiet", "-"}},
+		"rust":   {Command: []string{"rustfmt", "--edition", "2021", "--emit", "stdout"}},
+		"c":      {Command: clangFormat},
+		"c++":    {Command: clangFormat},
+	}
+	for _, filetype := range []string{"javascript", "typescript", "react", "json", "css", "scss", "html", "vue", "yaml", "md", "graphql"} {
+		config[filetype] = &tool.Tool{Command: prettier}
+	}
+	return config
+}
+
+// LoadConfig returns the installed built-in formatters overridden by the
+// config file, a JSON object of filetypes to formatters, e.g.
+//
+//	{"go": {"command": ["goimports"]}, "md": null}
+//
+// where null turns a built-in formatter off.
+func LoadConfig() (Config, error) {
+	tools, err := tool.LoadConfig(ConfigFile, defaults())
+	config := make(Config, len(tools))
+	for filetype, t := range tools {
+		config[filetype] = &Formatter{*t}
+	}
+	return config, err
+}
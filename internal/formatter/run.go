@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Timeout is how long a formatter may run
+const Timeout = 10 * time.Second
+
+// Run pipes text, the contents of filename, through the formatter and
+// returns what it wrote. When it fails, the error holds the first line of
+// its stderr.
+func (f *Formatter) Run(filename, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := f.Cmd(ctx, filename)
+	cmd.Stdin = strings.NewReader(text)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Name the file where the formatter only saw its stdin
+		msg := strings.ReplaceAll(stderr.String(), "<standard input>", filepath.Base(filename))
+		return "", f.Failure(ctx, Timeout, msg, err)
+	}
+	if stdout.Len() == 0 && strings.TrimSpace(text) != "" {
+		return "", fmt.Errorf("%s: no output", f.Name())
+	}
+	return stdout.String(), nil
+}
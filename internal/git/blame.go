@@ -0,0 +1,79 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is the commit that last changed a line
+type BlameLine struct {
+	Hash    string // all zeros for lines not committed yet
+	Author  string
+	Time    time.Time
+	Summary string
+}
+
+// Committed reports whether the line is in a commit, rather than only in
+// the work tree or the buffer
+func (l BlameLine) Committed() bool {
+	return strings.Trim(l.Hash, "0") != ""
+}
+
+// Blame returns the commit of each line of contents, the file as it is in
+// the editor, which may differ from the file on disk
+func (f File) Blame(contents string) ([]BlameLine, error) {
+	out, err := run(f.Root, contents, "blame", "--porcelain", "--contents", "-", "--", f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame reads the output of git blame --porcelain. Each line of the
+// file follows a header naming its commit; the details of a commit are
+// only given the first time it's named.
+func parseBlame(out string) []BlameLine {
+	commits := make(map[string]*BlameLine)
+	var lines []BlameLine
+	var cur *BlameLine
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if cur != nil {
+				lines = append(lines, *cur)
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if len(key) == 40 && isHex(key) {
+			if cur = commits[key]; cur == nil {
+				cur = &BlameLine{Hash: key}
+				commits[key] = cur
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			cur.Author = value
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				cur.Time = time.Unix(sec, 0)
+			}
+		case "summary":
+			cur.Summary = value
+		}
+	}
+	return lines
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
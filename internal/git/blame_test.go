@@ -0,0 +1,64 @@
+package git
+
+import "testing"
+
+func TestBlame(t *testing.T) {
+	r := newRepo(t)
+	r.write("f.txt", "one", "two", "three")
+	r.commit("first")
+	r.write("f.txt", "one", "TWO", "three")
+	r.git("add", "f.txt")
+	r.git("-c", "user.name=Bob", "commit", "-q", "-m", "second")
+	f := r.find("f.txt")
+
+	// The buffer has a line the file on disk doesn't
+	lines, err := f.Blame("one\nTWO\nnew\nthree\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		author, summary string
+		committed       bool
+	}{
+		{"Ada", "first", true},
+		{"Bob", "second", true},
+		{"", "", false},
+		{"Ada", "first", true},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Committed() != w.committed {
+			t.Errorf("line %d committed = %v, want %v", i, l.Committed(), w.committed)
+		}
+		if w.committed && (l.Author != w.author || l.Summary != w.summary || l.Time.IsZero()) {
+			t.Errorf("line %d = %+v, want author %s and summary %s", i, l, w.author, w.summary)
+		}
+	}
+	if lines[0].Hash != lines[3].Hash || lines[0].Hash == lines[1].Hash {
+		t.Errorf("hashes %s %s %s, want the first and last the same", lines[0].Hash, lines[1].Hash, lines[3].Hash)
+	}
+}
+
+func TestParseBlame(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	out := hash + " 1 1 2\n" +
+		"author Ada\n" +
+		"author-time 1700000000\n" +
+		"summary first line\n" +
+		"filename f.txt\n" +
+		"\tone\n" +
+		hash + " 2 2\n" +
+		"\ttwo\n"
+	lines := parseBlame(out)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for i, l := range lines {
+		if l.Hash != hash || l.Author != "Ada" || l.Summary != "first line" || l.Time.Unix() != 1700000000 {
+			t.Errorf("line %d = %+v", i, l)
+		}
+	}
+}
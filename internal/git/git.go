@@ -0,0 +1,100 @@
+// Package git reads what the editor shows about files in git repositories,
+// such as their staged version, with the git command.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// Timeout is how long a git command may run
+const Timeout = 10 * time.Second
+
+// ErrNotTracked is returned for files outside a repository or not tracked
+// in one
+var ErrNotTracked = errors.New("not tracked by git")
+
+// File is a file tracked in a repository
+type File struct {
+	Root string // top directory of the work tree
+	Path string // slash-separated, relative to Root
+}
+
+// run runs git in dir with input on stdin and returns what it wrote. When
+// it fails, the error holds the first line of its stderr.
+func run(dir, input string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("git %s: timed out after %v", args[0], Timeout)
+		}
+		if msg := utils.FirstLine(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// Find returns the repository file at path, or ErrNotTracked
+func Find(path string) (File, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return File{}, ErrNotTracked
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return File{}, err
+	}
+	dir, name := filepath.Split(abs)
+	root, err := run(dir, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return File{}, ErrNotTracked
+	}
+	out, err := run(dir, "", "ls-files", "--full-name", "--", name)
+	if err != nil {
+		return File{}, err
+	}
+	rel := utils.FirstLine(out)
+	if rel == "" {
+		return File{}, ErrNotTracked
+	}
+	return File{Root: strings.TrimSpace(root), Path: rel}, nil
+}
+
+// Index returns the lines of the file as staged in the index, without the
+// newline ending the last one, or ErrNotTracked when the index doesn't have
+// the file
+func (f File) Index() ([]string, error) {
+	out, err := run(f.Root, "", "show", ":"+f.Path)
+	if err != nil {
+		return nil, ErrNotTracked
+	}
+	return splitLines(out), nil
+}
+
+// ApplyCached stages a patch of the file, leaving the work tree as it is
+func (f File) ApplyCached(patch string) error {
+	_, err := run(f.Root, patch, "apply", "--cached", "--unidiff-zero", "-")
+	return err
+}
+
+// splitLines splits file contents into lines the way buffers hold them
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
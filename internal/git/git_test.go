@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a repository in a temporary directory
+type testRepo struct {
+	t    *testing.T
+	root string
+}
+
+// newRepo creates an empty repository, skipping the test without git
+func newRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRepo{t: t, root: root}
+	r.git("init", "-q")
+	r.git("config", "user.name", "Ada")
+	r.git("config", "user.email", "ada@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	return r
+}
+
+// git runs a git command in the repository and returns its output
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	out, err := run(r.root, "", args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return out
+}
+
+// write writes lines to a file of the work tree
+func (r *testRepo) write(name string, lines ...string) {
+	r.t.Helper()
+	path := filepath.Join(r.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit stages everything and commits it
+func (r *testRepo) commit(message string) {
+	r.t.Helper()
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", message)
+}
+
+// find returns the repository file name
+func (r *testRepo) find(name string) File {
+	r.t.Helper()
+	f, err := Find(filepath.Join(r.root, filepath.FromSlash(name)))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return f
+}
+
+func TestFindUntracked(t *testing.T) {
+	r := newRepo(t)
+	r.write("new.txt", "a")
+	if _, err := Find(filepath.Join(r.root, "new.txt")); err != ErrNotTracked {
+		t.Fatalf("Find(untracked) = %v, want ErrNotTracked", err)
+	}
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/diff"
+)
+
+// Kind is what a hunk did to the file
+type Kind int
+
+const (
+	Added Kind = iota
+	Changed
+	Removed
+)
+
+// Hunk is a run of lines that differs from the index
+type Hunk struct {
+	OldStart int      // first line replaced in the index, 0-based
+	NewStart int      // first line in the buffer, 0-based
+	Old      []string // lines in the index
+	New      []string // lines in the buffer
+}
+
+// Hunks returns where lines differ from the staged ones, in order
+func Hunks(staged, lines []string) []Hunk {
+	edits := diff.Lines(staged, lines)
+	hunks := make([]Hunk, len(edits))
+	shift := 0
+	for i, ed := range edits {
+		hunks[i] = Hunk{
+			OldStart: ed.Start,
+			NewStart: ed.Start + shift,
+			Old:      staged[ed.Start:ed.End],
+			New:      ed.Lines,
+		}
+		shift += len(ed.Lines) - (ed.End - ed.Start)
+	}
+	return hunks
+}
+
+// Kind returns whether the hunk added, changed or removed lines
+func (h Hunk) Kind() Kind {
+	switch {
+	case len(h.Old) == 0:
+		return Added
+	case len(h.New) == 0:
+		return Removed
+	}
+	return Changed
+}
+
+// SignLine returns the buffer line a hunk is marked on: its first line, or
+// for removed lines the one above them
+func (h Hunk) SignLine() int {
+	if len(h.New) == 0 {
+		return max(h.NewStart-1, 0)
+	}
+	return h.NewStart
+}
+
+// Contains reports whether a buffer line belongs to the hunk
+func (h Hunk) Contains(line int) bool {
+	if len(h.New) == 0 {
+		return line == h.SignLine()
+	}
+	return line >= h.NewStart && line < h.NewStart+len(h.New)
+}
+
+// Header returns the @@ line of the hunk in a unified diff
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", rangeSpec(h.OldStart, len(h.Old)), rangeSpec(h.NewStart, len(h.New)))
+}
+
+// rangeSpec formats a range for a hunk header: its 1-based first line, or
+// the line before an empty range
+func rangeSpec(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Diff returns the hunk as lines of a unified diff, header first
+func (h Hunk) Diff() []string {
+	lines := []string{h.Header()}
+	for _, line := range h.Old {
+		lines = append(lines, "-"+line)
+	}
+	for _, line := range h.New {
+		lines = append(lines, "+"+line)
+	}
+	return lines
+}
+
+// Patch returns a patch that makes the hunk's change to the file in the
+// index, for File.ApplyCached. The hunk must have been worked out against
+// the index as it is, since its lines are where the patch applies.
+func (h Hunk) Patch(path string) string {
+	// Applied on its own, the hunk's new lines start where the old ones did
+	staged := h
+	staged.NewStart = h.OldStart
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	for _, line := range staged.Diff() {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []Hunk
+		kinds []Kind
+	}{
+		{"same", []string{"a", "b", "c"}, []Hunk{}, nil},
+		{"added", []string{"a", "x", "b", "c"}, []Hunk{{OldStart: 1, NewStart: 1, Old: []string{}, New: []string{"x"}}}, []Kind{Added}},
+		{"changed", []string{"a", "x", "c"}, []Hunk{{OldStart: 1, NewStart: 1, Old: []string{"b"}, New: []string{"x"}}}, []Kind{Changed}},
+		{"removed", []string{"a", "c"}, []Hunk{{OldStart: 1, NewStart: 1, Old: []string{"b"}, New: nil}}, []Kind{Removed}},
+		{"shifted", []string{"x", "a", "b", "y"}, []Hunk{
+			{OldStart: 0, NewStart: 0, Old: []string{}, New: []string{"x"}},
+			{OldStart: 2, NewStart: 3, Old: []string{"c"}, New: []string{"y"}},
+		}, []Kind{Added, Changed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hunks([]string{"a", "b", "c"}, tt.lines)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d hunks %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, h := range got {
+				w := tt.want[i]
+				if h.OldStart != w.OldStart || h.NewStart != w.NewStart ||
+					!slices.Equal(h.Old, w.Old) || !slices.Equal(h.New, w.New) {
+					t.Errorf("hunk %d = %+v, want %+v", i, h, w)
+				}
+				if h.Kind() != tt.kinds[i] {
+					t.Errorf("hunk %d kind = %v, want %v", i, h.Kind(), tt.kinds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyCachedHunkByHunk(t *testing.T) {
+	r := newRepo(t)
+	r.write("f.txt", "a", "b", "c", "d")
+	r.commit("init")
+	f := r.find("f.txt")
+
+	lines := []string{"a", "X", "b", "c", "Y", "d"}
+	// Each hunk is staged against the index the one before it left
+	for _, want := range [][]string{
+		{"a", "X", "b", "c", "d"},
+		{"a", "X", "b", "c", "Y", "d"},
+	} {
+		index, err := f.Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hunks := Hunks(index, lines)
+		if len(hunks) == 0 {
+			t.Fatal("no hunks left to stage")
+		}
+		if err := f.ApplyCached(hunks[0].Patch(f.Path)); err != nil {
+			t.Fatal(err)
+		}
+		if index, _ = f.Index(); !slices.Equal(index, want) {
+			t.Fatalf("index = %q, want %q", index, want)
+		}
+	}
+	if index, _ := f.Index(); len(Hunks(index, lines)) != 0 {
+		t.Errorf("hunks left after staging all of them")
+	}
+}
+
+func TestApplyCachedRemoved(t *testing.T) {
+	r := newRepo(t)
+	r.write("f.txt", "a", "b", "c")
+	r.commit("init")
+	f := r.find("f.txt")
+
+	for _, lines := range [][]string{{"b", "c"}, {"a", "b"}} {
+		r.git("reset", "-q")
+		index, _ := f.Index()
+		hunks := Hunks(index, lines)
+		if err := f.ApplyCached(hunks[0].Patch(f.Path)); err != nil {
+			t.Fatal(err)
+		}
+		if index, _ = f.Index(); !slices.Equal(index, lines) {
+			t.Errorf("index = %q, want %q", index, lines)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// Commit is a commit that changed a file
+type Commit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+	Path    string // the file's path in the commit, which renames change
+}
+
+// Short returns the abbreviated hash of the commit
+func (c Commit) Short() string {
+	return c.Hash[:min(len(c.Hash), 8)]
+}
+
+// Log returns the commits that changed the file, newest first, following
+// it across renames
+func (f File) Log() ([]Commit, error) {
+	out, err := run(f.Root, "", "log", "--follow", "--name-only",
+		"--format=%x1e%H%x00%an%x00%at%x00%s", "--", f.Path)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		header, names, _ := strings.Cut(record, "\n")
+		fields := strings.SplitN(header, "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Author: fields[1], Subject: fields[3], Path: f.Path}
+		if sec, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			c.Time = time.Unix(sec, 0)
+		}
+		if name := utils.FirstLine(names); name != "" {
+			c.Path = name
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// At returns the lines of the file as a commit left it
+func (f File) At(c Commit) ([]string, error) {
+	out, err := run(f.Root, "", "show", c.Hash+":"+c.Path)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
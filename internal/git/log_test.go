@@ -0,0 +1,53 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLogFollowsRenames(t *testing.T) {
+	r := newRepo(t)
+	r.write("old.txt", "one", "two", "three", "four", "five")
+	r.commit("add")
+	r.write("old.txt", "one", "two", "three", "four", "five", "six")
+	r.commit("grow")
+	r.git("mv", "old.txt", "new.txt")
+	r.commit("rename")
+	r.write("other.txt", "x")
+	r.commit("unrelated")
+
+	f := r.find("new.txt")
+	commits, err := f.Log()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ subject, path string }{
+		{"rename", "new.txt"},
+		{"grow", "old.txt"},
+		{"add", "old.txt"},
+	}
+	if len(commits) != len(want) {
+		t.Fatalf("got %d commits %+v, want %d", len(commits), commits, len(want))
+	}
+	for i, w := range want {
+		c := commits[i]
+		if c.Subject != w.subject || c.Path != w.path || c.Author != "Ada" || c.Time.IsZero() {
+			t.Errorf("commit %d = %+v, want %s at %s", i, c, w.subject, w.path)
+		}
+		if len(c.Short()) != 8 {
+			t.Errorf("Short() = %q", c.Short())
+		}
+	}
+
+	// Older commits are read under the path they had then
+	lines, err := f.At(commits[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"one", "two", "three", "four", "five"}; !slices.Equal(lines, want) {
+		t.Errorf("At(add) = %q, want %q", lines, want)
+	}
+	if lines, _ = f.At(commits[0]); len(lines) != 6 {
+		t.Errorf("At(rename) has %d lines, want 6", len(lines))
+	}
+}
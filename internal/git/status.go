@@ -0,0 +1,140 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// Status is the state of a file in the work tree, in increasing order of
+// how much it stands out
+type Status int
+
+const (
+	StatusClean Status = iota
+	StatusIgnored
+	StatusUntracked
+	StatusAdded
+	StatusModified
+	StatusConflicted
+)
+
+// Code returns the letter git status uses for a state, or 0 for a clean file
+func (s Status) Code() rune {
+	switch s {
+	case StatusIgnored:
+		return '!'
+	case StatusUntracked:
+		return '?'
+	case StatusAdded:
+		return 'A'
+	case StatusModified:
+		return 'M'
+	case StatusConflicted:
+		return 'U'
+	}
+	return 0
+}
+
+// StatusList is the state of the files of a repository that aren't clean
+type StatusList struct {
+	Root   string // top directory of the work tree
+	GitDir string // the repository's .git directory
+	files  map[string]Status
+	dirs   map[string]Status // whole directories, untracked or ignored
+	within map[string]Status // directories, by the files in them
+}
+
+// LoadStatus runs git status on the repository dir is in. Paths are
+// absolute with symlinks resolved, as utils.CanonicalPath makes them.
+func LoadStatus(dir string) (*StatusList, error) {
+	out, err := run(utils.CanonicalPath(dir), "", "rev-parse", "--show-toplevel", "--absolute-git-dir")
+	if err != nil {
+		return nil, ErrNotTracked
+	}
+	// One path per line, since they may contain spaces
+	paths := strings.Split(strings.TrimSpace(out), "\n")
+	if len(paths) != 2 {
+		return nil, ErrNotTracked
+	}
+	root := utils.CanonicalPath(strings.TrimSpace(paths[0]))
+	out, err = run(root, "", "status", "--porcelain", "-z", "--ignored", "--untracked-files=normal")
+	if err != nil {
+		return nil, err
+	}
+	l := parseStatus(root, out)
+	l.GitDir = strings.TrimSpace(paths[1])
+	return l, nil
+}
+
+// parseStatus reads the output of git status --porcelain -z: an "XY path"
+// entry per file, with the old path of a rename after it, and directories
+// that are untracked or ignored as a whole ending in a slash
+func parseStatus(root, out string) *StatusList {
+	l := &StatusList{
+		Root:   root,
+		files:  make(map[string]Status),
+		dirs:   make(map[string]Status),
+		within: make(map[string]Status),
+	}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		xy, name := entry[:2], entry[3:]
+		if xy[0] == 'R' || xy[0] == 'C' {
+			i++ // the old path
+		}
+		status := entryStatus(xy)
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			l.dirs[path] = status
+		} else {
+			l.files[path] = status
+		}
+		if status == StatusIgnored {
+			continue
+		}
+		for dir := filepath.Dir(path); len(dir) > len(root); dir = filepath.Dir(dir) {
+			l.within[dir] = max(l.within[dir], status)
+		}
+	}
+	return l
+}
+
+// entryStatus reads the two letter state of a porcelain entry: X for the
+// index and Y for the work tree
+func entryStatus(xy string) Status {
+	switch xy {
+	case "??":
+		return StatusUntracked
+	case "!!":
+		return StatusIgnored
+	case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+		return StatusConflicted
+	}
+	if xy[0] == 'A' && xy[1] != 'D' {
+		return StatusAdded
+	}
+	return StatusModified
+}
+
+// Of returns the state of a file or directory in the work tree. That of a
+// directory is the most notable state of the files in it.
+func (l *StatusList) Of(path string, isDir bool) Status {
+	if status, ok := l.files[path]; ok {
+		return status
+	}
+	for dir := path; len(dir) > len(l.Root); dir = filepath.Dir(dir) {
+		if status, ok := l.dirs[dir]; ok {
+			return status
+		}
+	}
+	if isDir {
+		return l.within[path]
+	}
+	return StatusClean
+}
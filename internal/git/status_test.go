@@ -0,0 +1,106 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadStatus(t *testing.T) {
+	r := newRepo(t)
+	r.write(".gitignore", "build/", "*.log")
+	r.write("clean.txt", "a")
+	r.write("src/edited.go", "a")
+	r.write("src/deep/kept.go", "a")
+	r.commit("init")
+
+	r.write("src/edited.go", "b")
+	r.write("added.txt", "a")
+	r.git("add", "added.txt")
+	r.write("notes/todo.txt", "a")
+	r.write("build/out.bin", "a")
+	r.write("debug.log", "a")
+
+	// Paths given through a symlink come out the same
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(r.root, link); err != nil {
+		t.Fatal(err)
+	}
+	l, err := LoadStatus(filepath.Join(link, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Root != r.root {
+		t.Errorf("Root = %s, want %s", l.Root, r.root)
+	}
+	if l.GitDir != filepath.Join(r.root, ".git") {
+		t.Errorf("GitDir = %s", l.GitDir)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  Status
+	}{
+		{"clean.txt", false, StatusClean},
+		{"src/edited.go", false, StatusModified},
+		{"src", true, StatusModified},
+		{"src/deep", true, StatusClean},
+		{"src/deep/kept.go", false, StatusClean},
+		{"added.txt", false, StatusAdded},
+		{"notes", true, StatusUntracked},
+		{"notes/todo.txt", false, StatusUntracked},
+		{"build", true, StatusIgnored},
+		{"build/out.bin", false, StatusIgnored},
+		{"debug.log", false, StatusIgnored},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(r.root, filepath.FromSlash(tt.path))
+		if got := l.Of(path, tt.isDir); got != tt.want {
+			t.Errorf("Of(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadStatusPathWithSpace(t *testing.T) {
+	r := newRepo(t)
+	r.root = filepath.Join(r.root, "my project")
+	if err := os.Mkdir(r.root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r.git("init", "-q")
+	r.write("new.txt", "a")
+
+	l, err := LoadStatus(r.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Root != r.root || l.GitDir != filepath.Join(r.root, ".git") {
+		t.Errorf("Root, GitDir = %s, %s", l.Root, l.GitDir)
+	}
+	if got := l.Of(filepath.Join(r.root, "new.txt"), false); got != StatusUntracked {
+		t.Errorf("Of(new.txt) = %v, want untracked", got)
+	}
+}
+
+func TestLoadStatusOutsideRepository(t *testing.T) {
+	if _, err := LoadStatus(t.TempDir()); err != ErrNotTracked {
+		t.Errorf("LoadStatus = %v, want ErrNotTracked", err)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	codes := map[Status]rune{
+		StatusClean:      0,
+		StatusIgnored:    '!',
+		StatusUntracked:  '?',
+		StatusAdded:      'A',
+		StatusModified:   'M',
+		StatusConflicted: 'U',
+	}
+	for status, want := range codes {
+		if got := status.Code(); got != want {
+			t.Errorf("%v.Code() = %q, want %q", status, got, want)
+		}
+	}
+}
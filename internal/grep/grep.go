@@ -0,0 +1,168 @@
+package grep
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Adelodunpeter25/vx/internal/search"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// maxLineBytes is the longest line a file can have before it is skipped
+const maxLineBytes = 1024 * 1024
+
+// Hit is a single match in a file
+type Hit struct {
+	Path string
+	Line int // 0-based
+	Col  int // rune column
+	Len  int
+	Text string
+}
+
+// Options controls which files a search visits
+type Options struct {
+	Hidden bool   // search dot files and directories
+	Notify func() // called from worker goroutines after results are sent
+
+	// Buffers holds the contents of open files by absolute path. They are
+	// searched instead of the file on disk so unsaved edits are included.
+	Buffers map[string]search.Source
+}
+
+// Search walks root in parallel and sends the hits of each file to out,
+// skipping binary files and anything excluded by .gitignore. out is closed
+// when the search finishes or ctx is cancelled.
+func Search(ctx context.Context, root string, re *regexp.Regexp, opts Options, out chan<- []Hit) {
+	defer close(out)
+
+	paths := make(chan string, 256)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				var hits []Hit
+				if src, ok := opts.Buffers[path]; ok {
+					hits = searchSource(ctx, path, src, re)
+				} else {
+					hits = searchFile(ctx, path, re)
+				}
+				if len(hits) == 0 {
+					continue
+				}
+				select {
+				case out <- hits:
+				case <-ctx.Done():
+					return
+				}
+				if opts.Notify != nil {
+					opts.Notify()
+				}
+			}
+		}()
+	}
+
+	rules := loadIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), root)
+	walk(ctx, root, rules, opts, paths)
+	close(paths)
+	wg.Wait()
+}
+
+// Files sends every file below root that a search would visit to paths,
+// and closes it when done
+func Files(ctx context.Context, root string, hidden bool, paths chan<- string) {
+	defer close(paths)
+	rules := loadIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), root)
+	walk(ctx, root, rules, Options{Hidden: hidden}, paths)
+}
+
+// walk sends every file below dir that is not ignored to paths
+func walk(ctx context.Context, dir string, rules []rule, opts Options, paths chan<- string) {
+	if ctx.Err() != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	if local := loadIgnoreFile(filepath.Join(dir, ".gitignore"), dir); len(local) > 0 {
+		rules = append(rules[:len(rules):len(rules)], local...)
+	}
+
+	for _, ent := range entries {
+		name := ent.Name()
+		if name == ".git" || (!opts.Hidden && strings.HasPrefix(name, ".")) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if ignored(rules, path, ent.IsDir()) {
+			continue
+		}
+		if ent.IsDir() {
+			walk(ctx, path, rules, opts, paths)
+			continue
+		}
+		if !ent.Type().IsRegular() {
+			continue
+		}
+		select {
+		case paths <- path:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// searchFile returns the hits in a single text file
+func searchFile(ctx context.Context, path string, re *regexp.Regexp) []Hit {
+	if binary, err := utils.IsBinaryFile(path); err != nil || binary {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var hits []Hit
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for lineNum := 0; scanner.Scan(); lineNum++ {
+		if lineNum%1000 == 0 && ctx.Err() != nil {
+			return nil
+		}
+		line := scanner.Text()
+		for _, m := range search.FindInLine(re, line, lineNum) {
+			hits = append(hits, Hit{Path: path, Line: m.Line, Col: m.Col, Len: m.Len, Text: line})
+		}
+	}
+	return hits
+}
+
+// searchSource returns the hits in an open buffer
+func searchSource(ctx context.Context, path string, src search.Source, re *regexp.Regexp) []Hit {
+	var hits []Hit
+	scan := func(lineNum int, line string) bool {
+		for _, m := range search.FindInLine(re, line, lineNum) {
+			hits = append(hits, Hit{Path: path, Line: m.Line, Col: m.Col, Len: m.Len, Text: line})
+		}
+		return ctx.Err() == nil
+	}
+	for i := 0; i < src.LineCount(); i++ {
+		if !scan(i, src.Line(i)) {
+			return nil
+		}
+	}
+	if err := src.ReadRest(ctx, scan); err != nil {
+		return nil
+	}
+	return hits
+}
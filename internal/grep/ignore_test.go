@@ -0,0 +1,103 @@
+package grep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name at root", []string{"*.log"}, "a.log", false, true},
+		{"name in subdir", []string{"*.log"}, "x/y/a.log", false, true},
+		{"other extension", []string{"*.log"}, "a.txt", false, false},
+		{"star stops at slash", []string{"a*c"}, "ab/c", false, false},
+		{"question mark", []string{"?.o"}, "x.o", false, true},
+		{"question mark length", []string{"?.o"}, "xy.o", false, false},
+		{"class", []string{"[ab].go"}, "b.go", false, true},
+		{"negated class", []string{"[!ab].go"}, "b.go", false, false},
+		{"unclosed class", []string{"[ab"}, "[ab", false, true},
+		{"anchored", []string{"/build"}, "build", true, true},
+		{"anchored not nested", []string{"/build"}, "src/build", true, false},
+		{"slash anchors", []string{"doc/gen"}, "doc/gen", true, true},
+		{"slash anchors not nested", []string{"doc/gen"}, "x/doc/gen", true, false},
+		{"dir only on dir", []string{"out/"}, "out", true, true},
+		{"dir only on file", []string{"out/"}, "out", false, false},
+		{"dir only nested", []string{"out/"}, "a/out", true, true},
+		{"double star prefix", []string{"**/tmp"}, "a/b/tmp", true, true},
+		{"double star prefix at root", []string{"**/tmp"}, "tmp", true, true},
+		{"double star suffix", []string{"logs/**"}, "logs/a/b.txt", false, true},
+		{"double star middle", []string{"a/**/z"}, "a/b/c/z", false, true},
+		{"double star middle none", []string{"a/**/z"}, "a/z", false, true},
+		{"negate", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"negate then ignore", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"escaped bang", []string{`\!x`}, "!x", false, true},
+		{"escaped hash", []string{`\#x`}, "#x", false, true},
+		{"comment", []string{"# a.log"}, "# a.log", false, false},
+		{"blank and trailing space", []string{"", "a.log  "}, "a.log", false, true},
+		{"outside base", []string{"*"}, "../other", false, false},
+		{"dotted name", []string{"*"}, "..name", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []rule
+			for _, p := range tt.patterns {
+				if r, ok := parseRule(p, base); ok {
+					rules = append(rules, r)
+				}
+			}
+			path := filepath.Join(base, filepath.FromSlash(tt.path))
+			if got := ignored(rules, path, tt.isDir); got != tt.want {
+				t.Errorf("ignored(%q, %q) = %v, want %v", tt.patterns, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchNestedIgnore(t *testing.T) {
+	root := t.TempDir()
+	for name, contents := range map[string]string{
+		".gitignore":        "*.log\n/build/\n",
+		"a.go":              "x\n",
+		"a.log":             "x\n",
+		"build/out.go":      "x\n",
+		"sub/build/b.go":    "x\n",
+		"sub/.gitignore":    "!keep.log\ngen/\n",
+		"sub/keep.log":      "x\n",
+		"sub/drop.log":      "x\n",
+		"sub/gen/c.go":      "x\n",
+		".hidden/d.go":      "x\n",
+		".git/info/exclude": "a.go\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := make(chan []Hit)
+	go Search(context.Background(), root, regexp.MustCompile("x"), Options{}, out)
+	var got []string
+	for hits := range out {
+		rel, _ := filepath.Rel(root, hits[0].Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	slices.Sort(got)
+	want := []string{"sub/build/b.go", "sub/keep.log"}
+	if !slices.Equal(got, want) {
+		t.Errorf("searched %q, want %q", got, want)
+	}
+}
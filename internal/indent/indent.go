@@ -0,0 +1,146 @@
+// Package indent decides how new lines are indented from the filetype's
+// rules: which lines open a block and which end one.
+package indent
+
+import "strings"
+
+// Rules are the indentation rules of a filetype
+type Rules struct {
+	Brackets bool     // a line ending in an open bracket indents the next one
+	Colon    bool     // a line ending in ':' indents the next one, as in Python
+	Enders   []string // statements after which the block ends, dedenting the next line
+	Spaces   int      // columns per level of a filetype indented with spaces only
+}
+
+// prose filetypes only keep the indentation of the line above
+var prose = map[string]bool{
+	"":                 true,
+	"text":             true,
+	"md":               true,
+	"markdown":         true,
+	"restructuredtext": true,
+	"org":              true,
+}
+
+var byFiletype = map[string]Rules{
+	"python": {Brackets: true, Colon: true, Enders: []string{"return", "pass", "break", "continue", "raise"}, Spaces: 4},
+	"yaml":   {Brackets: true, Colon: true, Spaces: 2},
+	"nim":    {Brackets: true, Colon: true, Spaces: 2},
+}
+
+// For returns the rules of a filetype. Filetypes without rules of their own
+// indent after brackets, like most programming languages.
+func For(filetype string) Rules {
+	if rules, ok := byFiletype[filetype]; ok {
+		return rules
+	}
+	if prose[filetype] {
+		return Rules{}
+	}
+	return Rules{Brackets: true}
+}
+
+// Opens reports whether the line after line is indented one more level
+func (r Rules) Opens(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if line == "" {
+		return false
+	}
+	switch line[len(line)-1] {
+	case '{', '(', '[':
+		return r.Brackets
+	case ':':
+		return r.Colon
+	}
+	return false
+}
+
+// Ends reports whether line ends its block, so the line after it is
+// indented one level less
+func (r Rules) Ends(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, word := range r.Enders {
+		if fields[0] == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Closer returns the bracket closing open, or 0 if open is not an opening
+// bracket
+func Closer(open rune) rune {
+	switch open {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	}
+	return 0
+}
+
+// Opener returns the bracket opened by close, or 0 if close is not a
+// closing bracket
+func Opener(close rune) rune {
+	switch close {
+	case ')':
+		return '('
+	case ']':
+		return '['
+	case '}':
+		return '{'
+	}
+	return 0
+}
+
+// Leading returns the whitespace a line starts with
+func Leading(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// Unit returns one level of indentation: a tab, or shiftwidth spaces when
+// expandtab is set
+func Unit(expandtab bool, shiftwidth int) string {
+	if !expandtab {
+		return "\t"
+	}
+	return strings.Repeat(" ", max(shiftwidth, 1))
+}
+
+// Columns returns how wide s is, with tabs advancing to the next multiple
+// of tabstop
+func Columns(s string, tabstop int) int {
+	tabstop = max(tabstop, 1)
+	cols := 0
+	for _, r := range s {
+		if r == '\t' {
+			cols += tabstop - cols%tabstop
+		} else {
+			cols++
+		}
+	}
+	return cols
+}
+
+// Outdent removes one level from the whitespace ws: a trailing tab, or the
+// spaces back to the previous multiple of shiftwidth
+func Outdent(ws string, tabstop, shiftwidth int) string {
+	if strings.HasSuffix(ws, "\t") {
+		return ws[:len(ws)-1]
+	}
+	shiftwidth = max(shiftwidth, 1)
+	n := Columns(ws, tabstop) % shiftwidth
+	if n == 0 {
+		n = shiftwidth
+	}
+	for n > 0 && strings.HasSuffix(ws, " ") {
+		ws = ws[:len(ws)-1]
+		n--
+	}
+	return ws
+}
@@ -0,0 +1,83 @@
+package indent
+
+import "testing"
+
+func TestOutdent(t *testing.T) {
+	tests := []struct {
+		ws                  string
+		tabstop, shiftwidth int
+		want                string
+	}{
+		{"", 8, 4, ""},
+		{"\t", 8, 4, ""},
+		{"\t\t", 8, 4, "\t"},
+		{"    \t", 8, 4, "    "},
+		{"    ", 8, 4, ""},
+		{"        ", 8, 4, "    "},
+		{"      ", 8, 4, "    "},
+		{"  ", 8, 4, ""},
+		{"   ", 8, 2, "  "},
+		{"\t  ", 8, 4, "\t"},
+		{"\t      ", 4, 4, "\t    "},
+		{"\t    ", 8, 4, "\t"},
+		{"   ", 8, 0, "  "},
+	}
+	for _, tt := range tests {
+		if got := Outdent(tt.ws, tt.tabstop, tt.shiftwidth); got != tt.want {
+			t.Errorf("Outdent(%q, %d, %d) = %q, want %q", tt.ws, tt.tabstop, tt.shiftwidth, got, tt.want)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		s       string
+		tabstop int
+		want    int
+	}{
+		{"", 4, 0},
+		{"  ", 4, 2},
+		{"\t", 4, 4},
+		{" \t", 4, 4},
+		{"\t ", 4, 5},
+		{"abcde\t", 4, 8},
+		{"\t", 0, 1},
+	}
+	for _, tt := range tests {
+		if got := Columns(tt.s, tt.tabstop); got != tt.want {
+			t.Errorf("Columns(%q, %d) = %d, want %d", tt.s, tt.tabstop, got, tt.want)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	python, c, prose := For("python"), For("c"), For("md")
+	tests := []struct {
+		name  string
+		rules Rules
+		line  string
+		opens bool
+		ends  bool
+	}{
+		{"python colon", python, "if x:", true, false},
+		{"python colon trailing space", python, "def f():  ", true, false},
+		{"python bracket", python, "x = [", true, false},
+		{"python return", python, "    return x", false, true},
+		{"python returned", python, "returned = 1", false, false},
+		{"c brace", c, "int main() {", true, false},
+		{"c colon", c, "case 1:", false, false},
+		{"c return", c, "return 0;", false, false},
+		{"prose", prose, "list: {", false, false},
+		{"blank", c, "   ", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Opens(tt.line); got != tt.opens {
+				t.Errorf("Opens(%q) = %v, want %v", tt.line, got, tt.opens)
+			}
+			if got := tt.rules.Ends(tt.line); got != tt.ends {
+				t.Errorf("Ends(%q) = %v, want %v", tt.line, got, tt.ends)
+			}
+		})
+	}
+}
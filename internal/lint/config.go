@@ -0,0 +1,46 @@
+// Package lint runs external linters such as go vet, shellcheck or eslint
+// on saved files and reads the problems they report.
+package lint
+
+import "github.com/Adelodunpeter25/vx/internal/tool"
+
+// ConfigFile is the name of the linter configuration in the config directory
+const ConfigFile = "linters.json"
+
+// Linter is a command that checks a file and prints what it finds as
+// file:line:col: message lines
+type Linter struct {
+	tool.Tool
+}
+
+// Config maps filetypes to linters
+type Config map[string]*Linter
+
+// defaults are the linters used when they are installed, unless the config
+// file says otherwise
+func defaults() tool.Config {
+	eslint := []string{"eslint", "--format", "unix", "${file}"}
+	return tool.Config{
+		"go":         {Command: []string{"go", "vet", "."}},
+		"bash":       {Command: []string{"shellcheck", "--format", "gcc", "${file}"}},
+		"python":     {Command: []string{"flake8", "${file}"}},
+		"javascript": {Command: eslint},
+		"typescript": {Command: eslint},
+		"react":      {Command: eslint},
+	}
+}
+
+// LoadConfig returns the installed built-in linters overridden by the
+// config file, a JSON object of filetypes to linters, e.g.
+//
+//	{"go": {"command": ["staticcheck", "."]}, "bash": null}
+//
+// where null turns a built-in linter off.
+func LoadConfig() (Config, error) {
+	tools, err := tool.LoadConfig(ConfigFile, defaults())
+	config := make(Config, len(tools))
+	for filetype, t := range tools {
+		config[filetype] = &Linter{*t}
+	}
+	return config, err
+}
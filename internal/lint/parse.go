@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+)
+
+// Issue is a problem a linter reported
+type Issue struct {
+	Path     string
+	Line     int // 0-based
+	Col      int // 0-based byte column, or -1 when the linter gave none
+	Severity int
+	Message  string
+}
+
+var (
+	// issueLine matches file:line:col: message and file:line: message
+	issueLine = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?:\s*(.*)$`)
+	// severityPrefix matches the "warning: " gcc style linters start with
+	severityPrefix = regexp.MustCompile(`(?i)^(fatal error|error|warning|note|info|style|hint):\s*`)
+	// severityTag matches the "[Error/rule]" eslint's unix format ends with
+	severityTag = regexp.MustCompile(`\[(Error|Warning|Info)/[^\]]*\]$`)
+)
+
+// severities maps the words linters use to severities
+var severities = map[string]int{
+	"fatal error": diagnostic.Error,
+	"error":       diagnostic.Error,
+	"warning":     diagnostic.Warning,
+	"note":        diagnostic.Info,
+	"info":        diagnostic.Info,
+	"style":       diagnostic.Hint,
+	"hint":        diagnostic.Hint,
+}
+
+// Parse reads the issues in a linter's output, resolving relative paths
+// against dir. Lines that name no location are skipped. Issues without a
+// severity are warnings.
+func Parse(output, dir string) []Issue {
+	var issues []Issue
+	for _, line := range strings.Split(output, "\n") {
+		if issue, ok := parseLine(strings.TrimRight(line, "\r"), dir); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func parseLine(line, dir string) (Issue, bool) {
+	m := issueLine.FindStringSubmatch(line)
+	if m == nil {
+		return Issue{}, false
+	}
+	path := m[1]
+	// Tools that report for another, as go vet does for the compiler, put
+	// their name first: "vet: ./main.go:3:2: ..."
+	if i := strings.LastIndex(path, ": "); i >= 0 {
+		path = path[i+2:]
+	}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return Issue{}, false
+	}
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+
+	issue := Issue{Path: filepath.Clean(path), Col: -1, Severity: diagnostic.Warning, Message: m[4]}
+	n, _ := strconv.Atoi(m[2])
+	issue.Line = max(n-1, 0)
+	if m[3] != "" {
+		n, _ := strconv.Atoi(m[3])
+		issue.Col = max(n-1, 0)
+	}
+	if sm := severityPrefix.FindStringSubmatch(issue.Message); sm != nil {
+		issue.Severity = severities[strings.ToLower(sm[1])]
+		issue.Message = issue.Message[len(sm[0]):]
+	} else if sm := severityTag.FindStringSubmatch(issue.Message); sm != nil {
+		issue.Severity = severities[strings.ToLower(sm[1])]
+	}
+	if issue.Message == "" {
+		return Issue{}, false
+	}
+	return issue, true
+}
@@ -0,0 +1,49 @@
+package lint
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Adelodunpeter25/vx/internal/diagnostic"
+)
+
+func TestParse(t *testing.T) {
+	dir := filepath.FromSlash("/proj/pkg")
+	in := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+	tests := []struct {
+		name   string
+		output string
+		want   []Issue
+	}{
+		{"go vet", "# example.com/pkg\nvet: ./main.go:3:2: unreachable code\n",
+			[]Issue{{in("main.go"), 2, 1, diagnostic.Warning, "unreachable code"}}},
+		{"gcc style", "run.sh:10:5: warning: Quote this [SC2086]\nrun.sh:12:1: error: Parse error\nrun.sh:1:1: note: Ok\n",
+			[]Issue{
+				{in("run.sh"), 9, 4, diagnostic.Warning, "Quote this [SC2086]"},
+				{in("run.sh"), 11, 0, diagnostic.Error, "Parse error"},
+				{in("run.sh"), 0, 0, diagnostic.Info, "Ok"},
+			}},
+		{"flake8", "./app.py:4:80: E501 line too long (88 > 79 characters)\n",
+			[]Issue{{in("app.py"), 3, 79, diagnostic.Warning, "E501 line too long (88 > 79 characters)"}}},
+		{"eslint unix", "/proj/web/a.js:2:7: 'x' is assigned a value but never used. [Error/no-unused-vars]\n",
+			[]Issue{{filepath.FromSlash("/proj/web/a.js"), 1, 6, diagnostic.Error, "'x' is assigned a value but never used. [Error/no-unused-vars]"}}},
+		{"no column", "lib/x.go:7: something odd\n",
+			[]Issue{{in("lib/x.go"), 6, -1, diagnostic.Warning, "something odd"}}},
+		{"fatal error", "a.c:1:1: fatal error: no such file\n",
+			[]Issue{{in("a.c"), 0, 0, diagnostic.Error, "no such file"}}},
+		{"style and crlf", "b.sh:2:3: style: Use $(...)\r\n",
+			[]Issue{{in("b.sh"), 1, 2, diagnostic.Hint, "Use $(...)"}}},
+		{"cleans paths", "./sub/../c.go:1:1: x\n",
+			[]Issue{{in("c.go"), 0, 0, diagnostic.Warning, "x"}}},
+		{"skips the rest", "Checking...\n\n3 problems\nmain.go:1:1: \n", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.output, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("Parse =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
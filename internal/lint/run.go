@@ -0,0 +1,50 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Timeout is how long a linter may run
+const Timeout = 30 * time.Second
+
+// Run lints filename, an absolute path, and returns the issues reported in
+// it. Linters exit with an error when they find problems, so only a linter
+// that fails without reporting any is an error, holding the first line of
+// its output.
+func (l *Linter) Run(ctx context.Context, filename string) ([]Issue, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	cmd := l.Cmd(ctx, filename)
+	// Some linters report on stderr, as go vet does
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, l.Failure(ctx, Timeout, "", err)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	all := Parse(output.String(), cmd.Dir)
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || len(all) == 0) {
+		return nil, l.Failure(ctx, Timeout, output.String(), err)
+	}
+
+	// Linters of whole packages report on the other files too
+	var issues []Issue
+	for _, issue := range all {
+		if issue.Path == filepath.Clean(filename) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
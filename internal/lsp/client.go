@@ -0,0 +1,503 @@
+// Package lsp is a client for the Language Server Protocol. A Client runs a
+// server as a child process talking JSON-RPC over stdio, or talks to one on
+// any connection, such as a fake server in-process.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is a connection to a language server
+type Client struct {
+	Name string // shown in messages, e.g. "gopls"
+	Root string
+
+	// OnDiagnostics is called when the server publishes diagnostics for a
+	// document, and OnMessage when it asks to show a message. Both are
+	// called from the goroutine reading the connection and must be set
+	// before Initialize.
+	OnDiagnostics func(uri string)
+	OnMessage     func(severity int, text string)
+
+	conn     *Conn
+	cmd      *exec.Cmd
+	exited   chan struct{}
+	stderr   *tail
+	settings json.RawMessage
+	encoding string
+	caps     capabilities
+
+	mu          sync.Mutex
+	diagnostics map[string][]Diagnostic
+}
+
+// capabilities are the parts of the server's capabilities the client uses
+type capabilities struct {
+	sync       int // 0 none, 1 full text, 2 incremental
+	openClose  bool
+	save       bool
+	saveText   bool
+	hover      bool
+	definition bool
+	references bool
+	rename     bool
+	completion bool
+	triggers   []string
+}
+
+// Text document sync kinds
+const (
+	syncNone        = 0
+	syncFull        = 1
+	syncIncremental = 2
+)
+
+// Start runs a server in root
+func Start(server *Server, root string) (*Client, error) {
+	if len(server.Command) == 0 {
+		return nil, fmt.Errorf("no server command")
+	}
+	cmd := exec.Command(server.Command[0], server.Command[1:]...)
+	cmd.Dir = root
+	// Pipes are made by hand so that Wait doesn't close them under the
+	// reading goroutine
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		inR.Close()
+		inW.Close()
+		return nil, err
+	}
+	stderr := &tail{}
+	cmd.Stdin = inR
+	cmd.Stdout = outW
+	cmd.Stderr = stderr
+	err = cmd.Start()
+	inR.Close()
+	outW.Close()
+	if err != nil {
+		inW.Close()
+		outR.Close()
+		return nil, err
+	}
+
+	c := NewClient(pipe{outR, inW}, server.Name(), root)
+	c.cmd = cmd
+	c.stderr = stderr
+	c.settings = server.Settings
+	c.exited = make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(c.exited)
+	}()
+	return c, nil
+}
+
+// NewClient returns a client for a server on rw
+func NewClient(rw io.ReadWriteCloser, name, root string) *Client {
+	c := &Client{
+		Name:        name,
+		Root:        root,
+		encoding:    EncodingUTF16,
+		diagnostics: make(map[string][]Diagnostic),
+	}
+	c.conn = NewConn(rw, c.handle)
+	return c
+}
+
+// pipe joins a process's stdout and stdin
+type pipe struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (p pipe) Close() error {
+	p.WriteCloser.Close()
+	return p.ReadCloser.Close()
+}
+
+// clientCapabilities tells the server what the client understands
+var clientCapabilities = map[string]any{
+	"general": map[string]any{
+		"positionEncodings": []string{EncodingUTF8, EncodingUTF16},
+	},
+	"textDocument": map[string]any{
+		"synchronization":    map[string]any{"didSave": true},
+		"publishDiagnostics": map[string]any{},
+		"hover":              map[string]any{"contentFormat": []string{"plaintext", "markdown"}},
+		"definition":         map[string]any{"linkSupport": true},
+		"references":         map[string]any{},
+		"rename":             map[string]any{},
+		"completion": map[string]any{
+			"completionItem": map[string]any{
+				"snippetSupport":      true,
+				"documentationFormat": []string{"plaintext", "markdown"},
+			},
+		},
+	},
+	"workspace": map[string]any{
+		"configuration":    true,
+		"workspaceFolders": true,
+		"workspaceEdit":    map[string]any{"documentChanges": true},
+	},
+}
+
+// Initialize performs the initialize handshake. options are sent as
+// initializationOptions.
+func (c *Client) Initialize(ctx context.Context, options json.RawMessage) error {
+	params := map[string]any{
+		"processId":        os.Getpid(),
+		"clientInfo":       map[string]string{"name": "vx"},
+		"rootUri":          URIFromPath(c.Root),
+		"workspaceFolders": c.workspaceFolders(),
+		"capabilities":     clientCapabilities,
+	}
+	if len(options) > 0 {
+		params["initializationOptions"] = options
+	}
+	var result struct {
+		Capabilities struct {
+			PositionEncoding   string          `json:"positionEncoding"`
+			TextDocumentSync   json.RawMessage `json:"textDocumentSync"`
+			HoverProvider      json.RawMessage `json:"hoverProvider"`
+			DefinitionProvider json.RawMessage `json:"definitionProvider"`
+			ReferencesProvider json.RawMessage `json:"referencesProvider"`
+			RenameProvider     json.RawMessage `json:"renameProvider"`
+			CompletionProvider *struct {
+				TriggerCharacters []string `json:"triggerCharacters"`
+			} `json:"completionProvider"`
+		} `json:"capabilities"`
+	}
+	if err := c.conn.Call(ctx, "initialize", params, &result); err != nil {
+		return c.wrap(err)
+	}
+
+	caps := result.Capabilities
+	if caps.PositionEncoding != "" {
+		c.encoding = caps.PositionEncoding
+	}
+	c.caps.sync, c.caps.openClose, c.caps.save, c.caps.saveText = parseSync(caps.TextDocumentSync)
+	c.caps.hover = provided(caps.HoverProvider)
+	c.caps.definition = provided(caps.DefinitionProvider)
+	c.caps.references = provided(caps.ReferencesProvider)
+	c.caps.rename = provided(caps.RenameProvider)
+	if caps.CompletionProvider != nil {
+		c.caps.completion = true
+		c.caps.triggers = caps.CompletionProvider.TriggerCharacters
+	}
+
+	c.conn.Notify("initialized", struct{}{})
+	if len(c.settings) > 0 {
+		c.conn.Notify("workspace/didChangeConfiguration", map[string]any{"settings": c.settings})
+	}
+	return nil
+}
+
+// parseSync reads textDocumentSync, which is a sync kind or an object
+func parseSync(raw json.RawMessage) (kind int, openClose, save, saveText bool) {
+	if len(raw) == 0 {
+		return syncNone, false, false, false
+	}
+	if json.Unmarshal(raw, &kind) == nil {
+		return kind, kind != syncNone, true, false
+	}
+	var opts struct {
+		OpenClose bool            `json:"openClose"`
+		Change    int             `json:"change"`
+		Save      json.RawMessage `json:"save"`
+	}
+	if json.Unmarshal(raw, &opts) != nil {
+		return syncNone, false, false, false
+	}
+	var saveOpts struct {
+		IncludeText bool `json:"includeText"`
+	}
+	if json.Unmarshal(opts.Save, &saveOpts) == nil {
+		saveText = saveOpts.IncludeText
+	}
+	return opts.Change, opts.OpenClose, provided(opts.Save), saveText
+}
+
+// provided reports whether a capability is true or an options object
+func provided(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return s != "" && s != "false" && s != "null"
+}
+
+func (c *Client) workspaceFolders() []map[string]string {
+	return []map[string]string{{"uri": URIFromPath(c.Root), "name": filepath.Base(c.Root)}}
+}
+
+// handle answers the server's requests and notifications
+func (c *Client) handle(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p publishDiagnosticsParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		if len(p.Diagnostics) == 0 {
+			delete(c.diagnostics, p.URI)
+		} else {
+			c.diagnostics[p.URI] = p.Diagnostics
+		}
+		c.mu.Unlock()
+		if c.OnDiagnostics != nil {
+			c.OnDiagnostics(p.URI)
+		}
+		return nil, nil
+	case "window/showMessage":
+		var p struct {
+			Type    int    `json:"type"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(params, &p); err == nil && c.OnMessage != nil {
+			c.OnMessage(p.Type, p.Message)
+		}
+		return nil, nil
+	case "workspace/configuration":
+		var p struct {
+			Items []struct {
+				Section string `json:"section"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		var sections map[string]json.RawMessage
+		json.Unmarshal(c.settings, &sections)
+		result := make([]json.RawMessage, len(p.Items))
+		for i, item := range p.Items {
+			result[i] = json.RawMessage("null")
+			if s, ok := sections[item.Section]; ok {
+				result[i] = s
+			}
+		}
+		return result, nil
+	case "workspace/workspaceFolders":
+		return c.workspaceFolders(), nil
+	case "workspace/applyEdit":
+		return map[string]bool{"applied": false}, nil
+	case "window/logMessage", "window/showMessageRequest", "window/workDoneProgress/create",
+		"client/registerCapability", "client/unregisterCapability", "$/progress", "telemetry/event":
+		return nil, nil
+	}
+	return nil, ErrMethodNotFound
+}
+
+// Encoding returns the position encoding the server counts characters in
+func (c *Client) Encoding() string {
+	return c.encoding
+}
+
+// TriggerCharacters returns the characters that start completion
+func (c *Client) TriggerCharacters() []string {
+	return c.caps.triggers
+}
+
+// Diagnostics returns the last diagnostics published for a document
+func (c *Client) Diagnostics(uri string) []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diagnostics[uri]
+}
+
+// Err returns why the connection to the server closed, or nil
+func (c *Client) Err() error {
+	if err := c.conn.Err(); err != nil {
+		return c.wrap(err)
+	}
+	return nil
+}
+
+// wrap adds the last line the server wrote to stderr to an error
+func (c *Client) wrap(err error) error {
+	if c.stderr == nil || c.conn.Err() == nil {
+		return err
+	}
+	if line := c.stderr.lastLine(); line != "" {
+		return fmt.Errorf("%v: %s", err, line)
+	}
+	return err
+}
+
+// Shutdown asks the server to exit and stops it if it doesn't
+func (c *Client) Shutdown(ctx context.Context) {
+	if c.conn.Err() == nil {
+		c.conn.Call(ctx, "shutdown", nil, nil)
+		c.conn.Notify("exit", nil)
+	}
+	if c.cmd != nil {
+		select {
+		case <-c.exited:
+		case <-time.After(500 * time.Millisecond):
+			c.cmd.Process.Kill()
+		}
+	}
+	c.conn.Close()
+}
+
+func (c *Client) unsupported(feature string) error {
+	return fmt.Errorf("%s does not support %s", c.Name, feature)
+}
+
+// positionParams returns the parameters of a request at a rune position
+func (c *Client) positionParams(doc *Document, line, col int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: doc.URI},
+		Position:     Position{Line: line, Character: Character(c.encoding, doc.Line(line), col)},
+	}
+}
+
+// Hover returns the hover text for a position, or "" if there is none
+func (c *Client) Hover(ctx context.Context, doc *Document, line, col int) (string, error) {
+	if !c.caps.hover {
+		return "", c.unsupported("hover")
+	}
+	var result *struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.conn.Call(ctx, "textDocument/hover", c.positionParams(doc, line, col), &result); err != nil {
+		return "", c.wrap(err)
+	}
+	if result == nil {
+		return "", nil
+	}
+	return markupText(result.Contents), nil
+}
+
+// Definition returns where the symbol at a position is defined
+func (c *Client) Definition(ctx context.Context, doc *Document, line, col int) ([]Location, error) {
+	if !c.caps.definition {
+		return nil, c.unsupported("go to definition")
+	}
+	var raw json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/definition", c.positionParams(doc, line, col), &raw); err != nil {
+		return nil, c.wrap(err)
+	}
+	return parseLocations(raw), nil
+}
+
+// parseLocations reads a Location, a list of them or a list of LocationLinks
+func parseLocations(raw json.RawMessage) []Location {
+	var one Location
+	if json.Unmarshal(raw, &one) == nil && one.URI != "" {
+		return []Location{one}
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) != nil {
+		return nil
+	}
+	var locations []Location
+	for _, item := range list {
+		var link locationLink
+		if json.Unmarshal(item, &link) == nil && link.TargetURI != "" {
+			locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+			continue
+		}
+		var loc Location
+		if json.Unmarshal(item, &loc) == nil && loc.URI != "" {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// References returns the uses of the symbol at a position, including its
+// declaration
+func (c *Client) References(ctx context.Context, doc *Document, line, col int) ([]Location, error) {
+	if !c.caps.references {
+		return nil, c.unsupported("references")
+	}
+	params := struct {
+		TextDocumentPositionParams
+		Context struct {
+			IncludeDeclaration bool `json:"includeDeclaration"`
+		} `json:"context"`
+	}{TextDocumentPositionParams: c.positionParams(doc, line, col)}
+	params.Context.IncludeDeclaration = true
+	var locations []Location
+	if err := c.conn.Call(ctx, "textDocument/references", params, &locations); err != nil {
+		return nil, c.wrap(err)
+	}
+	return locations, nil
+}
+
+// Rename returns the edits that rename the symbol at a position
+func (c *Client) Rename(ctx context.Context, doc *Document, line, col int, newName string) (*WorkspaceEdit, error) {
+	if !c.caps.rename {
+		return nil, c.unsupported("rename")
+	}
+	params := struct {
+		TextDocumentPositionParams
+		NewName string `json:"newName"`
+	}{c.positionParams(doc, line, col), newName}
+	var edit *WorkspaceEdit
+	if err := c.conn.Call(ctx, "textDocument/rename", params, &edit); err != nil {
+		return nil, c.wrap(err)
+	}
+	if edit == nil {
+		return nil, fmt.Errorf("nothing to rename")
+	}
+	return edit, nil
+}
+
+// Completion returns the completions at a position
+func (c *Client) Completion(ctx context.Context, doc *Document, line, col int) ([]CompletionItem, error) {
+	if !c.caps.completion {
+		return nil, c.unsupported("completion")
+	}
+	var raw json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/completion", c.positionParams(doc, line, col), &raw); err != nil {
+		return nil, c.wrap(err)
+	}
+	var items []CompletionItem
+	if json.Unmarshal(raw, &items) == nil {
+		return items, nil
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// tail keeps the end of a server's stderr for error messages
+type tail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailSize = 4096
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > tailSize {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-tailSize:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tail) lastLine() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(string(t.buf)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
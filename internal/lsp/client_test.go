@@ -0,0 +1,384 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// lines is a Text held as a slice
+type lines []string
+
+func (l lines) LineCount() int    { return len(l) }
+func (l lines) Line(n int) string { return l[n] }
+
+// notification is a notification the fake server received
+type notification struct {
+	method string
+	params json.RawMessage
+}
+
+// fakeServer is a language server on the other end of a net.Pipe. It
+// answers requests from results, by method, and records notifications.
+type fakeServer struct {
+	conn    *Conn
+	results map[string]any
+	params  map[string]chan json.RawMessage // params of requests, by method
+	notes   chan notification
+}
+
+// startServer connects a client to a fake server with capabilities and
+// initializes it
+func startServer(t *testing.T, capabilities map[string]any, results map[string]any) (*Client, *fakeServer) {
+	t.Helper()
+	a, b := net.Pipe()
+	s := &fakeServer{
+		results: results,
+		params:  make(map[string]chan json.RawMessage),
+		notes:   make(chan notification, 16),
+	}
+	for method := range results {
+		s.params[method] = make(chan json.RawMessage, 1)
+	}
+	s.params["initialize"] = make(chan json.RawMessage, 1)
+	s.conn = NewConn(b, func(method string, params json.RawMessage) (any, error) {
+		if method == "initialize" {
+			s.params[method] <- params
+			return map[string]any{"capabilities": capabilities}, nil
+		}
+		if result, ok := s.results[method]; ok {
+			s.params[method] <- params
+			return result, nil
+		}
+		s.notes <- notification{method, params}
+		return nil, ErrMethodNotFound
+	})
+	c := NewClient(a, "fake", "/work")
+	t.Cleanup(func() {
+		c.conn.Close()
+		s.conn.Close()
+	})
+	if err := c.Initialize(context.Background(), json.RawMessage(`{"fast":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	return c, s
+}
+
+// note waits for the next notification the server receives
+func (s *fakeServer) note(t *testing.T) notification {
+	t.Helper()
+	select {
+	case n := <-s.notes:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+	}
+	return notification{}
+}
+
+// request returns the params of the last request for method
+func (s *fakeServer) request(t *testing.T, method string) json.RawMessage {
+	t.Helper()
+	select {
+	case p := <-s.params[method]:
+		return p
+	case <-time.After(time.Second):
+		t.Fatalf("no %s request", method)
+	}
+	return nil
+}
+
+func TestInitialize(t *testing.T) {
+	c, s := startServer(t, map[string]any{
+		"positionEncoding":   "utf-8",
+		"textDocumentSync":   map[string]any{"openClose": true, "change": 2, "save": map[string]bool{"includeText": true}},
+		"hoverProvider":      true,
+		"definitionProvider": map[string]any{},
+		"renameProvider":     false,
+		"completionProvider": map[string]any{"triggerCharacters": []string{".", ":"}},
+	}, nil)
+
+	var params struct {
+		RootURI               string          `json:"rootUri"`
+		InitializationOptions json.RawMessage `json:"initializationOptions"`
+		Capabilities          map[string]any  `json:"capabilities"`
+	}
+	if err := json.Unmarshal(s.request(t, "initialize"), &params); err != nil {
+		t.Fatal(err)
+	}
+	if params.RootURI != "file:///work" || string(params.InitializationOptions) != `{"fast":true}` || params.Capabilities["textDocument"] == nil {
+		t.Errorf("initialize params = %+v", params)
+	}
+	if n := s.note(t); n.method != "initialized" {
+		t.Errorf("got %s after initialize, want initialized", n.method)
+	}
+
+	if c.Encoding() != EncodingUTF8 {
+		t.Errorf("Encoding() = %s, want utf-8", c.Encoding())
+	}
+	if !slices.Equal(c.TriggerCharacters(), []string{".", ":"}) {
+		t.Errorf("TriggerCharacters() = %v", c.TriggerCharacters())
+	}
+	want := capabilities{
+		sync: syncIncremental, openClose: true, save: true, saveText: true,
+		hover: true, definition: true, completion: true, triggers: []string{".", ":"},
+	}
+	if got := c.caps; got.sync != want.sync || got.openClose != want.openClose || got.save != want.save ||
+		got.saveText != want.saveText || got.hover != want.hover || got.definition != want.definition ||
+		got.references || got.rename || !got.completion {
+		t.Errorf("capabilities = %+v, want %+v", got, want)
+	}
+	if _, err := c.Rename(context.Background(), &Document{}, 0, 0, "x"); err == nil {
+		t.Error("Rename succeeded without a rename provider")
+	}
+}
+
+func TestParseSync(t *testing.T) {
+	tests := []struct {
+		raw                      string
+		kind                     int
+		openClose, save, withTxt bool
+	}{
+		{``, syncNone, false, false, false},
+		{`0`, syncNone, false, true, false},
+		{`1`, syncFull, true, true, false},
+		{`2`, syncIncremental, true, true, false},
+		{`{"openClose":true,"change":1}`, syncFull, true, false, false},
+		{`{"change":2,"save":true}`, syncIncremental, false, true, false},
+		{`{"change":2,"save":{"includeText":true}}`, syncIncremental, false, true, true},
+	}
+	for _, tt := range tests {
+		kind, openClose, save, saveText := parseSync(json.RawMessage(tt.raw))
+		if kind != tt.kind || openClose != tt.openClose || save != tt.save || saveText != tt.withTxt {
+			t.Errorf("parseSync(%s) = %d %v %v %v", tt.raw, kind, openClose, save, saveText)
+		}
+	}
+}
+
+// changeParams is a didChange notification
+type changeParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+func TestDidChangeIncremental(t *testing.T) {
+	c, s := startServer(t, map[string]any{"textDocumentSync": 2}, nil)
+	s.note(t) // initialized
+
+	doc := c.Open("file:///work/a.go", "go", lines{"a", "b", "c"})
+	if n := s.note(t); n.method != "textDocument/didOpen" {
+		t.Fatalf("got %s, want didOpen", n.method)
+	}
+
+	tests := []struct {
+		name  string
+		text  lines
+		want  Range
+		added string
+	}{
+		{"change a line", lines{"a", "X", "c"}, Range{Start: Position{1, 0}, End: Position{2, 0}}, "X\n"},
+		{"insert a line", lines{"a", "X", "new", "c"}, Range{Start: Position{2, 0}, End: Position{2, 0}}, "new\n"},
+		{"delete a line", lines{"a", "new", "c"}, Range{Start: Position{1, 0}, End: Position{2, 0}}, ""},
+		{"append at the end", lines{"a", "new", "c", "d"}, Range{Start: Position{2, 1}, End: Position{2, 1}}, "\nd"},
+		{"change the last line", lines{"a", "new", "c", "é"}, Range{Start: Position{2, 1}, End: Position{3, 1}}, "\né"},
+		{"replace everything", lines{"z"}, Range{End: Position{3, 1}}, "z"},
+	}
+	for i, tt := range tests {
+		if !c.Change(doc, tt.text, 0) {
+			t.Fatalf("%s: Change reported nothing changed", tt.name)
+		}
+		n := s.note(t)
+		var p changeParams
+		if err := json.Unmarshal(n.params, &p); err != nil || n.method != "textDocument/didChange" {
+			t.Fatalf("%s: got %s %s", tt.name, n.method, n.params)
+		}
+		if *p.TextDocument.Version != i+2 {
+			t.Errorf("%s: version %d, want %d", tt.name, *p.TextDocument.Version, i+2)
+		}
+		ev := p.ContentChanges[0]
+		if ev.Range == nil || *ev.Range != tt.want || ev.Text != tt.added {
+			t.Errorf("%s: change %+v %q, want %+v %q", tt.name, ev.Range, ev.Text, tt.want, tt.added)
+		}
+		if doc.LineCount() != len(tt.text) || doc.Line(len(tt.text)-1) != tt.text[len(tt.text)-1] {
+			t.Errorf("%s: document doesn't hold the new lines", tt.name)
+		}
+	}
+	if c.Change(doc, lines{"z"}, 0) {
+		t.Error("Change reported a change for the same text")
+	}
+}
+
+func TestDidChangeFull(t *testing.T) {
+	c, s := startServer(t, map[string]any{"textDocumentSync": 1}, nil)
+	s.note(t) // initialized
+	doc := c.Open("file:///work/a.go", "go", lines{"a", "b"})
+	s.note(t) // didOpen
+	c.Change(doc, lines{"a", "b", "c"}, 1)
+	var p changeParams
+	json.Unmarshal(s.note(t).params, &p)
+	if ev := p.ContentChanges[0]; ev.Range != nil || ev.Text != "a\nb\nc" {
+		t.Errorf("full change = %+v", ev)
+	}
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	c, s := startServer(t, map[string]any{}, nil)
+	published := make(chan string, 2)
+	c.OnDiagnostics = func(uri string) { published <- uri }
+
+	uri := "file:///work/a.go"
+	s.conn.Notify("textDocument/publishDiagnostics", map[string]any{
+		"uri": uri,
+		"diagnostics": []Diagnostic{
+			{Range: Range{Start: Position{1, 2}, End: Position{1, 5}}, Severity: SeverityError, Source: "compiler", Message: "undefined: x"},
+		},
+	})
+	select {
+	case got := <-published:
+		if got != uri {
+			t.Errorf("OnDiagnostics(%s), want %s", got, uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnDiagnostics not called")
+	}
+	diags := c.Diagnostics(uri)
+	if len(diags) != 1 || diags[0].Message != "undefined: x" || diags[0].Range.Start != (Position{1, 2}) {
+		t.Errorf("Diagnostics = %+v", diags)
+	}
+
+	// An empty list clears them
+	s.conn.Notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": []Diagnostic{}})
+	<-published
+	if diags := c.Diagnostics(uri); diags != nil {
+		t.Errorf("Diagnostics after clearing = %+v", diags)
+	}
+}
+
+func TestHover(t *testing.T) {
+	c, s := startServer(t, map[string]any{"hoverProvider": true}, map[string]any{
+		"textDocument/hover": map[string]any{
+			"contents": map[string]string{"kind": "markdown", "value": "```go\nfunc Foo()\n```\nFoo does things."},
+		},
+	})
+	doc := c.Open("file:///work/a.go", "go", lines{"// é", "x := Foo()"})
+	text, err := c.Hover(context.Background(), doc, 1, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "func Foo()\nFoo does things." {
+		t.Errorf("Hover = %q", text)
+	}
+	var p TextDocumentPositionParams
+	json.Unmarshal(s.request(t, "textDocument/hover"), &p)
+	if p.TextDocument.URI != doc.URI || p.Position != (Position{1, 6}) {
+		t.Errorf("hover params = %+v", p)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	target := Range{Start: Position{3, 5}, End: Position{3, 8}}
+	tests := []struct {
+		name   string
+		result any
+		want   []Location
+	}{
+		{"location", Location{URI: "file:///work/b.go", Range: target}, []Location{{URI: "file:///work/b.go", Range: target}}},
+		{"locations", []Location{{URI: "file:///work/b.go", Range: target}, {URI: "file:///work/c.go"}},
+			[]Location{{URI: "file:///work/b.go", Range: target}, {URI: "file:///work/c.go"}}},
+		{"links", []locationLink{{TargetURI: "file:///work/b.go", TargetRange: Range{End: Position{9, 0}}, TargetSelectionRange: target}},
+			[]Location{{URI: "file:///work/b.go", Range: target}}},
+		{"none", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := startServer(t, map[string]any{"definitionProvider": true}, map[string]any{"textDocument/definition": tt.result})
+			doc := c.Open("file:///work/a.go", "go", lines{"Foo()"})
+			got, err := c.Definition(context.Background(), doc, 0, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Definition = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	c, _ := startServer(t, map[string]any{"renameProvider": map[string]any{}}, map[string]any{
+		"textDocument/rename": map[string]any{
+			"changes": map[string][]TextEdit{
+				"file:///work/a.go": {{Range: Range{Start: Position{0, 4}, End: Position{0, 7}}, NewText: "bar"}},
+			},
+			"documentChanges": []any{
+				TextDocumentEdit{
+					TextDocument: VersionedTextDocumentIdentifier{URI: "file:///work/b.go"},
+					Edits: []TextEdit{
+						{Range: Range{Start: Position{0, 0}, End: Position{0, 3}}, NewText: "bar"},
+						{Range: Range{Start: Position{1, 5}, End: Position{1, 8}}, NewText: "bar"},
+					},
+				},
+				map[string]any{"kind": "create", "uri": "file:///work/c.go"},
+			},
+		},
+	})
+	doc := c.Open("file:///work/a.go", "go", lines{"var foo = 1"})
+	edit, err := c.Rename(context.Background(), doc, 0, 5, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := edit.Edits()
+	if len(edits) != 2 {
+		t.Fatalf("edits for %d documents, want 2: %+v", len(edits), edits)
+	}
+	if got := ApplyEdits(EncodingUTF16, []string{"var foo = 1"}, edits["file:///work/a.go"]); !slices.Equal(got, []string{"var bar = 1"}) {
+		t.Errorf("a.go = %q", got)
+	}
+	if got := ApplyEdits(EncodingUTF16, []string{"foo()", "x := foo"}, edits["file:///work/b.go"]); !slices.Equal(got, []string{"bar()", "x := bar"}) {
+		t.Errorf("b.go = %q", got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	items := []map[string]any{
+		{"label": "Println", "kind": 3, "insertText": "Println(${1:a})", "insertTextFormat": InsertTextSnippet},
+		{"label": "Printf", "kind": 3, "textEdit": map[string]any{
+			"insert":  Range{Start: Position{0, 4}, End: Position{0, 6}},
+			"replace": Range{Start: Position{0, 4}, End: Position{0, 9}},
+			"newText": "Printf",
+		}},
+		{"label": "Print"},
+	}
+	tests := []struct {
+		name   string
+		result any
+	}{
+		{"list", items},
+		{"completion list", map[string]any{"isIncomplete": false, "items": items}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := startServer(t, map[string]any{"completionProvider": map[string]any{}}, map[string]any{"textDocument/completion": tt.result})
+			doc := c.Open("file:///work/a.go", "go", lines{"fmt.Pr"})
+			got, err := c.Completion(context.Background(), doc, 0, 6)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 3 {
+				t.Fatalf("got %d items, want 3", len(got))
+			}
+			if got[0].Text() != "Println(${1:a})" || got[0].InsertTextFormat != InsertTextSnippet || got[0].KindName() != "function" {
+				t.Errorf("item 0 = %+v", got[0])
+			}
+			if te := got[1].TextEdit; te == nil || te.Range.End != (Position{0, 6}) || got[1].Text() != "Printf" {
+				t.Errorf("item 1 = %+v, want the insert range of its edit", got[1])
+			}
+			if got[2].Text() != "Print" || got[2].KindName() != "" {
+				t.Errorf("item 2 = %+v", got[2])
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// ConfigFile is the name of the server configuration in the config directory
+const ConfigFile = "lsp.json"
+
+// Server describes how to start a language server
+type Server struct {
+	Command     []string        `json:"command"`
+	LanguageID  string          `json:"languageId"`            // defaults to one derived from the filetype
+	RootMarkers []string        `json:"rootMarkers"`           // files marking the project root
+	InitOptions json.RawMessage `json:"initializationOptions"` // sent with initialize
+	Settings    json.RawMessage `json:"settings"`              // answers workspace/configuration
+}
+
+// Name returns the server's command name
+func (s *Server) Name() string {
+	if len(s.Command) == 0 {
+		return ""
+	}
+	return filepath.Base(s.Command[0])
+}
+
+// Config maps filetypes to servers
+type Config map[string]*Server
+
+// defaults are the servers used when they are installed, unless the
+// config file says otherwise
+func defaults() Config {
+	typescript := []string{"typescript-language-server", "--stdio"}
+	return Config{
+		"go":         {Command: []string{"gopls"}, RootMarkers: []string{"go.work", "go.mod"}},
+		"rust":       {Command: []string{"rust-analyzer"}, RootMarkers: []string{"Cargo.toml"}},
+		"python":     {Command: []string{"pylsp"}, RootMarkers: []string{"pyproject.toml", "setup.py"}},
+		"c":          {Command: []string{"clangd"}, RootMarkers: []string{"compile_commands.json"}},
+		"c++":        {Command: []string{"clangd"}, RootMarkers: []string{"compile_commands.json"}},
+		"javascript": {Command: typescript, RootMarkers: []string{"package.json"}},
+		"typescript": {Command: typescript, RootMarkers: []string{"tsconfig.json", "package.json"}},
+		"react":      {Command: typescript, RootMarkers: []string{"package.json"}},
+	}
+}
+
+// ConfigDir returns the directory the config file is read from
+func ConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vx")
+}
+
+// LoadConfig returns the installed built-in servers overridden by the
+// config file, a JSON object of filetypes to servers, e.g.
+//
+//	{"go": {"command": ["gopls", "serve"]}, "python": null}
+//
+// where null turns a built-in server off.
+func LoadConfig() (Config, error) {
+	config := defaults()
+	for filetype, s := range config {
+		if _, err := exec.LookPath(s.Command[0]); err != nil {
+			delete(config, filetype)
+		}
+	}
+	dir := ConfigDir()
+	if dir == "" {
+		return config, nil
+	}
+	path := filepath.Join(dir, ConfigFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	var user map[string]*Server
+	if err := json.Unmarshal(data, &user); err != nil {
+		return config, fmt.Errorf("%s: %v", ConfigFile, err)
+	}
+	for filetype, server := range user {
+		if lang, ok := highlight.FindLanguage(filetype); ok {
+			filetype = lang
+		}
+		if server != nil && len(server.Command) == 0 {
+			return config, fmt.Errorf("%s: %s: no command given", ConfigFile, filetype)
+		}
+		config[filetype] = server
+	}
+	return config, nil
+}
+
+// languageIDs maps filetypes whose name differs from their LSP language id
+var languageIDs = map[string]string{
+	"c++":   "cpp",
+	"c#":    "csharp",
+	"react": "javascriptreact",
+	"md":    "markdown",
+	"bash":  "shellscript",
+}
+
+// LanguageIDFor returns the language id the server knows filetype by
+func (s *Server) LanguageIDFor(filetype string) string {
+	if s.LanguageID != "" {
+		return s.LanguageID
+	}
+	if id, ok := languageIDs[filetype]; ok {
+		return id
+	}
+	return strings.ToLower(filetype)
+}
+
+// FindRoot returns the project root of a file: the nearest directory above
+// it holding one of the server's root markers or a .git directory, or
+// fallback when there is none
+func (s *Server) FindRoot(path, fallback string) string {
+	markers := append(append([]string(nil), s.RootMarkers...), ".git")
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for _, marker := range markers {
+		for d := dir; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(filepath.Join(d, marker)); err == nil {
+				return d
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	return fallback
+}
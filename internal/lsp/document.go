@@ -0,0 +1,222 @@
+package lsp
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Text is the contents of a document by line
+type Text interface {
+	LineCount() int
+	Line(n int) string
+}
+
+// Document is a file the server has open. It keeps the lines last sent, so
+// edits are sent as the range that changed and positions in requests match
+// what the server has.
+type Document struct {
+	URI     string
+	Version int
+
+	mu    sync.Mutex // guards lines, read by requests in other goroutines
+	lines []string
+}
+
+// Line returns a line as last sent to the server
+func (d *Document) Line(n int) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+	return d.lines[n]
+}
+
+// LineCount returns the number of lines last sent to the server
+func (d *Document) LineCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.lines)
+}
+
+// Open tells the server a document was opened
+func (c *Client) Open(uri, languageID string, text Text) *Document {
+	doc := &Document{URI: uri, Version: 1, lines: make([]string, text.LineCount())}
+	for i := range doc.lines {
+		doc.lines[i] = text.Line(i)
+	}
+	if c.caps.openClose {
+		c.conn.Notify("textDocument/didOpen", map[string]any{
+			"textDocument": TextDocumentItem{URI: uri, LanguageID: languageID, Version: doc.Version, Text: doc.text()},
+		})
+	}
+	return doc
+}
+
+// Change sends the edits made to a document. Lines before from are known to
+// be unchanged. It returns false if nothing changed.
+func (c *Client) Change(doc *Document, text Text, from int) bool {
+	start, oldEnd, newEnd, changed := doc.diff(text, from)
+	if !changed {
+		return false
+	}
+	event := doc.changeEvent(c.encoding, text, start, oldEnd, newEnd)
+
+	lines := make([]string, 0, len(doc.lines)-(oldEnd-start)+(newEnd-start))
+	lines = append(lines, doc.lines[:start]...)
+	for i := start; i < newEnd; i++ {
+		lines = append(lines, text.Line(i))
+	}
+	doc.mu.Lock()
+	doc.lines = append(lines, doc.lines[oldEnd:]...)
+	doc.mu.Unlock()
+	doc.Version++
+
+	if c.caps.sync == syncNone {
+		return true
+	}
+	if c.caps.sync == syncFull {
+		event = TextDocumentContentChangeEvent{Text: doc.text()}
+	}
+	c.conn.Notify("textDocument/didChange", map[string]any{
+		"textDocument":   VersionedTextDocumentIdentifier{URI: doc.URI, Version: &doc.Version},
+		"contentChanges": []TextDocumentContentChangeEvent{event},
+	})
+	return true
+}
+
+// diff finds the lines that differ between the document and text: lines
+// start to oldEnd were replaced by lines start to newEnd of text
+func (d *Document) diff(text Text, from int) (start, oldEnd, newEnd int, changed bool) {
+	count := text.LineCount()
+	start = max(0, min(from, len(d.lines), count))
+	for start < len(d.lines) && start < count && d.lines[start] == text.Line(start) {
+		start++
+	}
+	oldEnd, newEnd = len(d.lines), count
+	for oldEnd > start && newEnd > start && d.lines[oldEnd-1] == text.Line(newEnd-1) {
+		oldEnd--
+		newEnd--
+	}
+	return start, oldEnd, newEnd, oldEnd > start || newEnd > start
+}
+
+// changeEvent describes replacing lines start to oldEnd with lines start to
+// newEnd of text. Whole lines are replaced with their line breaks, except
+// at the end of the document, where the break before them is replaced
+// instead since the last line has none.
+func (d *Document) changeEvent(encoding string, text Text, start, oldEnd, newEnd int) TextDocumentContentChangeEvent {
+	var b strings.Builder
+	if oldEnd < len(d.lines) {
+		for i := start; i < newEnd; i++ {
+			b.WriteString(text.Line(i))
+			b.WriteByte('\n')
+		}
+		r := Range{Start: Position{Line: start}, End: Position{Line: oldEnd}}
+		return TextDocumentContentChangeEvent{Range: &r, Text: b.String()}
+	}
+
+	last := len(d.lines) - 1
+	end := Position{Line: last, Character: Character(encoding, d.lines[last], len([]rune(d.lines[last])))}
+	if start == 0 {
+		for i := 0; i < newEnd; i++ {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(text.Line(i))
+		}
+		r := Range{End: end}
+		return TextDocumentContentChangeEvent{Range: &r, Text: b.String()}
+	}
+	prev := d.lines[start-1]
+	for i := start; i < newEnd; i++ {
+		b.WriteByte('\n')
+		b.WriteString(text.Line(i))
+	}
+	r := Range{Start: Position{Line: start - 1, Character: Character(encoding, prev, len([]rune(prev)))}, End: end}
+	return TextDocumentContentChangeEvent{Range: &r, Text: b.String()}
+}
+
+// Save tells the server a document was written to disk
+func (c *Client) Save(doc *Document) {
+	if !c.caps.save {
+		return
+	}
+	params := map[string]any{"textDocument": TextDocumentIdentifier{URI: doc.URI}}
+	if c.caps.saveText {
+		params["text"] = doc.text()
+	}
+	c.conn.Notify("textDocument/didSave", params)
+}
+
+// Close tells the server a document was closed
+func (c *Client) Close(doc *Document) {
+	if c.caps.openClose {
+		c.conn.Notify("textDocument/didClose", map[string]any{
+			"textDocument": TextDocumentIdentifier{URI: doc.URI},
+		})
+	}
+	c.mu.Lock()
+	delete(c.diagnostics, doc.URI)
+	c.mu.Unlock()
+}
+
+func (d *Document) text() string {
+	return strings.Join(d.lines, "\n")
+}
+
+// ApplyEdits returns lines with text edits applied. Edits must not overlap;
+// their positions refer to lines as given.
+func ApplyEdits(encoding string, lines []string, edits []TextEdit) []string {
+	text := strings.Join(lines, "\n")
+	offsets := lineOffsets(lines)
+	offset := func(p Position) int {
+		if p.Line >= len(lines) {
+			return len(text)
+		}
+		line := max(p.Line, 0)
+		col := Column(encoding, lines[line], p.Character)
+		return offsets[line] + len(string([]rune(lines[line])[:col]))
+	}
+
+	sorted := SortEdits(edits)
+	var b strings.Builder
+	pos := 0
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ed := sorted[i]
+		start, end := offset(ed.Range.Start), offset(ed.Range.End)
+		if start < pos || end < start {
+			continue
+		}
+		b.WriteString(text[pos:start])
+		b.WriteString(ed.NewText)
+		pos = end
+	}
+	b.WriteString(text[pos:])
+	return strings.Split(strings.ReplaceAll(b.String(), "\r\n", "\n"), "\n")
+}
+
+// SortEdits returns edits ordered from last to first in the document, so
+// applying them one by one leaves the positions of the rest valid. Inserts
+// at the same position still end up in their original order.
+func SortEdits(edits []TextEdit) []TextEdit {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Range.Start, sorted[j].Range.Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+	})
+	slices.Reverse(sorted)
+	return sorted
+}
+
+func lineOffsets(lines []string) []int {
+	offsets := make([]int, len(lines))
+	n := 0
+	for i, line := range lines {
+		offsets[i] = n
+		n += len(line) + 1
+	}
+	return offsets
+}
@@ -0,0 +1,303 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error is a JSON-RPC error response
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrMethodNotFound is returned by handlers for methods they don't know
+var ErrMethodNotFound = &Error{Code: -32601, Message: "method not found"}
+
+// Handler answers requests and notifications from the other end. The result
+// is ignored for notifications.
+type Handler func(method string, params json.RawMessage) (any, error)
+
+// message is any JSON-RPC message: a request has a method and an id, a
+// notification only a method, and a response only an id
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection framed with Content-Length headers, as
+// LSP uses over stdio. Both ends work the same way, so a fake server is a
+// Conn with a Handler on the other end of a net.Pipe.
+type Conn struct {
+	rw      io.ReadWriteCloser
+	handler Handler
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte // messages waiting to be written
+	closed  bool
+	err     error
+	nextID  int64
+	pending map[int64]chan *message
+	done    chan struct{}
+}
+
+// NewConn starts reading and writing messages on rw. handler may be nil
+// if the other end never sends requests or notifications.
+func NewConn(rw io.ReadWriteCloser, handler Handler) *Conn {
+	c := &Conn{
+		rw:      rw,
+		handler: handler,
+		pending: make(map[int64]chan *message),
+		done:    make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	go c.readLoop()
+	go c.writeLoop()
+	return c
+}
+
+// Call sends a request and waits for its response, which is decoded into
+// result unless it is nil. A cancelled ctx sends $/cancelRequest.
+func (c *Conn) Call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return c.closedErr()
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(strconv.FormatInt(id, 10), method, params); err != nil {
+		c.forget(id)
+		return err
+	}
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-ctx.Done():
+		c.forget(id)
+		c.Notify("$/cancelRequest", map[string]int64{"id": id})
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	}
+}
+
+// Notify sends a notification without waiting
+func (c *Conn) Notify(method string, params any) error {
+	return c.send("", method, params)
+}
+
+// Close closes the connection and fails the calls waiting on it
+func (c *Conn) Close() error {
+	c.shutdown(nil)
+	return c.rw.Close()
+}
+
+// Done is closed when the connection is closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection closed, or nil while it is open
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		return nil
+	}
+	return c.closedErr()
+}
+
+func (c *Conn) closedErr() error {
+	if c.err != nil {
+		return c.err
+	}
+	return fmt.Errorf("connection closed")
+}
+
+func (c *Conn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// send queues a request (with id) or notification
+func (c *Conn) send(id, method string, params any) error {
+	msg := message{JSONRPC: "2.0", Method: method}
+	if id != "" {
+		msg.ID = json.RawMessage(id)
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	return c.queueMessage(&msg)
+}
+
+// reply queues the response to a request
+func (c *Conn) reply(id json.RawMessage, result any, err error) {
+	msg := message{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: -32603, Message: err.Error()}
+		}
+		msg.Error = rpcErr
+	} else {
+		data, mErr := json.Marshal(result)
+		if mErr != nil {
+			msg.Error = &Error{Code: -32603, Message: mErr.Error()}
+		} else {
+			msg.Result = data
+		}
+	}
+	c.queueMessage(&msg)
+}
+
+func (c *Conn) queueMessage(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return c.closedErr()
+	}
+	c.queue = append(c.queue, data)
+	c.cond.Signal()
+	return nil
+}
+
+// writeLoop writes queued messages, so senders never block on a slow reader
+func (c *Conn) writeLoop() {
+	for {
+		c.mu.Lock()
+		for len(c.queue) == 0 && !c.closed {
+			c.cond.Wait()
+		}
+		if c.closed {
+			c.mu.Unlock()
+			return
+		}
+		data := c.queue[0]
+		c.queue[0] = nil
+		c.queue = c.queue[1:]
+		c.mu.Unlock()
+
+		header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
+		if _, err := io.WriteString(c.rw, header); err != nil {
+			c.shutdown(err)
+			return
+		}
+		if _, err := c.rw.Write(data); err != nil {
+			c.shutdown(err)
+			return
+		}
+	}
+}
+
+func (c *Conn) readLoop() {
+	r := bufio.NewReader(c.rw)
+	for {
+		data, err := readMessage(r)
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		c.dispatch(&msg)
+	}
+}
+
+func (c *Conn) dispatch(msg *message) {
+	if msg.Method == "" {
+		id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		ch := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- msg
+		}
+		return
+	}
+
+	var result any
+	err := error(ErrMethodNotFound)
+	if c.handler != nil {
+		result, err = c.handler(msg.Method, msg.Params)
+	}
+	if len(msg.ID) > 0 {
+		c.reply(msg.ID, result, err)
+	}
+}
+
+// shutdown marks the connection closed, recording err as the reason
+func (c *Conn) shutdown(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if err == io.EOF {
+		err = fmt.Errorf("server exited")
+	}
+	c.err = err
+	c.queue = nil
+	c.cond.Broadcast()
+	close(c.done)
+}
+
+// readMessage reads one message body after its headers
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("bad Content-Length header")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
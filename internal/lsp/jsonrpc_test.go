@@ -0,0 +1,178 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"one", "Content-Length: 2\r\n\r\n{}", []string{"{}"}},
+		{"two", "Content-Length: 2\r\n\r\n{}Content-Length: 4\r\n\r\nnull", []string{"{}", "null"}},
+		{"content type", "Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n[]", []string{"[]"}},
+		{"multibyte", "Content-Length: 5\r\n\r\n\"é\"\n", []string{"\"é\"\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input))
+			for _, want := range tt.want {
+				data, err := readMessage(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Errorf("got %q, want %q", data, want)
+				}
+			}
+			if _, err := readMessage(r); err != io.EOF {
+				t.Errorf("after the last message got %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadMessageBadHeader(t *testing.T) {
+	for _, input := range []string{"Content-Length: x\r\n\r\n{}", "Content-Type: text\r\n\r\n{}", "Content-Length: 9\r\n\r\n{}"} {
+		if _, err := readMessage(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("readMessage(%q) succeeded", input)
+		}
+	}
+}
+
+func TestConnWritesFramedMessages(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewConn(client, nil)
+	defer c.Close()
+	c.Notify("note", map[string]int{"n": 1})
+
+	r := bufio.NewReader(server)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var length int
+	if _, err := fmt.Sscanf(header, "Content-Length: %d\r\n", &length); err != nil {
+		t.Fatalf("header %q: %v", header, err)
+	}
+	if blank, _ := r.ReadString('\n'); blank != "\r\n" {
+		t.Fatalf("got %q after the header, want a blank line", blank)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		t.Fatal(err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.JSONRPC != "2.0" || msg.Method != "note" || len(msg.ID) != 0 || string(msg.Params) != `{"n":1}` {
+		t.Errorf("got %s", body)
+	}
+}
+
+func TestConnCall(t *testing.T) {
+	a, b := net.Pipe()
+	server := NewConn(b, func(method string, params json.RawMessage) (any, error) {
+		switch method {
+		case "echo":
+			return params, nil
+		case "fail":
+			return nil, errors.New("broken")
+		}
+		return nil, ErrMethodNotFound
+	})
+	defer server.Close()
+	client := NewConn(a, nil)
+	defer client.Close()
+	ctx := context.Background()
+
+	var got []int
+	if err := client.Call(ctx, "echo", []int{1, 2}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("echo = %v", got)
+	}
+
+	var rpcErr *Error
+	if err := client.Call(ctx, "fail", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Message != "broken" {
+		t.Errorf("fail = %v, want the handler's error", err)
+	}
+	if err := client.Call(ctx, "missing", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != ErrMethodNotFound.Code {
+		t.Errorf("missing = %v, want method not found", err)
+	}
+}
+
+func TestConnCloseFailsCalls(t *testing.T) {
+	a, b := net.Pipe()
+	// The other end reads requests and never answers
+	go io.Copy(io.Discard, b)
+	client := NewConn(a, nil)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.Call(context.Background(), "wait", nil, nil)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Close()
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Call succeeded on a closed connection")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Call still waiting after the connection closed")
+	}
+	if client.Err() == nil {
+		t.Error("Err() = nil after the connection closed")
+	}
+}
+
+func TestConnCallCancelled(t *testing.T) {
+	a, b := net.Pipe()
+	cancelled := make(chan json.RawMessage, 1)
+	release := make(chan struct{})
+	server := NewConn(b, func(method string, params json.RawMessage) (any, error) {
+		if method == "$/cancelRequest" {
+			cancelled <- params
+			return nil, nil
+		}
+		<-release
+		return nil, nil
+	})
+	defer server.Close()
+	client := NewConn(a, nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// The handler blocks the server's reader, so the cancel notification
+	// is read once the request is released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	if err := client.Call(ctx, "slow", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call = %v, want the context's error", err)
+	}
+	select {
+	case params := <-cancelled:
+		if string(params) != `{"id":1}` {
+			t.Errorf("$/cancelRequest params = %s", params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no $/cancelRequest sent")
+	}
+}
@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position encodings a server can count characters in
+const (
+	EncodingUTF8  = "utf-8"
+	EncodingUTF16 = "utf-16"
+	EncodingUTF32 = "utf-32"
+)
+
+// Character converts a rune column in line to a character offset in
+// encoding
+func Character(encoding, line string, col int) int {
+	if encoding == EncodingUTF32 {
+		return col
+	}
+	n := 0
+	for _, r := range line {
+		if col <= 0 {
+			break
+		}
+		col--
+		n += unitLen(encoding, r)
+	}
+	return n
+}
+
+// Column converts a character offset in encoding to a rune column in line,
+// clamped to the line's length
+func Column(encoding, line string, character int) int {
+	if encoding == EncodingUTF32 {
+		return min(max(character, 0), utf8.RuneCountInString(line))
+	}
+	col := 0
+	for _, r := range line {
+		if character <= 0 {
+			break
+		}
+		character -= unitLen(encoding, r)
+		col++
+	}
+	return col
+}
+
+// unitLen returns how many code units r takes in encoding
+func unitLen(encoding string, r rune) int {
+	if encoding == EncodingUTF8 {
+		return utf8.RuneLen(r)
+	}
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// URIFromPath returns the file URI of a path
+func URIFromPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// PathFromURI returns the path of a file URI, or "" for other URIs
+func PathFromURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
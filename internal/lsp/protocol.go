@@ -0,0 +1,241 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// The subset of the Language Server Protocol the editor uses. Positions are
+// zero-based, with Character counted in the negotiated position encoding.
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink is the richer form of Location some servers answer with
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// TextDocumentContentChangeEvent replaces Range with Text, or the whole
+// document when Range is nil
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Diagnostic severities
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+type Diagnostic struct {
+	Range    Range           `json:"range"`
+	Severity int             `json:"severity,omitempty"`
+	Code     json.RawMessage `json:"code,omitempty"`
+	Source   string          `json:"source,omitempty"`
+	Message  string          `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type TextDocumentEdit struct {
+	TextDocument VersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                      `json:"edits"`
+}
+
+// WorkspaceEdit holds edits to several documents. Creating, renaming and
+// deleting files is not supported.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []json.RawMessage     `json:"documentChanges,omitempty"`
+}
+
+// Edits returns the text edits by document URI
+func (w *WorkspaceEdit) Edits() map[string][]TextEdit {
+	edits := make(map[string][]TextEdit)
+	for uri, list := range w.Changes {
+		edits[uri] = append(edits[uri], list...)
+	}
+	for _, raw := range w.DocumentChanges {
+		var change struct {
+			Kind string `json:"kind"`
+			TextDocumentEdit
+		}
+		if json.Unmarshal(raw, &change) != nil || change.Kind != "" {
+			continue
+		}
+		uri := change.TextDocument.URI
+		edits[uri] = append(edits[uri], change.Edits...)
+	}
+	return edits
+}
+
+// Completion item insert text formats
+const (
+	InsertTextPlain   = 1
+	InsertTextSnippet = 2
+)
+
+type CompletionItem struct {
+	Label               string          `json:"label"`
+	Kind                int             `json:"kind,omitempty"`
+	Detail              string          `json:"detail,omitempty"`
+	Documentation       json.RawMessage `json:"documentation,omitempty"`
+	SortText            string          `json:"sortText,omitempty"`
+	FilterText          string          `json:"filterText,omitempty"`
+	InsertText          string          `json:"insertText,omitempty"`
+	InsertTextFormat    int             `json:"insertTextFormat,omitempty"`
+	TextEdit            *TextEdit       `json:"textEdit,omitempty"`
+	AdditionalTextEdits []TextEdit      `json:"additionalTextEdits,omitempty"`
+}
+
+// UnmarshalJSON accepts an InsertReplaceEdit as the text edit, using its
+// insert range
+func (c *CompletionItem) UnmarshalJSON(data []byte) error {
+	type plain CompletionItem
+	var item struct {
+		plain
+		TextEdit *struct {
+			Range   *Range `json:"range"`
+			Insert  *Range `json:"insert"`
+			NewText string `json:"newText"`
+		} `json:"textEdit"`
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*c = CompletionItem(item.plain)
+	c.TextEdit = nil
+	if te := item.TextEdit; te != nil {
+		r := te.Range
+		if r == nil {
+			r = te.Insert
+		}
+		if r != nil {
+			c.TextEdit = &TextEdit{Range: *r, NewText: te.NewText}
+		}
+	}
+	return nil
+}
+
+// Text returns what accepting the item inserts, as a snippet when
+// InsertTextFormat is InsertTextSnippet
+func (c *CompletionItem) Text() string {
+	switch {
+	case c.TextEdit != nil:
+		return c.TextEdit.NewText
+	case c.InsertText != "":
+		return c.InsertText
+	}
+	return c.Label
+}
+
+// completionKinds names CompletionItemKind values, starting at 1
+var completionKinds = []string{
+	"text", "method", "function", "constructor", "field", "variable", "class",
+	"interface", "module", "property", "unit", "value", "enum", "keyword",
+	"snippet", "color", "file", "reference", "folder", "enum member",
+	"constant", "struct", "event", "operator", "type parameter",
+}
+
+// KindName returns the name of the item's kind, or ""
+func (c *CompletionItem) KindName() string {
+	if c.Kind < 1 || c.Kind > len(completionKinds) {
+		return ""
+	}
+	return completionKinds[c.Kind-1]
+}
+
+// markupText returns the text of hover contents or documentation, which may
+// be a string, a MarkupContent, a MarkedString or a list of MarkedStrings.
+// Markdown code fences are dropped.
+func markupText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return stripFences(s)
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			if text := markupText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	var markup struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &markup) == nil {
+		return stripFences(markup.Value)
+	}
+	return ""
+}
+
+func stripFences(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// DocumentationText returns the item's documentation as plain text
+func (c *CompletionItem) DocumentationText() string {
+	return markupText(c.Documentation)
+}
@@ -10,6 +10,7 @@ type Element struct {
 	Type     ElementType
 	Content  string
 	Level    int // For headers
+	Line     int // Source line, 0-based
 	Segments []Segment // For inline formatting
 }
 
@@ -36,19 +37,19 @@ func Parse(text string) []Element {
 	elements := make([]Element, 0)
 	inCodeBlock := false
 	
-	for _, line := range lines {
+	for i, line := range lines {
 		// Code blocks
 		if strings.HasPrefix(line, "```") {
 			inCodeBlock = !inCodeBlock
 			if !inCodeBlock {
 				continue
 			}
-			elements = append(elements, Element{Type: TypeCodeBlock, Content: ""})
+			elements = append(elements, Element{Type: TypeCodeBlock, Content: "", Line: i})
 			continue
 		}
 		
 		if inCodeBlock {
-			elements = append(elements, Element{Type: TypeCodeBlock, Content: line})
+			elements = append(elements, Element{Type: TypeCodeBlock, Content: line, Line: i})
 			continue
 		}
 		
@@ -67,6 +68,7 @@ func Parse(text string) []Element {
 			elements = append(elements, Element{
 				Type: TypeHeader, 
 				Content: content, 
+				Line: i,
 				Level: level,
 				Segments: segments,
 			})
@@ -81,6 +83,7 @@ func Parse(text string) []Element {
 			elements = append(elements, Element{
 				Type: TypeList, 
 				Content: content,
+				Line: i,
 				Segments: segments,
 			})
 			continue
@@ -93,6 +96,7 @@ func Parse(text string) []Element {
 			elements = append(elements, Element{
 				Type: TypeBlockquote, 
 				Content: content,
+				Line: i,
 				Segments: segments,
 			})
 			continue
@@ -104,6 +108,7 @@ func Parse(text string) []Element {
 			elements = append(elements, Element{
 				Type: TypeText, 
 				Content: line,
+				Line: i,
 				Segments: segments,
 			})
 		}
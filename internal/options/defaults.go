@@ -0,0 +1,57 @@
+package options
+
+// Names of the built-in options
+const (
+	TabStop      = "tabstop"
+	ShiftWidth   = "shiftwidth"
+	Wrap         = "wrap"
+	Number       = "number"
+	IgnoreCase   = "ignorecase"
+	SmartCase    = "smartcase"
+	Regex        = "regex"
+	WholeWord    = "wholeword"
+	ShowHidden   = "showhidden"
+	PreserveCase = "preservecase"
+	Filetype     = "filetype"
+	FoldMethod   = "foldmethod"
+	AutoComplete = "autocomplete"
+	ExpandTab    = "expandtab"
+	SmartIndent  = "smartindent"
+	AutoPairs    = "autopairs"
+	FormatOnSave = "formatonsave"
+	LintOnSave   = "lintonsave"
+	MakePrg      = "makeprg"
+	ErrorFormat  = "errorformat"
+	GitGutter    = "gitgutter"
+	ShowIgnored  = "showignored"
+	IndentGuides = "indentguides"
+)
+
+// NewDefault creates a registry with the built-in editor options. Options
+// whose values are checked by editor features, such as filetype and
+// errorformat, are registered by the editor itself.
+func NewDefault() *Registry {
+	r := NewRegistry()
+	r.Register(Option{Name: TabStop, Short: "ts", Kind: KindInt, Scope: ScopeBuffer, Default: 4, Min: 1})
+	r.Register(Option{Name: ShiftWidth, Short: "sw", Kind: KindInt, Scope: ScopeBuffer, Default: 2, Min: 1})
+	r.Register(Option{Name: ExpandTab, Short: "et", Kind: KindBool, Scope: ScopeBuffer, Default: false})
+	r.Register(Option{Name: SmartIndent, Short: "si", Kind: KindBool, Scope: ScopeBuffer, Default: true})
+	r.Register(Option{Name: Wrap, Kind: KindBool, Scope: ScopeBuffer, Default: true})
+	r.Register(Option{Name: Number, Short: "nu", Kind: KindBool, Scope: ScopeBuffer, Default: true})
+	r.Register(Option{Name: IgnoreCase, Short: "ic", Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: SmartCase, Short: "scs", Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: Regex, Kind: KindBool, Scope: ScopeGlobal, Default: false})
+	r.Register(Option{Name: WholeWord, Kind: KindBool, Scope: ScopeGlobal, Default: false})
+	r.Register(Option{Name: ShowHidden, Kind: KindBool, Scope: ScopeGlobal, Default: false})
+	r.Register(Option{Name: ShowIgnored, Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: IndentGuides, Short: "ig", Kind: KindBool, Scope: ScopeGlobal, Default: false})
+	r.Register(Option{Name: PreserveCase, Short: "pc", Kind: KindBool, Scope: ScopeGlobal, Default: false})
+	r.Register(Option{Name: AutoComplete, Short: "ac", Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: AutoPairs, Short: "ap", Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: FormatOnSave, Short: "fos", Kind: KindBool, Scope: ScopeBuffer, Default: true})
+	r.Register(Option{Name: LintOnSave, Short: "los", Kind: KindBool, Scope: ScopeBuffer, Default: true})
+	r.Register(Option{Name: MakePrg, Short: "mp", Kind: KindString, Scope: ScopeBuffer, Default: ""})
+	r.Register(Option{Name: GitGutter, Short: "gg", Kind: KindBool, Scope: ScopeGlobal, Default: true})
+	r.Register(Option{Name: FoldMethod, Short: "fdm", Kind: KindString, Scope: ScopeBuffer, Default: "indent", Choices: []string{"indent", "bracket"}})
+	return r
+}
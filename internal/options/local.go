@@ -0,0 +1,95 @@
+package options
+
+import "fmt"
+
+// Local holds buffer-local overrides on top of a registry's global values
+type Local struct {
+	reg    *Registry
+	values map[string]any
+}
+
+// NewLocal creates an empty buffer-local scope
+func (r *Registry) NewLocal() *Local {
+	return &Local{
+		reg:    r,
+		values: make(map[string]any),
+	}
+}
+
+// Registry returns the registry this scope belongs to
+func (l *Local) Registry() *Registry {
+	return l.reg
+}
+
+// Get returns the local value if set, otherwise the global value
+func (l *Local) Get(name string) any {
+	opt, ok := l.reg.Lookup(name)
+	if !ok {
+		return nil
+	}
+	if v, ok := l.values[opt.Name]; ok {
+		return v
+	}
+	return l.reg.values[opt.Name]
+}
+
+// Bool returns the effective value of a boolean option
+func (l *Local) Bool(name string) bool {
+	v, _ := l.Get(name).(bool)
+	return v
+}
+
+// Int returns the effective value of an integer option
+func (l *Local) Int(name string) int {
+	v, _ := l.Get(name).(int)
+	return v
+}
+
+// String returns the effective value of a string option
+func (l *Local) String(name string) string {
+	v, _ := l.Get(name).(string)
+	return v
+}
+
+// IsSet reports whether the option has a buffer-local value
+func (l *Local) IsSet(name string) bool {
+	opt, ok := l.reg.Lookup(name)
+	if !ok {
+		return false
+	}
+	_, ok = l.values[opt.Name]
+	return ok
+}
+
+// Set stores a buffer-local value
+func (l *Local) Set(name string, value any) error {
+	opt, ok := l.reg.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	if opt.Scope != ScopeBuffer {
+		return fmt.Errorf("%s is a global option", opt.Name)
+	}
+	if err := opt.validate(value); err != nil {
+		return err
+	}
+	if old, ok := l.values[opt.Name]; ok && old == value {
+		return nil
+	}
+	l.values[opt.Name] = value
+	l.reg.notify(Change{Name: opt.Name, Value: value, Local: l})
+	return nil
+}
+
+// Unset removes the buffer-local value so the global value applies again
+func (l *Local) Unset(name string) {
+	opt, ok := l.reg.Lookup(name)
+	if !ok {
+		return
+	}
+	if _, ok := l.values[opt.Name]; !ok {
+		return
+	}
+	delete(l.values, opt.Name)
+	l.reg.notify(Change{Name: opt.Name, Value: l.reg.values[opt.Name], Local: l})
+}
@@ -0,0 +1,193 @@
+package options
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind is the value type of an option
+type Kind int
+
+const (
+	KindBool Kind = iota
+	KindInt
+	KindString
+)
+
+// Scope controls whether an option can hold a buffer-local value
+type Scope int
+
+const (
+	// ScopeGlobal options have a single editor-wide value
+	ScopeGlobal Scope = iota
+	// ScopeBuffer options have a global default that buffers may override
+	ScopeBuffer
+)
+
+// Option describes a single setting
+type Option struct {
+	Name    string
+	Short   string
+	Kind    Kind
+	Scope   Scope
+	Default any
+	Min     int                   // Minimum value for KindInt options
+	Choices []string              // Allowed values for KindString options (empty means any)
+	Check   func(value any) error // Optional extra validation
+}
+
+// Change describes an option value update
+type Change struct {
+	Name  string
+	Value any
+	Local *Local // Non-nil when only a buffer-local value changed
+}
+
+// Registry holds option definitions and their global values
+type Registry struct {
+	defs      map[string]*Option
+	aliases   map[string]string
+	values    map[string]any
+	listeners []func(Change)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		defs:    make(map[string]*Option),
+		aliases: make(map[string]string),
+		values:  make(map[string]any),
+	}
+}
+
+// Register adds an option definition and sets its default value
+func (r *Registry) Register(opt Option) {
+	def := opt
+	r.defs[def.Name] = &def
+	r.aliases[def.Name] = def.Name
+	if def.Short != "" {
+		r.aliases[def.Short] = def.Name
+	}
+	r.values[def.Name] = def.Default
+}
+
+// Lookup returns the definition for a name or short alias
+func (r *Registry) Lookup(name string) (*Option, bool) {
+	full, ok := r.aliases[name]
+	if !ok {
+		return nil, false
+	}
+	return r.defs[full], true
+}
+
+// OnChange registers a listener that is called after every value change
+func (r *Registry) OnChange(fn func(Change)) {
+	r.listeners = append(r.listeners, fn)
+}
+
+func (r *Registry) notify(c Change) {
+	for _, fn := range r.listeners {
+		fn(c)
+	}
+}
+
+// Get returns the global value of an option
+func (r *Registry) Get(name string) any {
+	opt, ok := r.Lookup(name)
+	if !ok {
+		return nil
+	}
+	return r.values[opt.Name]
+}
+
+// Bool returns the global value of a boolean option
+func (r *Registry) Bool(name string) bool {
+	v, _ := r.Get(name).(bool)
+	return v
+}
+
+// Int returns the global value of an integer option
+func (r *Registry) Int(name string) int {
+	v, _ := r.Get(name).(int)
+	return v
+}
+
+// String returns the global value of a string option
+func (r *Registry) String(name string) string {
+	v, _ := r.Get(name).(string)
+	return v
+}
+
+// Set validates and stores a global value
+func (r *Registry) Set(name string, value any) error {
+	opt, ok := r.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	if err := opt.validate(value); err != nil {
+		return err
+	}
+	if r.values[opt.Name] == value {
+		return nil
+	}
+	r.values[opt.Name] = value
+	r.notify(Change{Name: opt.Name, Value: value})
+	return nil
+}
+
+// Reset restores the default global value
+func (r *Registry) Reset(name string) error {
+	opt, ok := r.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	return r.Set(opt.Name, opt.Default)
+}
+
+// Names returns all option names in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.defs))
+	for name := range r.defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (o *Option) validate(value any) error {
+	switch o.Kind {
+	case KindBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s is a boolean option", o.Name)
+		}
+	case KindInt:
+		n, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("%s expects a number", o.Name)
+		}
+		if n < o.Min {
+			return fmt.Errorf("%s must be at least %d", o.Name, o.Min)
+		}
+	case KindString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s expects a string", o.Name)
+		}
+		if len(o.Choices) > 0 && !contains(o.Choices, s) {
+			return fmt.Errorf("invalid value for %s: %s", o.Name, s)
+		}
+	}
+	if o.Check != nil {
+		return o.Check(value)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Apply executes the arguments of a :set or :setlocal command.
+// It returns a message to show (e.g. for "opt?" queries).
+func (r *Registry) Apply(args string, local *Local, localOnly bool) (string, error) {
+	fields := splitFields(args)
+	if len(fields) == 0 {
+		return r.describeChanged(local), nil
+	}
+
+	var shown []string
+	for _, field := range fields {
+		msg, err := r.applyOne(field, local, localOnly)
+		if err != nil {
+			return strings.Join(shown, " "), err
+		}
+		if msg != "" {
+			shown = append(shown, msg)
+		}
+	}
+	return strings.Join(shown, " "), nil
+}
+
+// splitFields splits arguments at whitespace, except where a backslash
+// escapes it, as in makeprg=go\ build
+func splitFields(args string) []string {
+	var fields []string
+	var cur strings.Builder
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == '\\' && i+1 < len(args) && (args[i+1] == ' ' || args[i+1] == '\t'):
+			cur.WriteByte(args[i+1])
+			i++
+		case c == ' ' || c == '\t':
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+func (r *Registry) applyOne(field string, local *Local, localOnly bool) (string, error) {
+	if localOnly && local == nil {
+		return "", fmt.Errorf("no buffer for :setlocal")
+	}
+
+	// opt=val, opt:val, opt+=n, opt-=n
+	if idx := strings.IndexAny(field, "=:"); idx > 0 {
+		name, value := field[:idx], field[idx+1:]
+		op := byte(0)
+		if strings.HasSuffix(name, "+") || strings.HasSuffix(name, "-") {
+			op = name[len(name)-1]
+			name = name[:len(name)-1]
+		}
+		opt, ok := r.Lookup(name)
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		parsed, err := opt.parse(value)
+		if err != nil {
+			return "", err
+		}
+		if op != 0 {
+			if opt.Kind != KindInt {
+				return "", fmt.Errorf("%s is not a number option", opt.Name)
+			}
+			cur := r.effective(opt, local).(int)
+			if op == '+' {
+				parsed = cur + parsed.(int)
+			} else {
+				parsed = cur - parsed.(int)
+			}
+		}
+		return "", r.store(opt, parsed, local, localOnly)
+	}
+
+	// opt? shows the current value
+	if strings.HasSuffix(field, "?") {
+		opt, ok := r.Lookup(strings.TrimSuffix(field, "?"))
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", strings.TrimSuffix(field, "?"))
+		}
+		return opt.format(r.effective(opt, local)), nil
+	}
+
+	// opt& resets to the default
+	if strings.HasSuffix(field, "&") {
+		opt, ok := r.Lookup(strings.TrimSuffix(field, "&"))
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", strings.TrimSuffix(field, "&"))
+		}
+		if localOnly {
+			local.Unset(opt.Name)
+			return "", nil
+		}
+		return "", r.store(opt, opt.Default, local, false)
+	}
+
+	// opt! and invopt toggle a boolean
+	toggle := false
+	name := field
+	if strings.HasSuffix(name, "!") {
+		toggle = true
+		name = strings.TrimSuffix(name, "!")
+	} else if _, ok := r.Lookup(name); !ok && strings.HasPrefix(name, "inv") {
+		toggle = true
+		name = strings.TrimPrefix(name, "inv")
+	}
+	if toggle {
+		opt, ok := r.Lookup(name)
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		if opt.Kind != KindBool {
+			return "", fmt.Errorf("%s is not a boolean option", opt.Name)
+		}
+		return "", r.store(opt, !r.effective(opt, local).(bool), local, localOnly)
+	}
+
+	// noopt clears a boolean
+	if opt, ok := r.Lookup(name); ok {
+		if opt.Kind != KindBool {
+			// Like vi, naming a non-boolean option shows its value
+			return opt.format(r.effective(opt, local)), nil
+		}
+		return "", r.store(opt, true, local, localOnly)
+	}
+	if strings.HasPrefix(name, "no") {
+		opt, ok := r.Lookup(strings.TrimPrefix(name, "no"))
+		if ok && opt.Kind == KindBool {
+			return "", r.store(opt, false, local, localOnly)
+		}
+	}
+	return "", fmt.Errorf("unknown option: %s", name)
+}
+
+// store writes a value to the right scope. Plain :set on a buffer option
+// updates the global value and the current buffer's override, if any.
+func (r *Registry) store(opt *Option, value any, local *Local, localOnly bool) error {
+	if localOnly {
+		return local.Set(opt.Name, value)
+	}
+	if err := r.Set(opt.Name, value); err != nil {
+		return err
+	}
+	if local != nil && local.IsSet(opt.Name) {
+		return local.Set(opt.Name, value)
+	}
+	return nil
+}
+
+func (r *Registry) effective(opt *Option, local *Local) any {
+	if local != nil {
+		return local.Get(opt.Name)
+	}
+	return r.values[opt.Name]
+}
+
+func (r *Registry) describeChanged(local *Local) string {
+	var parts []string
+	for _, name := range r.Names() {
+		opt := r.defs[name]
+		value := r.effective(opt, local)
+		if value != opt.Default {
+			parts = append(parts, opt.format(value))
+		}
+	}
+	if len(parts) == 0 {
+		return "All options at default values"
+	}
+	return strings.Join(parts, " ")
+}
+
+func (o *Option) parse(value string) (any, error) {
+	switch o.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a number: %s", o.Name, value)
+		}
+		return n, nil
+	case KindString:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("%s is a boolean option", o.Name)
+	}
+}
+
+func (o *Option) format(value any) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return o.Name
+		}
+		return "no" + o.Name
+	default:
+		return fmt.Sprintf("%s=%v", o.Name, v)
+	}
+}
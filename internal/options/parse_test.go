@@ -0,0 +1,143 @@
+package options
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"nu", []string{"nu"}},
+		{"nu  ts=8\tsw=2", []string{"nu", "ts=8", "sw=2"}},
+		{`makeprg=go\ build\ ./...`, []string{"makeprg=go build ./..."}},
+		{`mp=a\	b nu`, []string{"mp=a\tb", "nu"}},
+		{`efm=%f\:%l`, []string{`efm=%f\:%l`}},
+		{`mp=a\`, []string{`mp=a\`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			if got := splitFields(tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("splitFields(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestRegistry returns the default options plus a checked, a string
+// and a choice option
+func newTestRegistry() *Registry {
+	r := NewDefault()
+	r.Register(Option{Name: "program", Short: "prg", Kind: KindString, Scope: ScopeBuffer, Default: ""})
+	r.Register(Option{Name: "method", Short: "mth", Kind: KindString, Scope: ScopeBuffer, Default: "indent", Choices: []string{"indent", "bracket"}})
+	r.Register(Option{Name: "even", Kind: KindInt, Scope: ScopeBuffer, Default: 2, Check: func(value any) error {
+		if value.(int)%2 != 0 {
+			return fmt.Errorf("even must be even")
+		}
+		return nil
+	}})
+	return r
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		localOnly bool
+		want      string         // message
+		wantErr   string         // error, "" for none
+		global    map[string]any // global values afterwards
+		local     map[string]any // buffer values afterwards
+	}{
+		{name: "bool on", args: "wrap", global: map[string]any{Wrap: true}},
+		{name: "bool off", args: "nowrap", global: map[string]any{Wrap: false}, local: map[string]any{Wrap: false}},
+		{name: "short name", args: "nonu", global: map[string]any{Number: false}},
+		{name: "toggle", args: "wrap!", global: map[string]any{Wrap: false}},
+		{name: "inv", args: "invic", global: map[string]any{IgnoreCase: false}},
+		{name: "int", args: "ts=8", global: map[string]any{TabStop: 8}},
+		{name: "colon", args: "ts:3", global: map[string]any{TabStop: 3}},
+		{name: "add", args: "sw+=2", global: map[string]any{ShiftWidth: 4}},
+		{name: "subtract", args: "ts-=1", global: map[string]any{TabStop: 3}},
+		{name: "string", args: `prg=go\ build`, global: map[string]any{"program": "go build"}},
+		{name: "several", args: "nowrap ts=2 sw=4", global: map[string]any{Wrap: false, TabStop: 2, ShiftWidth: 4}},
+		{name: "show bool", args: "wrap?", want: "wrap"},
+		{name: "show int", args: "ts?", want: "tabstop=4"},
+		{name: "name of int shows it", args: "ts", want: "tabstop=4"},
+		{name: "show several", args: "ts? nowrap wrap?", want: "tabstop=4 nowrap"},
+		{name: "changed", args: "", want: "All options at default values"},
+		{name: "reset", args: "ts=8 ts&", global: map[string]any{TabStop: 4}},
+		{name: "choice", args: "mth=bracket", global: map[string]any{"method": "bracket"}},
+		{name: "local", args: "ts=8", localOnly: true, global: map[string]any{TabStop: 4}, local: map[string]any{TabStop: 8}},
+		{name: "local reset", args: "ts=8 ts&", localOnly: true, local: map[string]any{TabStop: 4}},
+		{name: "unknown", args: "bogus", wantErr: "unknown option: bogus"},
+		{name: "unknown value", args: "bogus=1", wantErr: "unknown option: bogus"},
+		{name: "not a number", args: "ts=x", wantErr: "tabstop expects a number: x"},
+		{name: "below min", args: "ts=0", wantErr: "tabstop must be at least 1"},
+		{name: "not a choice", args: "mth=syntax", wantErr: "invalid value for method: syntax"},
+		{name: "check", args: "even=3", wantErr: "even must be even"},
+		{name: "toggle int", args: "ts!", wantErr: "tabstop is not a boolean option"},
+		{name: "add to bool", args: "wrap+=1", wantErr: "wrap is a boolean option"},
+		{name: "add to string", args: "prg+=x", wantErr: "program is not a number option"},
+		{name: "local global", args: "ic", localOnly: true, wantErr: "ignorecase is a global option"},
+		{name: "stops at error", args: "ts=8 bogus sw=8", wantErr: "unknown option: bogus", global: map[string]any{TabStop: 8, ShiftWidth: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry()
+			local := r.NewLocal()
+			msg, err := r.Apply(tt.args, local, tt.localOnly)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Apply(%q) error: %v", tt.args, err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("Apply(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			if err == nil && msg != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.args, msg, tt.want)
+			}
+			for name, want := range tt.global {
+				if got := r.Get(name); got != want {
+					t.Errorf("global %s = %v, want %v", name, got, want)
+				}
+			}
+			for name, want := range tt.local {
+				if got := local.Get(name); got != want {
+					t.Errorf("local %s = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesBufferOverride(t *testing.T) {
+	r := newTestRegistry()
+	local, other := r.NewLocal(), r.NewLocal()
+	if _, err := r.Apply("ts=8", local, true); err != nil {
+		t.Fatal(err)
+	}
+	// Plain :set changes the global value and this buffer's own value, but
+	// not other buffers' overrides
+	if _, err := r.Apply("ts=2", local, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := local.Int(TabStop); got != 2 {
+		t.Errorf("local tabstop = %d, want 2", got)
+	}
+	if got := r.Int(TabStop); got != 2 {
+		t.Errorf("global tabstop = %d, want 2", got)
+	}
+	if _, err := r.Apply("ts=6", other, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Apply("ts=3", local, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.Int(TabStop); got != 6 {
+		t.Errorf("other buffer's tabstop = %d, want 6", got)
+	}
+}
@@ -0,0 +1,182 @@
+package outline
+
+import (
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/markdown"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// Source is a buffer an outline is built from
+type Source interface {
+	LineCount() int
+	Line(n int) string
+	Tokens(n int) []highlight.Token
+}
+
+// Outline holds the symbols of one buffer. Symbols are kept per line, so an
+// update only extracts them again from lines whose tokens changed.
+type Outline struct {
+	language string
+	rules    *rules
+	markdown bool
+	lines    []entry
+	symbols  []Symbol
+}
+
+// entry is the symbols declared on one line
+type entry struct {
+	tokens  []highlight.Token // tokens the symbols were extracted from
+	symbols []Symbol
+	indent  int
+	stale   bool
+}
+
+// New creates an outline for a language as named by highlight.FindLanguage
+func New(language string) *Outline {
+	md, _ := highlight.FindLanguage("markdown")
+	return &Outline{language: language, rules: rulesFor(language), markdown: language != "" && language == md}
+}
+
+// Language returns the language the outline was created for
+func (o *Outline) Language() string {
+	return o.language
+}
+
+// Symbols returns the symbols in buffer order
+func (o *Outline) Symbols() []Symbol {
+	return o.symbols
+}
+
+// Apply moves the per-line symbols for an edit to line that inserted delta
+// lines after it, or removed them when delta is negative
+func (o *Outline) Apply(line, delta int) {
+	if line < 0 || line >= len(o.lines) {
+		return
+	}
+	after := line + 1
+	switch {
+	case delta > 0:
+		inserted := make([]entry, delta)
+		for i := range inserted {
+			inserted[i].stale = true
+		}
+		o.lines = append(o.lines[:after], append(inserted, o.lines[after:]...)...)
+	case delta < 0:
+		end := min(after-delta, len(o.lines))
+		o.lines = append(o.lines[:after], o.lines[end:]...)
+	}
+	o.lines[line] = entry{stale: true}
+}
+
+// Update extracts the symbols of lines that changed since the last update
+// and reports whether the outline changed
+func (o *Outline) Update(src Source) bool {
+	if o.markdown {
+		o.symbols = headings(src)
+		return true
+	}
+
+	count := src.LineCount()
+	if len(o.lines) > count {
+		o.lines = o.lines[:count]
+	}
+	for len(o.lines) < count {
+		o.lines = append(o.lines, entry{stale: true})
+	}
+	changed := false
+	for n := range o.lines {
+		tokens := src.Tokens(n)
+		e := &o.lines[n]
+		if !e.stale && sameTokens(e.tokens, tokens) {
+			continue
+		}
+		*e = entry{tokens: tokens, symbols: o.rules.extract(tokens), indent: indent(tokens)}
+		changed = true
+	}
+	if changed {
+		o.nest()
+	}
+	return changed
+}
+
+// Extract lexes lines as language and returns their symbols. It leaves the
+// tokens unstyled, so the index workers can call it in parallel.
+func Extract(language string, lines []string) []Symbol {
+	src := &lexed{lines: lines}
+	highlight.NewForLanguage(language).LexTokens(lines, func(l highlight.Line) bool {
+		src.tokens = append(src.tokens, l.Tokens)
+		return true
+	})
+	o := New(language)
+	o.Update(src)
+	return o.Symbols()
+}
+
+// lexed is a Source for lines lexed up front
+type lexed struct {
+	lines  []string
+	tokens [][]highlight.Token
+}
+
+func (s *lexed) LineCount() int                 { return len(s.lines) }
+func (s *lexed) Line(n int) string              { return s.lines[n] }
+func (s *lexed) Tokens(n int) []highlight.Token { return s.tokens[n] }
+
+// nest lists the symbols with their nesting, found from indentation. A
+// function nested in a type is a method.
+func (o *Outline) nest() {
+	type scope struct {
+		indent int
+		kind   Kind
+	}
+	var stack []scope
+	o.symbols = nil
+	for line, e := range o.lines {
+		for _, s := range e.symbols {
+			for len(stack) > 0 && stack[len(stack)-1].indent >= e.indent {
+				stack = stack[:len(stack)-1]
+			}
+			inType := len(stack) > 0 && stack[len(stack)-1].kind == Type
+			if s.member && !inType {
+				continue
+			}
+			if s.Kind == Function && inType {
+				s.Kind = Method
+			}
+			s.Line = line
+			s.Depth = len(stack)
+			o.symbols = append(o.symbols, s)
+			stack = append(stack, scope{e.indent, s.Kind})
+		}
+	}
+}
+
+// headings lists the headings of a markdown buffer
+func headings(src Source) []Symbol {
+	lines := make([]string, src.LineCount())
+	for i := range lines {
+		lines[i] = src.Line(i)
+	}
+	var symbols []Symbol
+	for _, el := range markdown.Parse(strings.Join(lines, "\n")) {
+		if el.Type != markdown.TypeHeader || el.Content == "" {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Name:  strings.TrimSpace(strings.TrimRight(el.Content, "#")),
+			Kind:  Heading,
+			Line:  el.Line,
+			Depth: max(el.Level-1, 0),
+		})
+	}
+	return symbols
+}
+
+// sameTokens reports whether two token slices are the same lexed line
+func sameTokens(a, b []highlight.Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,279 @@
+package outline
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+	"github.com/alecthomas/chroma/v2"
+)
+
+type Kind int
+
+const (
+	Function Kind = iota
+	Method
+	Type
+	Heading
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Function:
+		return "func"
+	case Method:
+		return "method"
+	case Type:
+		return "type"
+	case Heading:
+		return "heading"
+	}
+	return ""
+}
+
+// Symbol is a declaration or heading in a buffer
+type Symbol struct {
+	Name  string
+	Kind  Kind
+	Line  int // 0-based
+	Col   int // rune column of the name
+	Depth int // nesting level
+
+	member bool // only a symbol when inside a type
+}
+
+// parens says where a name followed by parentheses, on a line ending in {,
+// declares a function
+type parens int
+
+const (
+	parensNone parens = iota
+	parensAnywhere
+	parensInType
+)
+
+// rules describes how a language declares symbols
+type rules struct {
+	keywords   map[string]Kind // keywords followed by the name they declare
+	leading    bool            // declaring keywords start their line
+	receiver   bool            // a (receiver) between a function keyword and its name makes it a method
+	names      bool            // NameFunction and NameClass tokens outside expressions are declarations
+	statements bool            // lines ending in ; are statements, not declarations
+	parens     parens
+}
+
+var (
+	cLike = rules{
+		keywords:   map[string]Kind{"struct": Type, "union": Type, "enum": Type, "class": Type, "interface": Type, "namespace": Type},
+		names:      true,
+		statements: true,
+	}
+	jsLike = rules{
+		keywords: map[string]Kind{"function": Function, "class": Type, "interface": Type, "enum": Type},
+		parens:   parensInType,
+	}
+	shell = rules{
+		keywords: map[string]Kind{"function": Function},
+		parens:   parensAnywhere,
+	}
+	generic = rules{
+		keywords: map[string]Kind{
+			"func": Function, "function": Function, "fn": Function, "def": Function, "defp": Function,
+			"sub": Function, "proc": Function, "procedure": Function,
+			"class": Type, "struct": Type, "interface": Type, "enum": Type, "trait": Type,
+			"module": Type, "defmodule": Type, "namespace": Type, "package": Type,
+		},
+	}
+)
+
+// languages maps language names, as returned by highlight.FindLanguage, to
+// their rules. Other languages use generic.
+var languages = map[string]*rules{
+	"go": {
+		keywords: map[string]Kind{"func": Function, "type": Type},
+		leading:  true,
+		receiver: true,
+	},
+	"rust": {
+		keywords: map[string]Kind{"fn": Function, "struct": Type, "enum": Type, "union": Type, "trait": Type, "type": Type, "impl": Type, "mod": Type},
+	},
+	"python": {names: true},
+	"ruby": {
+		keywords: map[string]Kind{"module": Type},
+		names:    true,
+	},
+	"c":           &cLike,
+	"c++":         &cLike,
+	"c#":          &cLike,
+	"java":        &cLike,
+	"php":         &cLike,
+	"objective-c": &cLike,
+	"javascript":  &jsLike,
+	"typescript":  &jsLike,
+	"react":       &jsLike,
+	"bash":        &shell,
+}
+
+// rulesFor returns the rules of a language
+func rulesFor(language string) *rules {
+	if r, ok := languages[language]; ok {
+		return r
+	}
+	return &generic
+}
+
+// extract finds the symbols declared on a line. Their Line and Depth are
+// left for the caller to fill in.
+func (r *rules) extract(tokens []highlight.Token) []Symbol {
+	toks := significant(tokens)
+	if len(toks) == 0 {
+		return nil
+	}
+	if r.statements && strings.HasSuffix(strings.TrimSpace(toks[len(toks)-1].Text), ";") {
+		return nil
+	}
+
+	var symbols []Symbol
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if kind, ok := r.keywords[strings.TrimSpace(t.Text)]; ok && t.Type.InCategory(chroma.Keyword) {
+			if r.leading && i > 0 {
+				continue
+			}
+			j := i + 1
+			if r.receiver && kind == Function && j < len(toks) && strings.HasPrefix(toks[j].Text, "(") {
+				j = closingParen(toks, j) + 1
+				kind = Method
+			}
+			if s, end, ok := nameAt(toks, j, kind); ok {
+				symbols = append(symbols, s)
+				i = end
+			}
+			continue
+		}
+		if r.names && (t.Type == chroma.NameFunction || t.Type == chroma.NameClass) && !inExpression(toks[:i]) {
+			s, end, ok := nameAt(toks, i, Function)
+			if !ok {
+				continue
+			}
+			if toks[end].Type == chroma.NameClass {
+				s.Kind = Type
+			}
+			symbols = append(symbols, s)
+			i = end
+		}
+	}
+	if len(symbols) == 0 && r.parens != parensNone {
+		if s, ok := parenDecl(toks); ok {
+			s.member = r.parens == parensInType
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// significant drops comments
+func significant(tokens []highlight.Token) []highlight.Token {
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if !t.Type.InCategory(chroma.Comment) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// closingParen returns the index of the token closing the parenthesis
+// opened at toks[i], or the last index if it isn't closed on the line
+func closingParen(toks []highlight.Token, i int) int {
+	depth := 0
+	for ; i < len(toks); i++ {
+		depth += strings.Count(toks[i].Text, "(") - strings.Count(toks[i].Text, ")")
+		if depth <= 0 {
+			return i
+		}
+	}
+	return len(toks) - 1
+}
+
+// nameAt returns a symbol for the name starting at toks[i] and the index of
+// its last token. Qualified names such as M.f are joined.
+func nameAt(toks []highlight.Token, i int, kind Kind) (Symbol, int, bool) {
+	if i >= len(toks) || !isName(toks[i]) {
+		return Symbol{}, i, false
+	}
+	name, offset, _ := identifier(toks[i].Text)
+	if name == "" {
+		return Symbol{}, i, false
+	}
+	s := Symbol{Name: name, Kind: kind, Col: toks[i].Col + offset}
+	for i+2 < len(toks) && strings.Trim(toks[i+1].Text, ".:") == "" && isName(toks[i+2]) {
+		part, _, _ := identifier(toks[i+2].Text)
+		if part == "" {
+			break
+		}
+		s.Name += toks[i+1].Text + part
+		i += 2
+	}
+	return s, i, true
+}
+
+func isName(t highlight.Token) bool {
+	return t.Type.InCategory(chroma.Name) || t.Type == chroma.Text
+}
+
+// inExpression reports whether the tokens before a name make it part of an
+// expression, such as a call, rather than a declaration
+func inExpression(before []highlight.Token) bool {
+	for _, t := range before {
+		if (t.Type.InCategory(chroma.Punctuation) || t.Type.InCategory(chroma.Operator)) &&
+			strings.ContainsAny(t.Text, "(=.,") {
+			return true
+		}
+	}
+	return false
+}
+
+// parenDecl matches `name(...) {`, with any keywords before the name
+func parenDecl(toks []highlight.Token) (Symbol, bool) {
+	if !strings.HasSuffix(strings.TrimSpace(toks[len(toks)-1].Text), "{") {
+		return Symbol{}, false
+	}
+	i := 0
+	for i < len(toks) && toks[i].Type.InCategory(chroma.Keyword) {
+		i++
+	}
+	s, end, ok := nameAt(toks, i, Function)
+	if !ok {
+		return Symbol{}, false
+	}
+	_, _, rest := identifier(toks[end].Text)
+	rest = strings.TrimSpace(rest)
+	if rest == "" && end+1 < len(toks) {
+		rest = strings.TrimSpace(toks[end+1].Text)
+	}
+	return s, strings.HasPrefix(rest, "(")
+}
+
+// identifier returns the identifier at the start of text after any spaces,
+// its rune offset in text and the text after it
+func identifier(text string) (name string, offset int, rest string) {
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	offset = len([]rune(text)) - len([]rune(trimmed))
+	end := strings.IndexFunc(trimmed, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_$.:?!", r)
+	})
+	if end < 0 {
+		end = len(trimmed)
+	}
+	name = strings.TrimRight(trimmed[:end], ".:")
+	return name, offset, trimmed[len(name):]
+}
+
+// indent returns the column of a line's first token
+func indent(tokens []highlight.Token) int {
+	if len(tokens) == 0 {
+		return 0
+	}
+	return tokens[0].Col
+}
@@ -0,0 +1,232 @@
+package outline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Panel is the outline sidebar. It shows the symbols of the active buffer
+// and follows the cursor while it isn't focused.
+type Panel struct {
+	Open    bool
+	Width   int
+	Focused bool
+	Message string // shown instead of the symbols, e.g. when there are none
+
+	symbols  []Symbol
+	current  int // symbol containing the cursor
+	selected int
+	scroll   int
+	rows     int
+}
+
+// Action is what the editor should do after a key or click
+type Action struct {
+	Jump  *Symbol
+	Close bool
+}
+
+func NewPanel() *Panel {
+	return &Panel{Width: 30, current: -1}
+}
+
+// SetSymbols replaces the symbols shown
+func (p *Panel) SetSymbols(symbols []Symbol) {
+	p.symbols = symbols
+	if p.selected >= len(symbols) {
+		p.selected = max(len(symbols)-1, 0)
+	}
+}
+
+// SetCursor marks the symbol containing line as current, and selects it
+// unless the panel has focus
+func (p *Panel) SetCursor(line int) {
+	p.current = -1
+	for i, s := range p.symbols {
+		if s.Line > line {
+			break
+		}
+		p.current = i
+	}
+	if !p.Focused && p.current >= 0 {
+		p.selected = p.current
+		p.reveal()
+	}
+}
+
+// Render draws the panel with a title row at x, y
+func (p *Panel) Render(term *terminal.Terminal, x, y, width, height int) {
+	if !p.Open || term == nil || width <= 0 || height <= 0 {
+		return
+	}
+
+	titleStyle := tcell.StyleDefault.Reverse(true)
+	if p.Focused {
+		titleStyle = titleStyle.Bold(true)
+	}
+	term.DrawText(x, y, fit(fmt.Sprintf(" Outline (%d)", len(p.symbols)), width), titleStyle)
+
+	p.rows = height - 1
+	p.clampScroll()
+	for row := 0; row < p.rows; row++ {
+		idx := p.scroll + row
+		style := tcell.StyleDefault
+		label := ""
+		switch {
+		case len(p.symbols) == 0 && row == 0:
+			label = " " + p.Message
+		case idx < len(p.symbols):
+			label = p.label(idx)
+			if idx == p.selected && (p.Focused || idx == p.current) {
+				style = style.Reverse(true)
+				if p.Focused {
+					style = style.Bold(true)
+				}
+			}
+		}
+		term.DrawText(x, y+1+row, fit(label, width), style)
+	}
+}
+
+// label formats a symbol as an indented row
+func (p *Panel) label(idx int) string {
+	s := p.symbols[idx]
+	marker := "  "
+	if idx == p.current {
+		marker = "> "
+	}
+	kind := ""
+	switch s.Kind {
+	case Function:
+		kind = "f "
+	case Method:
+		kind = "m "
+	case Type:
+		kind = "t "
+	}
+	return marker + strings.Repeat("  ", s.Depth) + kind + s.Name
+}
+
+// HandleKey handles keys while the panel has focus. Enter jumps to the
+// selected symbol and returns to the buffer; space jumps and stays.
+func (p *Panel) HandleKey(ev *terminal.Event) Action {
+	if p == nil || !p.Open || ev == nil {
+		return Action{}
+	}
+	switch ev.Key {
+	case tcell.KeyEscape:
+		p.Focused = false
+		return Action{}
+	case tcell.KeyUp:
+		p.move(-1)
+		return Action{}
+	case tcell.KeyDown:
+		p.move(1)
+		return Action{}
+	case tcell.KeyPgUp:
+		p.move(-p.rows)
+		return Action{}
+	case tcell.KeyPgDn:
+		p.move(p.rows)
+		return Action{}
+	case tcell.KeyEnter:
+		if s := p.selection(); s != nil {
+			p.Focused = false
+			return Action{Jump: s}
+		}
+		return Action{}
+	}
+	switch ev.Rune {
+	case 'k':
+		p.move(-1)
+	case 'j':
+		p.move(1)
+	case 'g':
+		p.move(-len(p.symbols))
+	case 'G':
+		p.move(len(p.symbols))
+	case ' ':
+		return Action{Jump: p.selection()}
+	case 'q':
+		p.Open = false
+		p.Focused = false
+		return Action{Close: true}
+	}
+	return Action{}
+}
+
+// HandleMouse jumps to the clicked symbol and scrolls with the wheel
+func (p *Panel) HandleMouse(ev *terminal.Event, x, y, width, height int) Action {
+	if p == nil || !p.Open || ev == nil {
+		return Action{}
+	}
+	switch ev.Button {
+	case tcell.WheelUp:
+		p.scroll--
+		p.clampScroll()
+	case tcell.WheelDown:
+		p.scroll++
+		p.clampScroll()
+	case tcell.Button1:
+		idx := p.scroll + ev.MouseY - y - 1
+		if ev.MouseY == y || idx < 0 || idx >= len(p.symbols) {
+			return Action{}
+		}
+		p.selected = idx
+		return Action{Jump: p.selection()}
+	}
+	return Action{}
+}
+
+func (p *Panel) selection() *Symbol {
+	if p.selected < 0 || p.selected >= len(p.symbols) {
+		return nil
+	}
+	s := p.symbols[p.selected]
+	return &s
+}
+
+func (p *Panel) move(delta int) {
+	p.selected += delta
+	if p.selected >= len(p.symbols) {
+		p.selected = len(p.symbols) - 1
+	}
+	if p.selected < 0 {
+		p.selected = 0
+	}
+	p.reveal()
+}
+
+// reveal scrolls so the selected symbol is visible
+func (p *Panel) reveal() {
+	if p.selected < p.scroll {
+		p.scroll = p.selected
+	}
+	if p.rows > 0 && p.selected >= p.scroll+p.rows {
+		p.scroll = p.selected - p.rows + 1
+	}
+}
+
+func (p *Panel) clampScroll() {
+	maxScroll := len(p.symbols) - p.rows
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if p.scroll > maxScroll {
+		p.scroll = maxScroll
+	}
+	if p.scroll < 0 {
+		p.scroll = 0
+	}
+}
+
+func fit(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
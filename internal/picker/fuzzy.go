@@ -0,0 +1,102 @@
+package picker
+
+import (
+	"unicode"
+)
+
+// Scores used to rank matches. Matches at word starts and runs of
+// consecutive characters rank above scattered ones.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 10
+	bonusConsecutive = 8
+	bonusFirst       = 6
+	penaltyGap       = 1
+)
+
+// Match fuzzy-matches pattern against text: every rune of pattern must occur
+// in text in order. It ignores case unless pattern has an uppercase letter,
+// and returns a score and the rune positions matched.
+func Match(pattern, text []rune) (score int, positions []int, ok bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	fold := !hasUpper(pattern)
+	same := func(p, t rune) bool {
+		if fold {
+			t = unicode.ToLower(t)
+		}
+		return p == t
+	}
+
+	// Find the first end of a match, then walk back from it to the latest
+	// start, which gives the shortest match ending there
+	pi := 0
+	end := -1
+	for i, r := range text {
+		if same(pattern[pi], r) {
+			if pi++; pi == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	start := end
+	for pi = len(pattern) - 1; start >= 0; start-- {
+		if same(pattern[pi], text[start]) {
+			if pi--; pi < 0 {
+				break
+			}
+		}
+	}
+
+	positions = make([]int, 0, len(pattern))
+	pi = 0
+	for i := start; i <= end && pi < len(pattern); i++ {
+		if !same(pattern[pi], text[i]) {
+			continue
+		}
+		score += scoreMatch
+		if boundary(text, i) {
+			score += bonusBoundary
+		}
+		if i == 0 {
+			score += bonusFirst
+		}
+		if len(positions) > 0 {
+			if prev := positions[len(positions)-1]; prev == i-1 {
+				score += bonusConsecutive
+			} else {
+				score -= penaltyGap * (i - prev - 1)
+			}
+		}
+		positions = append(positions, i)
+		pi++
+	}
+	return score, positions, true
+}
+
+// boundary reports whether text[i] starts a word: it follows a separator or
+// is an uppercase letter after a lowercase one
+func boundary(text []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := text[i-1], text[i]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return unicode.IsLetter(cur) || unicode.IsDigit(cur)
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
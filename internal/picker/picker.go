@@ -0,0 +1,218 @@
+package picker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Item is one entry in a picker. Detail is shown after Label, e.g. the file
+// a symbol is in. The query is matched against both, so matches in Label
+// rank first only through scoring.
+type Item struct {
+	Label  string
+	Detail string
+}
+
+// Picker is a fuzzy finder over a list of items
+type Picker struct {
+	Title  string
+	Status string // shown after the count, e.g. while items are loading
+
+	query    []rune
+	cursor   int
+	items    []Item
+	texts    [][]rune // label and detail, as matched
+	matches  []match
+	selected int
+	scroll   int
+	rows     int
+}
+
+type match struct {
+	index     int
+	score     int
+	positions []int
+}
+
+// Action is the result of a key in the picker
+type Action struct {
+	Accept int // index of the chosen item, or -1
+	Cancel bool
+}
+
+func New(title, query string) *Picker {
+	p := &Picker{Title: title, query: []rune(query)}
+	p.cursor = len(p.query)
+	return p
+}
+
+// SetItems replaces the items and filters them with the current query
+func (p *Picker) SetItems(items []Item) {
+	p.items = items
+	p.texts = make([][]rune, len(items))
+	for i, item := range items {
+		p.texts[i] = []rune(strings.TrimSpace(item.Label + " " + item.Detail))
+	}
+	p.filter()
+}
+
+// Query returns the text typed so far
+func (p *Picker) Query() string {
+	return string(p.query)
+}
+
+// filter matches the items against the query, best first
+func (p *Picker) filter() {
+	p.matches = p.matches[:0]
+	for i, text := range p.texts {
+		if score, positions, ok := Match(p.query, text); ok {
+			p.matches = append(p.matches, match{index: i, score: score, positions: positions})
+		}
+	}
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		a, b := p.matches[i], p.matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return len(p.texts[a.index]) < len(p.texts[b.index])
+	})
+	p.selected = 0
+	p.scroll = 0
+}
+
+// HandleKey edits the query and moves the selection
+func (p *Picker) HandleKey(ev *terminal.Event) Action {
+	none := Action{Accept: -1}
+	switch ev.Key {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return Action{Accept: -1, Cancel: true}
+	case tcell.KeyEnter:
+		if p.selected < len(p.matches) {
+			return Action{Accept: p.matches[p.selected].index}
+		}
+		return none
+	case tcell.KeyUp, tcell.KeyCtrlP, tcell.KeyCtrlK:
+		p.move(-1)
+		return none
+	case tcell.KeyDown, tcell.KeyCtrlN, tcell.KeyCtrlJ, tcell.KeyTab:
+		p.move(1)
+		return none
+	case tcell.KeyPgUp:
+		p.move(-p.rows)
+		return none
+	case tcell.KeyPgDn:
+		p.move(p.rows)
+		return none
+	case tcell.KeyLeft:
+		p.cursor = max(p.cursor-1, 0)
+		return none
+	case tcell.KeyRight:
+		p.cursor = min(p.cursor+1, len(p.query))
+		return none
+	case tcell.KeyCtrlU:
+		p.query = p.query[p.cursor:]
+		p.cursor = 0
+		p.filter()
+		return none
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if p.cursor > 0 {
+			p.query = append(p.query[:p.cursor-1], p.query[p.cursor:]...)
+			p.cursor--
+			p.filter()
+		}
+		return none
+	}
+	if ev.Rune != 0 {
+		p.query = append(p.query[:p.cursor], append([]rune{ev.Rune}, p.query[p.cursor:]...)...)
+		p.cursor++
+		p.filter()
+	}
+	return none
+}
+
+func (p *Picker) move(delta int) {
+	p.selected = max(min(p.selected+delta, len(p.matches)-1), 0)
+	if p.selected < p.scroll {
+		p.scroll = p.selected
+	}
+	if p.rows > 0 && p.selected >= p.scroll+p.rows {
+		p.scroll = p.selected - p.rows + 1
+	}
+}
+
+// Render draws the picker as a box: the query on top, then the matches
+func (p *Picker) Render(term *terminal.Terminal, x, y, width, height int) {
+	if term == nil || width <= 0 || height < 2 {
+		return
+	}
+	base := tcell.StyleDefault
+	titleStyle := base.Reverse(true).Bold(true)
+	title := fmt.Sprintf(" %s (%d/%d)", p.Title, len(p.matches), len(p.items))
+	if p.Status != "" {
+		title += " " + p.Status
+	}
+	term.DrawText(x, y, fit(title, width), titleStyle)
+
+	prompt := "> " + string(p.query)
+	term.DrawText(x, y+1, fit(prompt, width), base)
+	if cx := x + 2 + p.cursor; cx < x+width {
+		r, _, style, _ := term.ScreenContent(cx, y+1)
+		term.SetCell(cx, y+1, r, style.Reverse(true))
+	}
+
+	p.rows = height - 2
+	for row := 0; row < p.rows; row++ {
+		idx := p.scroll + row
+		rowY := y + 2 + row
+		if idx >= len(p.matches) {
+			term.DrawText(x, rowY, fit("", width), base)
+			continue
+		}
+		m := p.matches[idx]
+		style := base
+		if idx == p.selected {
+			style = style.Reverse(true)
+		}
+		p.renderItem(term, x, rowY, width, m, style)
+	}
+}
+
+// renderItem draws one match with the matched runes underlined and its
+// detail dimmed after the label
+func (p *Picker) renderItem(term *terminal.Terminal, x, y, width int, m match, style tcell.Style) {
+	term.DrawText(x, y, fit("", width), style)
+	labelLen := len([]rune(p.items[m.index].Label))
+	col := 2
+	pos := 0
+	for i, r := range p.texts[m.index] {
+		if col >= width {
+			return
+		}
+		s := style
+		if i > labelLen {
+			s = s.Dim(true)
+		}
+		if i == labelLen {
+			// Space out the detail
+			col++
+		}
+		if pos < len(m.positions) && m.positions[pos] == i {
+			s = s.Bold(true).Underline(true)
+			pos++
+		}
+		term.SetCell(x+col, y, r, s)
+		col++
+	}
+}
+
+func fit(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
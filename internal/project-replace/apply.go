@@ -0,0 +1,58 @@
+package projectreplace
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+)
+
+// ApplyLine applies the edits for one line, given last to first
+func ApplyLine(line string, edits []Edit) string {
+	runes := []rune(line)
+	for _, ed := range edits {
+		if ed.Col < 0 || ed.Col+ed.Len > len(runes) {
+			continue
+		}
+		runes = append(runes[:ed.Col], append([]rune(ed.Text), runes[ed.Col+ed.Len:]...)...)
+	}
+	return string(runes)
+}
+
+// ApplyToFile edits a file on disk. The new contents are written to a
+// temporary file that then replaces the original, so a failure never leaves
+// the file half written. Nothing is written if any edited line changed since
+// it was searched.
+func ApplyToFile(fe FileEdit) error {
+	info, err := os.Stat(fe.Path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(fe.Path)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	byLine := make(map[int][]Edit)
+	for _, ed := range fe.Edits {
+		byLine[ed.Line] = append(byLine[ed.Line], ed)
+	}
+	for n, edits := range byLine {
+		if n >= len(lines) {
+			return fmt.Errorf("file changed since search")
+		}
+		text := strings.TrimSuffix(lines[n], "\r")
+		if text != fe.Lines[n] {
+			return fmt.Errorf("file changed since search")
+		}
+		newText := ApplyLine(text, edits)
+		if strings.HasSuffix(lines[n], "\r") {
+			newText += "\r"
+		}
+		lines[n] = newText
+	}
+
+	return utils.WriteFileAtomic(fe.Path, []byte(strings.Join(lines, "\n")), info.Mode())
+}
@@ -0,0 +1,235 @@
+package projectreplace
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Adelodunpeter25/vx/internal/grep"
+)
+
+// Hit is a single match that can be accepted or skipped
+type Hit struct {
+	grep.Hit
+	Replacement string
+	Accepted    bool
+}
+
+// File groups the hits found in one file
+type File struct {
+	Path string
+	Hits []*Hit
+}
+
+// row is a line in the hit list: a file header or one of its hits
+type row struct {
+	file *File
+	hit  *Hit
+}
+
+// Session holds the matches of a project-wide replace until they are applied
+type Session struct {
+	Pattern     string
+	Replacement string
+	Root        string
+	Searching   bool
+
+	re       *regexp.Regexp
+	template string
+	files    []*File
+	rows     []row
+	selected int
+	scroll   int
+	height   int // list rows shown by the last Render
+}
+
+// Action is returned by key handling
+type Action struct {
+	Apply  bool
+	Cancel bool
+}
+
+// New creates a session. With literal set, the replacement is inserted as is;
+// otherwise $1 and ${name} refer to groups captured by re.
+func New(pattern, replacement, root string, re *regexp.Regexp, literal bool) *Session {
+	template := replacement
+	if literal {
+		template = strings.ReplaceAll(replacement, "$", "$$")
+	}
+	return &Session{
+		Pattern:     pattern,
+		Replacement: replacement,
+		Root:        root,
+		Searching:   true,
+		re:          re,
+		template:    template,
+	}
+}
+
+// Add records hits, which arrive grouped by file. All hits start out accepted.
+func (s *Session) Add(hits []grep.Hit) {
+	if len(hits) == 0 {
+		return
+	}
+	var selected *Hit
+	if r := s.selectedRow(); r != nil {
+		selected = r.hit
+	}
+
+	var file *File
+	for i := 0; i < len(hits); {
+		if file == nil || file.Path != hits[i].Path {
+			file = &File{Path: hits[i].Path}
+			s.files = append(s.files, file)
+		}
+		// Expand the replacement once per line, for every match on it
+		j := i
+		for j < len(hits) && hits[j].Path == hits[i].Path && hits[j].Line == hits[i].Line {
+			j++
+		}
+		replacements := s.expand(hits[i].Text)
+		for _, h := range hits[i:j] {
+			file.Hits = append(file.Hits, &Hit{Hit: h, Replacement: replacements[h.Col], Accepted: true})
+		}
+		i = j
+	}
+
+	sort.Slice(s.files, func(i, j int) bool {
+		return s.files[i].Path < s.files[j].Path
+	})
+	s.rebuildRows()
+	if selected != nil {
+		for i, r := range s.rows {
+			if r.hit == selected {
+				s.selected = i
+			}
+		}
+	}
+}
+
+// expand returns the replacement text for every match in line by rune column
+func (s *Session) expand(line string) map[int]string {
+	out := make(map[int]string)
+	col, pos := 0, 0
+	for _, loc := range s.re.FindAllStringSubmatchIndex(line, -1) {
+		col += utf8.RuneCountInString(line[pos:loc[0]])
+		pos = loc[0]
+		out[col] = string(s.re.ExpandString(nil, s.template, line, loc))
+	}
+	return out
+}
+
+func (s *Session) rebuildRows() {
+	s.rows = s.rows[:0]
+	for _, f := range s.files {
+		s.rows = append(s.rows, row{file: f})
+		for _, h := range f.Hits {
+			s.rows = append(s.rows, row{file: f, hit: h})
+		}
+	}
+}
+
+func (s *Session) selectedRow() *row {
+	if s.selected < 0 || s.selected >= len(s.rows) {
+		return nil
+	}
+	return &s.rows[s.selected]
+}
+
+// Counts returns the number of accepted hits, all hits and files
+func (s *Session) Counts() (accepted, total, files int) {
+	for _, f := range s.files {
+		for _, h := range f.Hits {
+			total++
+			if h.Accepted {
+				accepted++
+			}
+		}
+	}
+	return accepted, total, len(s.files)
+}
+
+// Toggle flips the selected hit, or every hit of the selected file
+func (s *Session) Toggle() {
+	r := s.selectedRow()
+	if r == nil {
+		return
+	}
+	if r.hit != nil {
+		r.hit.Accepted = !r.hit.Accepted
+		return
+	}
+	setAll(r.file.Hits, !allAccepted(r.file.Hits))
+}
+
+// ToggleAll accepts every hit, or skips them all if all are accepted
+func (s *Session) ToggleAll() {
+	var hits []*Hit
+	for _, f := range s.files {
+		hits = append(hits, f.Hits...)
+	}
+	setAll(hits, !allAccepted(hits))
+}
+
+func allAccepted(hits []*Hit) bool {
+	for _, h := range hits {
+		if !h.Accepted {
+			return false
+		}
+	}
+	return true
+}
+
+func setAll(hits []*Hit, accepted bool) {
+	for _, h := range hits {
+		h.Accepted = accepted
+	}
+}
+
+// Edit replaces Len runes at Line/Col with Text
+type Edit struct {
+	Line int
+	Col  int
+	Len  int
+	Text string
+}
+
+// FileEdit is the accepted replacements for one file
+type FileEdit struct {
+	Path  string
+	Edits []Edit         // from the end of the file to the start
+	Lines map[int]string // text each edited line had when it was searched
+}
+
+// Edits returns the accepted replacements grouped by file. Edits are ordered
+// last to first so applying one does not move the ones after it.
+func (s *Session) Edits() []FileEdit {
+	var out []FileEdit
+	for _, f := range s.files {
+		fe := FileEdit{Path: f.Path, Lines: make(map[int]string)}
+		for i := len(f.Hits) - 1; i >= 0; i-- {
+			h := f.Hits[i]
+			if !h.Accepted {
+				continue
+			}
+			fe.Edits = append(fe.Edits, Edit{Line: h.Line, Col: h.Col, Len: h.Len, Text: h.Replacement})
+			fe.Lines[h.Line] = h.Text
+		}
+		if len(fe.Edits) > 0 {
+			out = append(out, fe)
+		}
+	}
+	return out
+}
+
+// previewLine returns line with the accepted hits of that line replaced
+func previewLine(line string, hits []*Hit) string {
+	var edits []Edit
+	for i := len(hits) - 1; i >= 0; i-- {
+		if h := hits[i]; h.Accepted {
+			edits = append(edits, Edit{Line: h.Line, Col: h.Col, Len: h.Len, Text: h.Replacement})
+		}
+	}
+	return ApplyLine(line, edits)
+}
@@ -0,0 +1,192 @@
+package projectreplace
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Render draws the hit list on the left and a diff preview of the selected
+// file on the right
+func (s *Session) Render(term *terminal.Terminal, x, y, width, height int) {
+	if term == nil || width <= 0 || height <= 0 {
+		return
+	}
+
+	accepted, total, files := s.Counts()
+	title := fmt.Sprintf(" replace %s → %s: %d of %d matches in %d files", s.Pattern, s.Replacement, accepted, total, files)
+	if s.Searching {
+		title += " searching…"
+	}
+	title += "  [Space] toggle [a] all [Enter] apply [q] cancel"
+	term.DrawText(x, y, fit(title, width), tcell.StyleDefault.Reverse(true).Bold(true))
+
+	listWidth := width / 2
+	previewX := x + listWidth + 1
+	previewWidth := width - listWidth - 1
+	rows := height - 1
+	s.height = rows
+	s.clampScroll()
+
+	for i := 0; i < rows; i++ {
+		idx := s.scroll + i
+		label := ""
+		style := tcell.StyleDefault
+		if idx < len(s.rows) {
+			r := s.rows[idx]
+			if r.hit == nil {
+				mark := "[x]"
+				if !allAccepted(r.file.Hits) {
+					mark = "[ ]"
+				}
+				label = fmt.Sprintf("%s %s (%d)", mark, s.displayPath(r.file.Path), len(r.file.Hits))
+				style = style.Bold(true)
+			} else {
+				mark := "[x]"
+				if !r.hit.Accepted {
+					mark = "[ ]"
+				}
+				label = fmt.Sprintf("  %s %d: %s", mark, r.hit.Line+1, strings.TrimSpace(r.hit.Text))
+			}
+			if idx == s.selected {
+				style = style.Reverse(true)
+			}
+		}
+		term.DrawText(x, y+1+i, fit(label, listWidth), style)
+		term.SetCell(x+listWidth, y+1+i, '│', tcell.StyleDefault.Foreground(tcell.ColorGray))
+	}
+
+	if previewWidth > 0 {
+		s.renderPreview(term, previewX, y+1, previewWidth, rows)
+	}
+}
+
+// renderPreview shows the selected file's changed lines as a diff
+func (s *Session) renderPreview(term *terminal.Terminal, x, y, width, height int) {
+	r := s.selectedRow()
+	for i := 0; i < height; i++ {
+		term.DrawText(x, y+i, fit("", width), tcell.StyleDefault)
+	}
+	if r == nil {
+		return
+	}
+
+	type diffLine struct {
+		text  string
+		style tcell.Style
+	}
+	var lines []diffLine
+	focus := 0
+	hits := r.file.Hits
+	for i := 0; i < len(hits); {
+		j := i
+		for j < len(hits) && hits[j].Line == hits[i].Line {
+			j++
+		}
+		onLine := hits[i:j]
+		if r.hit != nil && r.hit.Line == hits[i].Line {
+			focus = len(lines)
+		}
+		old := hits[i].Text
+		updated := previewLine(old, onLine)
+		lines = append(lines, diffLine{fmt.Sprintf("@@ line %d @@", hits[i].Line+1), tcell.StyleDefault.Foreground(tcell.ColorTeal)})
+		if updated == old {
+			lines = append(lines, diffLine{"  " + old, tcell.StyleDefault.Foreground(tcell.ColorGray)})
+		} else {
+			lines = append(lines, diffLine{"- " + old, tcell.StyleDefault.Foreground(tcell.ColorRed)})
+			lines = append(lines, diffLine{"+ " + updated, tcell.StyleDefault.Foreground(tcell.ColorGreen)})
+		}
+		i = j
+	}
+
+	// Keep the selected hit's line in view
+	start := 0
+	if focus >= height {
+		start = focus - height/2
+	}
+	for i := 0; i < height && start+i < len(lines); i++ {
+		l := lines[start+i]
+		term.DrawText(x, y+i, fit(strings.ReplaceAll(l.text, "\t", "    "), width), l.style)
+	}
+}
+
+// HandleKey handles keys while the session is shown
+func (s *Session) HandleKey(ev *terminal.Event) Action {
+	switch ev.Key {
+	case tcell.KeyEscape:
+		return Action{Cancel: true}
+	case tcell.KeyEnter:
+		return Action{Apply: true}
+	case tcell.KeyUp:
+		s.move(-1)
+	case tcell.KeyDown:
+		s.move(1)
+	case tcell.KeyPgUp:
+		s.move(-s.height)
+	case tcell.KeyPgDn:
+		s.move(s.height)
+	}
+	switch ev.Rune {
+	case 'k':
+		s.move(-1)
+	case 'j':
+		s.move(1)
+	case ' ':
+		s.Toggle()
+		if r := s.selectedRow(); r != nil && r.hit != nil {
+			s.move(1)
+		}
+	case 'a':
+		s.ToggleAll()
+	case 'q':
+		return Action{Cancel: true}
+	}
+	return Action{}
+}
+
+func (s *Session) move(delta int) {
+	s.selected += delta
+	if s.selected >= len(s.rows) {
+		s.selected = len(s.rows) - 1
+	}
+	if s.selected < 0 {
+		s.selected = 0
+	}
+	if s.selected < s.scroll {
+		s.scroll = s.selected
+	}
+	if s.height > 0 && s.selected >= s.scroll+s.height {
+		s.scroll = s.selected - s.height + 1
+	}
+}
+
+func (s *Session) clampScroll() {
+	maxScroll := len(s.rows) - s.height
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if s.scroll > maxScroll {
+		s.scroll = maxScroll
+	}
+	if s.scroll < 0 {
+		s.scroll = 0
+	}
+}
+
+func (s *Session) displayPath(path string) string {
+	if rel, err := filepath.Rel(s.Root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func fit(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,145 @@
+package quickfix
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Item is a location in a file, such as a grep hit or a compiler error
+type Item struct {
+	Path string
+	Line int // 0-based
+	Col  int // rune column
+	Text string
+}
+
+// List is the quickfix list shown in a panel below the panes
+type List struct {
+	Open    bool
+	Focused bool
+	Height  int
+	Title   string
+	Status  string
+	Root    string // paths are shown relative to Root
+
+	items    []Item
+	selected int
+	current  int
+	scroll   int
+	rows     int // item rows shown by the last Render
+}
+
+type Action struct {
+	Open  *Item
+	Close bool
+}
+
+func New() *List {
+	return &List{
+		Height:  10,
+		current: -1,
+	}
+}
+
+// Set replaces the list contents
+func (l *List) Set(title, root string, items []Item) {
+	l.Title = title
+	l.Root = root
+	l.Status = ""
+	l.items = append([]Item(nil), items...)
+	l.selected = 0
+	l.current = -1
+	l.scroll = 0
+}
+
+// Append adds items to the end of the list
+func (l *List) Append(items ...Item) {
+	l.items = append(l.items, items...)
+}
+
+// SortByLocation orders items by path, line and column while keeping the
+// selected and current items in place
+func (l *List) SortByLocation() {
+	var selected, current *Item
+	if l.selected >= 0 && l.selected < len(l.items) {
+		s := l.items[l.selected]
+		selected = &s
+	}
+	if l.current >= 0 && l.current < len(l.items) {
+		c := l.items[l.current]
+		current = &c
+	}
+	sort.SliceStable(l.items, func(i, j int) bool {
+		a, b := l.items[i], l.items[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	if selected != nil {
+		l.selected = l.indexOf(*selected)
+	}
+	if current != nil {
+		l.current = l.indexOf(*current)
+	}
+}
+
+func (l *List) indexOf(item Item) int {
+	for i, it := range l.items {
+		if it == item {
+			return i
+		}
+	}
+	return 0
+}
+
+// Len returns the number of items
+func (l *List) Len() int {
+	return len(l.items)
+}
+
+// Current returns the index of the item last jumped to, or -1
+func (l *List) Current() int {
+	return l.current
+}
+
+// Next moves to the item after the current one
+func (l *List) Next() (*Item, bool) {
+	if len(l.items) == 0 || l.current >= len(l.items)-1 {
+		return nil, false
+	}
+	return l.Select(l.current + 1), true
+}
+
+// Prev moves to the item before the current one
+func (l *List) Prev() (*Item, bool) {
+	if len(l.items) == 0 || l.current <= 0 {
+		return nil, false
+	}
+	return l.Select(l.current - 1), true
+}
+
+// Select makes item idx current and returns it
+func (l *List) Select(idx int) *Item {
+	if idx < 0 || idx >= len(l.items) {
+		return nil
+	}
+	l.current = idx
+	l.selected = idx
+	l.reveal()
+	item := l.items[idx]
+	return &item
+}
+
+// DisplayPath returns the item's path relative to the list root
+func (l *List) DisplayPath(item Item) string {
+	if l.Root != "" {
+		if rel, err := filepath.Rel(l.Root, item.Path); err == nil {
+			return rel
+		}
+	}
+	return item.Path
+}
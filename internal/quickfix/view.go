@@ -0,0 +1,157 @@
+package quickfix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adelodunpeter25/vx/internal/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Render draws the list with a title row at x, y
+func (l *List) Render(term *terminal.Terminal, x, y, width, height int) {
+	if !l.Open || term == nil || width <= 0 || height <= 0 {
+		return
+	}
+
+	titleStyle := tcell.StyleDefault.Reverse(true)
+	if l.Focused {
+		titleStyle = titleStyle.Bold(true)
+	}
+	title := fmt.Sprintf(" %s (%d)", l.Title, len(l.items))
+	if l.Status != "" {
+		title += " " + l.Status
+	}
+	term.DrawText(x, y, fit(title, width), titleStyle)
+
+	rows := height - 1
+	l.rows = rows
+	l.clampScroll()
+	for row := 0; row < rows; row++ {
+		idx := l.scroll + row
+		style := tcell.StyleDefault
+		if idx >= len(l.items) {
+			term.DrawText(x, y+1+row, fit("", width), style)
+			continue
+		}
+		item := l.items[idx]
+		marker := "  "
+		if idx == l.current {
+			marker = "> "
+		}
+		label := fmt.Sprintf("%s%s:%d:%d: %s", marker, l.DisplayPath(item), item.Line+1, item.Col+1, strings.TrimSpace(item.Text))
+		if idx == l.selected {
+			style = style.Reverse(true)
+			if l.Focused {
+				style = style.Bold(true)
+			}
+		}
+		term.DrawText(x, y+1+row, fit(label, width), style)
+	}
+}
+
+// HandleKey handles keys while the list has focus
+func (l *List) HandleKey(ev *terminal.Event) Action {
+	if l == nil || !l.Open || ev == nil {
+		return Action{}
+	}
+	switch ev.Key {
+	case tcell.KeyEscape:
+		l.Focused = false
+		return Action{}
+	case tcell.KeyUp:
+		l.move(-1)
+		return Action{}
+	case tcell.KeyDown:
+		l.move(1)
+		return Action{}
+	case tcell.KeyPgUp:
+		l.move(-l.rows)
+		return Action{}
+	case tcell.KeyPgDn:
+		l.move(l.rows)
+		return Action{}
+	case tcell.KeyEnter:
+		return Action{Open: l.Select(l.selected)}
+	}
+	switch ev.Rune {
+	case 'k':
+		l.move(-1)
+	case 'j':
+		l.move(1)
+	case 'q':
+		l.Open = false
+		l.Focused = false
+		return Action{Close: true}
+	}
+	return Action{}
+}
+
+// HandleMouse selects the clicked item and scrolls with the wheel
+func (l *List) HandleMouse(ev *terminal.Event, x, y, width, height int) Action {
+	if l == nil || !l.Open || ev == nil {
+		return Action{}
+	}
+	switch ev.Button {
+	case tcell.WheelUp:
+		l.scroll--
+		l.clampScroll()
+		return Action{}
+	case tcell.WheelDown:
+		l.scroll++
+		l.clampScroll()
+		return Action{}
+	case tcell.Button1:
+		idx := l.scroll + ev.MouseY - y - 1
+		if ev.MouseY == y || idx < 0 || idx >= len(l.items) {
+			return Action{}
+		}
+		if idx == l.selected {
+			return Action{Open: l.Select(idx)}
+		}
+		l.selected = idx
+	}
+	return Action{}
+}
+
+func (l *List) move(delta int) {
+	l.selected += delta
+	if l.selected >= len(l.items) {
+		l.selected = len(l.items) - 1
+	}
+	if l.selected < 0 {
+		l.selected = 0
+	}
+	l.reveal()
+}
+
+// reveal scrolls so the selected item is visible
+func (l *List) reveal() {
+	if l.selected < l.scroll {
+		l.scroll = l.selected
+	}
+	if l.rows > 0 && l.selected >= l.scroll+l.rows {
+		l.scroll = l.selected - l.rows + 1
+	}
+}
+
+func (l *List) clampScroll() {
+	maxScroll := len(l.items) - l.rows
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if l.scroll > maxScroll {
+		l.scroll = maxScroll
+	}
+	if l.scroll < 0 {
+		l.scroll = 0
+	}
+}
+
+func fit(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,38 @@
+package replace
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PreserveCase adapts replacement to the case pattern of matched:
+// FOO gives an all uppercase replacement, Foo a capitalized one, and
+// anything else leaves the replacement as typed.
+func PreserveCase(matched, replacement string) string {
+	if replacement == "" {
+		return ""
+	}
+	hasLetter, allUpper := false, true
+	for _, r := range matched {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			if !unicode.IsUpper(r) {
+				allUpper = false
+			}
+		}
+	}
+	if !hasLetter {
+		return replacement
+	}
+
+	first, _ := utf8.DecodeRuneInString(matched)
+	if allUpper && utf8.RuneCountInString(matched) > 1 {
+		return strings.ToUpper(replacement)
+	}
+	if unicode.IsUpper(first) {
+		r, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToUpper(r)) + replacement[size:]
+	}
+	return replacement
+}
@@ -0,0 +1,27 @@
+package replace
+
+import "testing"
+
+func TestPreserveCase(t *testing.T) {
+	tests := []struct {
+		matched, replacement string
+		want                 string
+	}{
+		{"foo", "bar", "bar"},
+		{"FOO", "bar", "BAR"},
+		{"Foo", "bar", "Bar"},
+		{"fOO", "bar", "bar"},
+		{"F", "bar", "Bar"},
+		{"FOO_BAR", "baz_qux", "BAZ_QUX"},
+		{"Foo", "barBaz", "BarBaz"},
+		{"123", "bar", "bar"},
+		{"Élan", "été", "Été"},
+		{"Foo", "", ""},
+		{"FOO", "", ""},
+	}
+	for _, tt := range tests {
+		if got := PreserveCase(tt.matched, tt.replacement); got != tt.want {
+			t.Errorf("PreserveCase(%q, %q) = %q, want %q", tt.matched, tt.replacement, got, tt.want)
+		}
+	}
+}
@@ -29,3 +29,33 @@ func (e *Engine) NextMatch() bool {
 	}
 	return true
 }
+
+// ReplacementFor returns the text that replaces matched
+func (e *Engine) ReplacementFor(matched string) string {
+	if e.preserveCase {
+		return PreserveCase(matched, e.replaceTerm)
+	}
+	return e.replaceTerm
+}
+
+// RecordReplacement notes that the current match was replaced by newLen
+// runes and moves the later matches on the same line accordingly
+func (e *Engine) RecordReplacement(newLen int) {
+	cur := e.GetCurrentMatch()
+	if cur == nil {
+		return
+	}
+	delta := newLen - cur.Len
+	for i := e.currentIdx + 1; i < len(e.matches); i++ {
+		if e.matches[i].Line != cur.Line {
+			break
+		}
+		e.matches[i].Col += delta
+	}
+	e.replaced++
+}
+
+// Replaced returns how many matches were replaced in this session
+func (e *Engine) Replaced() int {
+	return e.replaced
+}
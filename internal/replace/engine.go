@@ -4,11 +4,14 @@ import "github.com/Adelodunpeter25/vx/internal/search"
 
 // Engine manages find and replace operations
 type Engine struct {
-	state       State
-	searchTerm  string
-	replaceTerm string
-	matches     []search.Match
-	currentIdx  int
+	state        State
+	searchTerm   string
+	replaceTerm  string
+	matches      []search.Match
+	currentIdx   int
+	replaced     int
+	inSelection  bool
+	preserveCase bool
 }
 
 func New() *Engine {
@@ -24,6 +27,27 @@ func (e *Engine) Start() {
 	e.replaceTerm = ""
 	e.matches = nil
 	e.currentIdx = 0
+	e.replaced = 0
+}
+
+// SetInSelection limits matches to the visual selection
+func (e *Engine) SetInSelection(in bool) {
+	e.inSelection = in
+}
+
+// InSelection reports whether matches are limited to the visual selection
+func (e *Engine) InSelection() bool {
+	return e.inSelection
+}
+
+// SetPreserveCase makes replacements follow the case of the text they replace
+func (e *Engine) SetPreserveCase(preserve bool) {
+	e.preserveCase = preserve
+}
+
+// PreservesCase reports whether case-preserving replacement is on
+func (e *Engine) PreservesCase() bool {
+	return e.preserveCase
 }
 
 // IsActive returns true if replace mode is active
@@ -43,4 +67,5 @@ func (e *Engine) Cancel() {
 	e.replaceTerm = ""
 	e.matches = nil
 	e.currentIdx = 0
+	e.inSelection = false
 }
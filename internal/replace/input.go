@@ -55,3 +55,19 @@ func (e *Engine) ConfirmReplace() {
 		e.state = StateInactive
 	}
 }
+
+// InRange keeps the matches that lie entirely between start and end, where
+// end is exclusive
+func InRange(matches []search.Match, startLine, startCol, endLine, endCol int) []search.Match {
+	var out []search.Match
+	for _, m := range matches {
+		if m.Line < startLine || (m.Line == startLine && m.Col < startCol) {
+			continue
+		}
+		if m.Line > endLine || (m.Line == endLine && m.Col+m.Len > endCol) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"sort"
+)
+
+// batchLines is how many lines a background search scans between updates
+const batchLines = 2000
+
+// Source provides the lines a background search scans. Lines beyond
+// LineCount are not in memory and are streamed by ReadRest.
+type Source interface {
+	LineCount() int
+	Line(n int) string
+	ReadRest(ctx context.Context, fn func(lineNum int, line string) bool) error
+}
+
+type batch struct {
+	gen     int
+	matches []Match
+	done    bool
+}
+
+// SetNotify sets a callback that the background search calls, from its own
+// goroutine, whenever new results are ready to be collected with Poll
+func (e *Engine) SetNotify(fn func()) {
+	e.notify = fn
+}
+
+// View tells a background search which lines are visible and where the
+// current match should be picked from
+type View struct {
+	First, Last int // visible lines, scanned first
+	Line, Col   int // anchor position
+	Backward    bool
+}
+
+// Start begins a background search. Lines View.First..View.Last are scanned
+// first, then the rest of the buffer after them, then the lines before them.
+// The first match at or after the anchor (or the last one before it when
+// searching backward) becomes the current match. The query may end in a
+// vi-style offset such as "/e" or "/+2". Any search still running is cancelled.
+func (e *Engine) Start(src Source, query string, view View) {
+	e.stop()
+	e.err = nil
+	e.query = query
+	e.offset = Offset{}
+	e.matches = []Match{}
+	e.current = -1
+	e.batches = 0
+	if query == "" {
+		return
+	}
+
+	pattern, offset, err := SplitOffset(query)
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.offset = offset
+	if pattern == "" {
+		return
+	}
+	re, err := e.Compile(pattern)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.gen++
+	e.cancel = cancel
+	e.searching = true
+	e.anchor = view
+	if e.results == nil {
+		e.results = make(chan batch, 64)
+	}
+	go run(ctx, e.gen, re, src, view.First, view.Last, e.results, e.notify)
+}
+
+func run(ctx context.Context, gen int, re *regexp.Regexp, src Source, first, last int, out chan<- batch, notify func()) {
+	loaded := src.LineCount()
+	if first < 0 {
+		first = 0
+	}
+	if last >= loaded {
+		last = loaded - 1
+	}
+	if first > last {
+		first, last = 0, -1
+	}
+
+	var pending []Match
+	scanned := 0
+	flush := func(done bool) bool {
+		b := batch{gen: gen, matches: pending, done: done}
+		pending = nil
+		scanned = 0
+		select {
+		case out <- b:
+		case <-ctx.Done():
+			return false
+		}
+		if notify != nil {
+			notify()
+		}
+		return true
+	}
+	scan := func(lineNum int, line string) bool {
+		pending = append(pending, FindInLine(re, line, lineNum)...)
+		scanned++
+		if scanned >= batchLines {
+			return ctx.Err() == nil && flush(false)
+		}
+		return true
+	}
+	scanRange := func(from, to int) bool {
+		for i := from; i <= to; i++ {
+			if !scan(i, src.Line(i)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Visible lines first so the screen fills in immediately
+	if !scanRange(first, last) || !flush(false) {
+		return
+	}
+	if !scanRange(last+1, loaded-1) {
+		return
+	}
+	stopped := false
+	err := src.ReadRest(ctx, func(lineNum int, line string) bool {
+		if !scan(lineNum, line) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped || (err != nil && ctx.Err() != nil) {
+		return
+	}
+	if !scanRange(0, first-1) {
+		return
+	}
+	flush(true)
+}
+
+// Poll collects results delivered by the background search. It must be
+// called from the same goroutine as Start and reports whether anything changed.
+func (e *Engine) Poll() bool {
+	if e.results == nil {
+		return false
+	}
+	changed := false
+	for {
+		select {
+		case b := <-e.results:
+			if b.gen != e.gen || !e.searching {
+				continue
+			}
+			e.merge(b.matches, b.done)
+			if b.done {
+				e.stop()
+			}
+			changed = true
+		default:
+			return changed
+		}
+	}
+}
+
+func (e *Engine) merge(found []Match, done bool) {
+	e.batches++
+	var cur *Match
+	if c := e.Current(); c != nil {
+		m := *c
+		cur = &m
+	}
+	if len(found) > 0 {
+		e.matches = append(e.matches, found...)
+		sort.Slice(e.matches, func(i, j int) bool {
+			return before(e.matches[i], e.matches[j])
+		})
+	}
+	if cur != nil {
+		e.current = e.indexOf(cur.Line, cur.Col)
+		return
+	}
+	if len(e.matches) == 0 {
+		return
+	}
+	e.pickCurrent(done)
+}
+
+// pickCurrent chooses the first current match relative to the anchor. It only
+// commits once the choice can no longer change: wrapping around has to wait
+// for the whole buffer, and so does a backward pick outside the viewport,
+// since the lines before the viewport are scanned top to bottom.
+func (e *Engine) pickCurrent(done bool) {
+	idx := e.indexOf(e.anchor.Line, e.anchor.Col)
+	if e.anchor.Backward {
+		idx--
+		switch {
+		case idx >= 0 && (done || e.batches == 1):
+			e.current = idx
+		case done:
+			e.current = len(e.matches) - 1
+		}
+		return
+	}
+	switch {
+	case idx < len(e.matches):
+		e.current = idx
+	case done:
+		e.current = 0
+	}
+}
+
+// indexOf returns the index of the first match at or after line/col
+func (e *Engine) indexOf(line, col int) int {
+	return sort.Search(len(e.matches), func(i int) bool {
+		m := e.matches[i]
+		return m.Line > line || (m.Line == line && m.Col >= col)
+	})
+}
+
+// LineMatches returns the matches on a single line
+func (e *Engine) LineMatches(line int) []Match {
+	start := e.indexOf(line, 0)
+	end := e.indexOf(line+1, 0)
+	return e.matches[start:end]
+}
+
+// Offset returns the search offset given with the current query
+func (e *Engine) Offset() Offset {
+	return e.offset
+}
+
+// Searching reports whether a background search is still running
+func (e *Engine) Searching() bool {
+	return e.searching
+}
+
+// stop cancels any running background search
+func (e *Engine) stop() {
+	if e.cancel != nil {
+		e.cancel()
+		e.cancel = nil
+	}
+	e.searching = false
+}
+
+func before(a, b Match) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Col < b.Col
+}
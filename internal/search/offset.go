@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OffsetKind selects what a search offset is relative to
+type OffsetKind int
+
+const (
+	OffsetNone  OffsetKind = iota
+	OffsetLine             // /foo/+2  lines below the match
+	OffsetStart            // /foo/s+1 characters from the match start
+	OffsetEnd              // /foo/e-1 characters from the match end
+)
+
+// Offset is a vi-style search offset that moves the cursor relative to a match
+type Offset struct {
+	Kind OffsetKind
+	N    int
+}
+
+// offsetPattern matches what may follow the slash of a search offset
+var offsetPattern = regexp.MustCompile(`^[esb]?[+-]?\d*$`)
+
+// SplitOffset separates a query like "foo/e+1" into its pattern and offset.
+// A slash preceded by a backslash is part of the pattern, and so is one not
+// followed by a valid offset, so "internal/editor" searches for the path.
+func SplitOffset(query string) (string, Offset, error) {
+	runes := []rune(query)
+	split := -1
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' {
+			i++
+			continue
+		}
+		if runes[i] == '/' {
+			split = i
+		}
+	}
+	if split < 0 || !offsetPattern.MatchString(string(runes[split+1:])) {
+		return strings.ReplaceAll(query, `\/`, "/"), Offset{}, nil
+	}
+	pattern := strings.ReplaceAll(string(runes[:split]), `\/`, "/")
+	off, err := parseOffset(string(runes[split+1:]))
+	if err != nil {
+		return pattern, Offset{}, err
+	}
+	return pattern, off, nil
+}
+
+func parseOffset(s string) (Offset, error) {
+	if s == "" {
+		return Offset{}, nil
+	}
+	off := Offset{Kind: OffsetLine}
+	switch s[0] {
+	case 'e':
+		off.Kind = OffsetEnd
+		s = s[1:]
+	case 's', 'b':
+		off.Kind = OffsetStart
+		s = s[1:]
+	}
+	if s == "" {
+		return off, nil
+	}
+	if s == "+" {
+		off.N = 1
+		return off, nil
+	}
+	if s == "-" {
+		off.N = -1
+		return off, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return Offset{}, fmt.Errorf("invalid search offset: %s", s)
+	}
+	off.N = n
+	return off, nil
+}
+
+// Apply returns the cursor position for a match with this offset applied
+func (o Offset) Apply(m Match) (line, col int) {
+	switch o.Kind {
+	case OffsetLine:
+		return m.Line + o.N, 0
+	case OffsetStart:
+		return m.Line, m.Col + o.N
+	case OffsetEnd:
+		end := m.Col + m.Len - 1
+		if end < m.Col {
+			end = m.Col
+		}
+		return m.Line, end + o.N
+	default:
+		return m.Line, m.Col
+	}
+}
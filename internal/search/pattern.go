@@ -0,0 +1,147 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// boundaryGroup names the empty groups \< and \> compile to. Go's \b only
+// knows ASCII word characters, so FindInLine checks the word boundaries
+// at these groups itself.
+const boundaryGroup = "wordboundary"
+
+var boundaryExpr = `(?P<` + boundaryGroup + `>)`
+
+// Compile turns a query into a regular expression using the engine's settings.
+// Supported modifiers:
+//
+//	\c      ignore case for this query
+//	\C      match case for this query
+//	\< \>   word boundaries (start and end of a word)
+func (e *Engine) Compile(query string) (*regexp.Regexp, error) {
+	ignoreCase := e.ignoreCase
+	if e.smartCase && hasUpper(query) {
+		ignoreCase = false
+	}
+
+	var expr strings.Builder
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			expr.WriteString(regexp.QuoteMeta(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) {
+			next := runes[i+1]
+			switch next {
+			case 'c':
+				ignoreCase = true
+				i++
+				continue
+			case 'C':
+				ignoreCase = false
+				i++
+				continue
+			case '<', '>':
+				flushLiteral()
+				expr.WriteString(boundaryExpr)
+				i++
+				continue
+			}
+			if e.regex {
+				expr.WriteRune(r)
+				expr.WriteRune(next)
+				i++
+				continue
+			}
+		}
+		if e.regex {
+			expr.WriteRune(r)
+		} else {
+			literal.WriteRune(r)
+		}
+	}
+	flushLiteral()
+
+	pattern := expr.String()
+	if e.wholeWord {
+		pattern = boundaryExpr + `(?:` + pattern + `)` + boundaryExpr
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, cleanRegexpError(err)
+	}
+	return re, nil
+}
+
+// hasBoundaries reports whether re was compiled with word boundaries
+func hasBoundaries(re *regexp.Regexp) bool {
+	for _, name := range re.SubexpNames() {
+		if name == boundaryGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// atBoundaries reports whether the boundary groups of a match, as returned
+// by FindAllStringSubmatchIndex, all fall between a word character and
+// something else
+func atBoundaries(re *regexp.Regexp, line string, loc []int) bool {
+	for i, name := range re.SubexpNames() {
+		if name != boundaryGroup || loc[2*i] < 0 {
+			continue
+		}
+		pos := loc[2*i]
+		before, _ := utf8.DecodeLastRuneInString(line[:pos])
+		after, _ := utf8.DecodeRuneInString(line[pos:])
+		if isWordRune(before) == isWordRune(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasUpper reports whether the query contains an uppercase letter outside of
+// escape sequences, which turns on case-sensitive matching with smartcase
+func hasUpper(query string) bool {
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' {
+			i++
+			continue
+		}
+		if unicode.IsUpper(runes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternError is a regexp error without the package prefix
+type patternError struct {
+	msg string
+}
+
+func (e *patternError) Error() string {
+	return e.msg
+}
+
+func cleanRegexpError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "error parsing regexp: ")
+	return &patternError{msg: msg}
+}
@@ -1,7 +1,8 @@
 package search
 
 import (
-	"strings"
+	"context"
+	"regexp"
 	"unicode/utf8"
 )
 
@@ -14,20 +15,65 @@ type Match struct {
 
 // Engine handles search operations
 type Engine struct {
-	query   string
-	matches []Match
-	current int
+	query      string
+	matches    []Match
+	current    int
+	err        error
+	ignoreCase bool
+	smartCase  bool
+	regex      bool
+	wholeWord  bool
+
+	// Background search state
+	notify    func()
+	cancel    context.CancelFunc
+	results   chan batch
+	gen       int
+	searching bool
+	batches   int
+	anchor    View
+	offset    Offset
 }
 
 func New() *Engine {
 	return &Engine{
-		matches: []Match{},
-		current: -1,
+		matches:    []Match{},
+		current:    -1,
+		ignoreCase: true,
 	}
 }
 
-// Search finds all matches in buffer
+// SetIgnoreCase controls whether searches ignore case
+func (e *Engine) SetIgnoreCase(ignore bool) {
+	e.ignoreCase = ignore
+}
+
+// SetSmartCase makes queries containing uppercase letters case-sensitive
+func (e *Engine) SetSmartCase(smart bool) {
+	e.smartCase = smart
+}
+
+// SetRegex switches between regular expression and literal queries
+func (e *Engine) SetRegex(regex bool) {
+	e.regex = regex
+}
+
+// SetWholeWord restricts matches to whole words
+func (e *Engine) SetWholeWord(whole bool) {
+	e.wholeWord = whole
+}
+
+// Err returns the pattern error from the last search, if any
+func (e *Engine) Err() error {
+	return e.err
+}
+
+// Search finds all matches in buffer. If the query is not a valid pattern the
+// error is available from Err and no matches are returned.
 func (e *Engine) Search(lines []string, query string) []Match {
+	e.stop()
+	e.err = nil
+	e.offset = Offset{}
 	if query == "" {
 		e.matches = []Match{}
 		e.current = -1
@@ -38,27 +84,14 @@ func (e *Engine) Search(lines []string, query string) []Match {
 	e.matches = []Match{}
 	e.current = -1
 
-	// Case-insensitive search
-	lowerQuery := strings.ToLower(query)
-	queryLen := utf8.RuneCountInString(query)
+	re, err := e.Compile(query)
+	if err != nil {
+		e.err = err
+		return e.matches
+	}
 
 	for lineNum, line := range lines {
-		lowerLine := strings.ToLower(line)
-		col := 0
-		for {
-			idx := strings.Index(lowerLine[col:], lowerQuery)
-			if idx == -1 {
-				break
-			}
-			byteIdx := col + idx
-			runeIdx := utf8.RuneCountInString(line[:byteIdx])
-			e.matches = append(e.matches, Match{
-				Line: lineNum,
-				Col:  runeIdx,
-				Len:  queryLen,
-			})
-			col += idx + 1
-		}
+		e.matches = append(e.matches, FindInLine(re, line, lineNum)...)
 	}
 
 	if len(e.matches) > 0 {
@@ -68,6 +101,35 @@ func (e *Engine) Search(lines []string, query string) []Match {
 	return e.matches
 }
 
+// FindInLine returns every match of re in line with rune-based columns
+func FindInLine(re *regexp.Regexp, line string, lineNum int) []Match {
+	bounded := hasBoundaries(re)
+	var locs [][]int
+	if bounded {
+		locs = re.FindAllStringSubmatchIndex(line, -1)
+	} else {
+		locs = re.FindAllStringIndex(line, -1)
+	}
+	if len(locs) == 0 {
+		return nil
+	}
+	matches := make([]Match, 0, len(locs))
+	runeCol, bytePos := 0, 0
+	for _, loc := range locs {
+		if bounded && !atBoundaries(re, line, loc) {
+			continue
+		}
+		runeCol += utf8.RuneCountInString(line[bytePos:loc[0]])
+		bytePos = loc[0]
+		matches = append(matches, Match{
+			Line: lineNum,
+			Col:  runeCol,
+			Len:  utf8.RuneCountInString(line[loc[0]:loc[1]]),
+		})
+	}
+	return matches
+}
+
 // Next moves to next match
 func (e *Engine) Next() *Match {
 	if len(e.matches) == 0 {
@@ -101,7 +163,10 @@ func (e *Engine) Current() *Match {
 
 // Clear clears search results
 func (e *Engine) Clear() {
+	e.stop()
 	e.query = ""
+	e.err = nil
+	e.offset = Offset{}
 	e.matches = []Match{}
 	e.current = -1
 }
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	type settings struct{ ignoreCase, smartCase, regex, wholeWord bool }
+	tests := []struct {
+		name    string
+		set     settings
+		query   string
+		match   []string
+		noMatch []string
+		wantErr string
+	}{
+		{"literal", settings{}, "a.b", []string{"xa.by"}, []string{"axb", "A.B"}, ""},
+		{"literal brackets", settings{}, "f(x)[0]", []string{"f(x)[0]"}, []string{"fx0"}, ""},
+		{"ignore case", settings{ignoreCase: true}, "foo", []string{"FOO", "Foo"}, []string{"fo"}, ""},
+		{"smartcase lower", settings{ignoreCase: true, smartCase: true}, "foo", []string{"FOO"}, nil, ""},
+		{"smartcase upper", settings{ignoreCase: true, smartCase: true}, "Foo", []string{"Foo"}, []string{"FOO", "foo"}, ""},
+		{"smartcase escape", settings{ignoreCase: true, smartCase: true}, `\Cfoo`, []string{"foo"}, []string{"FOO"}, ""},
+		{"force ignore", settings{}, `foo\c`, []string{"FOO"}, nil, ""},
+		{"force match", settings{ignoreCase: true}, `\Cfoo`, []string{"foo"}, []string{"Foo"}, ""},
+		{"word bounds", settings{}, `\<is\>`, []string{"this is it"}, []string{"this", "isle"}, ""},
+		{"whole word", settings{wholeWord: true}, "is", []string{"it is"}, []string{"this"}, ""},
+		{"regex", settings{regex: true}, `a.+b`, []string{"axxb"}, []string{"ab"}, ""},
+		{"regex escape", settings{regex: true}, `a\.b`, []string{"a.b"}, []string{"axb"}, ""},
+		{"regex whole word", settings{regex: true, wholeWord: true}, `is|it`, []string{"it"}, []string{"isle", "bit"}, ""},
+		{"unicode word bounds", settings{}, `\<café\>`, []string{"café", "un café.", "cafés café"}, []string{"cafés", "décafé"}, ""},
+		{"unicode whole word", settings{wholeWord: true}, "naïve", []string{"so naïve"}, []string{"naïveté"}, ""},
+		{"word start only", settings{}, `\<ré`, []string{"ré", "x réel"}, []string{"préfet"}, ""},
+		{"bounds in one alternative", settings{regex: true}, `\<ab\>|cd`, []string{"abcd", "x ab"}, []string{"abab"}, ""},
+		{"regex error", settings{regex: true}, `a(`, nil, nil, "missing closing ): `a(`"},
+		{"literal never errors", settings{}, `a(`, []string{"a("}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.SetIgnoreCase(tt.set.ignoreCase)
+			e.SetSmartCase(tt.set.smartCase)
+			e.SetRegex(tt.set.regex)
+			e.SetWholeWord(tt.set.wholeWord)
+			re, err := e.Compile(tt.query)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Compile(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.query, err)
+			}
+			for _, s := range tt.match {
+				if len(FindInLine(re, s, 0)) == 0 {
+					t.Errorf("%q (%s) doesn't match %q", tt.query, re, s)
+				}
+			}
+			for _, s := range tt.noMatch {
+				if len(FindInLine(re, s, 0)) != 0 {
+					t.Errorf("%q (%s) matches %q", tt.query, re, s)
+				}
+			}
+		})
+	}
+}
+
+func TestFindInLineWordBounds(t *testing.T) {
+	re, err := New().Compile(`\<été\>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := FindInLine(re, "étéété été_ été, été", 3)
+	want := []Match{{Line: 3, Col: 12, Len: 3}, {Line: 3, Col: 17, Len: 3}}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindInLine = %+v, want %+v", got, want)
+	}
+}
+
+func TestSplitOffset(t *testing.T) {
+	tests := []struct {
+		query   string
+		pattern string
+		offset  Offset
+		wantErr bool
+	}{
+		{"foo", "foo", Offset{}, false},
+		{"foo/", "foo", Offset{}, false},
+		{"foo/+2", "foo", Offset{OffsetLine, 2}, false},
+		{"foo/-1", "foo", Offset{OffsetLine, -1}, false},
+		{"foo/3", "foo", Offset{OffsetLine, 3}, false},
+		{"foo/+", "foo", Offset{OffsetLine, 1}, false},
+		{"foo/-", "foo", Offset{OffsetLine, -1}, false},
+		{"foo/e", "foo", Offset{OffsetEnd, 0}, false},
+		{"foo/e-1", "foo", Offset{OffsetEnd, -1}, false},
+		{"foo/s+2", "foo", Offset{OffsetStart, 2}, false},
+		{"foo/b", "foo", Offset{OffsetStart, 0}, false},
+		{"a/b/e", "a/b", Offset{OffsetEnd, 0}, false},
+		{"internal/editor", "internal/editor", Offset{}, false},
+		{"path/to/file", "path/to/file", Offset{}, false},
+		{`a\/e`, "a/e", Offset{}, false},
+		{`a\/b/e+1`, "a/b", Offset{OffsetEnd, 1}, false},
+		{"héllo/e", "héllo", Offset{OffsetEnd, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			pattern, offset, err := SplitOffset(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitOffset(%q) error = %v", tt.query, err)
+			}
+			if pattern != tt.pattern || offset != tt.offset {
+				t.Errorf("SplitOffset(%q) = %q, %+v, want %q, %+v", tt.query, pattern, offset, tt.pattern, tt.offset)
+			}
+		})
+	}
+}
+
+func TestOffsetApply(t *testing.T) {
+	m := Match{Line: 4, Col: 2, Len: 3}
+	tests := []struct {
+		offset    Offset
+		line, col int
+	}{
+		{Offset{}, 4, 2},
+		{Offset{OffsetLine, 2}, 6, 0},
+		{Offset{OffsetLine, -1}, 3, 0},
+		{Offset{OffsetStart, 1}, 4, 3},
+		{Offset{OffsetEnd, 0}, 4, 4},
+		{Offset{OffsetEnd, -1}, 4, 3},
+	}
+	for _, tt := range tests {
+		if line, col := tt.offset.Apply(m); line != tt.line || col != tt.col {
+			t.Errorf("%+v.Apply = %d,%d, want %d,%d", tt.offset, line, col, tt.line, tt.col)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package snippet
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pos is a position in text, with the column in runes
+type Pos struct {
+	Line, Col int
+}
+
+// Less reports whether p comes before q
+func (p Pos) Less(q Pos) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Col < q.Col)
+}
+
+// Range is where a tab stop's text is
+type Range struct {
+	Start, End Pos
+	Transform  *Transform // rewrites a mirror's copy of the placeholder
+}
+
+// Stop is a tab stop: its placeholder, then the mirrors that repeat it
+type Stop struct {
+	Index   int
+	Ranges  []Range
+	Choices []string
+}
+
+// Expansion is a template laid out as text, with the tab stops in it
+type Expansion struct {
+	Text  string
+	Stops []Stop // in tab order, ending with the final stop $0
+}
+
+// Expand lays the template out. vars returns the value of a variable, or
+// false when it is not known; lines after the first start with indent.
+func (t *Template) Expand(vars func(name string) (string, bool), indent string) *Expansion {
+	x := &expander{
+		defs:    make(map[int]*tabStop),
+		values:  make(map[int]string),
+		unknown: make(map[string]int),
+		stops:   make(map[int]*Stop),
+		indent:  []rune(indent),
+		vars:    vars,
+	}
+	x.next = maxIndex(t.nodes) + 1
+	nodes := x.resolve(t.nodes)
+	x.define(nodes)
+	x.layout(nodes)
+
+	exp := &Expansion{Text: x.text.String()}
+	for _, stop := range x.stops {
+		exp.Stops = append(exp.Stops, *stop)
+	}
+	sort.Slice(exp.Stops, func(i, j int) bool {
+		a, b := exp.Stops[i].Index, exp.Stops[j].Index
+		return a != 0 && (b == 0 || a < b)
+	})
+	if n := len(exp.Stops); n == 0 || exp.Stops[n-1].Index != 0 {
+		// The snippet ends at its end without a $0
+		exp.Stops = append(exp.Stops, Stop{Ranges: []Range{{Start: x.pos, End: x.pos}}})
+	}
+	return exp
+}
+
+type expander struct {
+	defs    map[int]*tabStop // the occurrence of each tab stop that gives its placeholder
+	values  map[int]string   // placeholder text by tab stop
+	unknown map[string]int   // tab stops made for unknown variables
+	next    int
+	stops   map[int]*Stop
+	vars    func(name string) (string, bool)
+
+	text   strings.Builder
+	pos    Pos
+	indent []rune
+}
+
+func maxIndex(nodes []node) int {
+	n := 0
+	for _, nd := range nodes {
+		switch nd := nd.(type) {
+		case *tabStop:
+			n = max(n, nd.index, maxIndex(nd.children))
+		case *variable:
+			n = max(n, maxIndex(nd.children))
+		}
+	}
+	return n
+}
+
+// resolve replaces variables with their values or defaults. An unknown
+// variable without a default becomes a placeholder holding its name.
+func (x *expander) resolve(nodes []node) []node {
+	var out []node
+	for _, nd := range nodes {
+		switch nd := nd.(type) {
+		case *tabStop:
+			stop := *nd
+			stop.children = x.resolve(nd.children)
+			out = append(out, &stop)
+		case *variable:
+			value, ok := "", false
+			if x.vars != nil {
+				value, ok = x.vars(nd.name)
+			}
+			switch {
+			case ok && (value != "" || !nd.hasDefault):
+				out = append(out, text(nd.transform.Apply(value)))
+			case nd.hasDefault:
+				out = append(out, x.resolve(nd.children)...)
+			default:
+				index, seen := x.unknown[nd.name]
+				if !seen {
+					index = x.next
+					x.next++
+					x.unknown[nd.name] = index
+				}
+				out = append(out, &tabStop{index: index, children: []node{text(nd.name)}})
+			}
+		default:
+			out = append(out, nd)
+		}
+	}
+	return out
+}
+
+// define picks the occurrence of each tab stop that gives its placeholder:
+// the first with one, or else the first
+func (x *expander) define(nodes []node) {
+	for _, nd := range nodes {
+		stop, ok := nd.(*tabStop)
+		if !ok {
+			continue
+		}
+		def := x.defs[stop.index]
+		if def == nil || (len(def.children) == 0 && len(def.choices) == 0 && (len(stop.children) > 0 || len(stop.choices) > 0)) {
+			x.defs[stop.index] = stop
+		}
+		x.define(stop.children)
+	}
+}
+
+// value returns the placeholder text of a tab stop
+func (x *expander) value(index int) string {
+	if v, ok := x.values[index]; ok {
+		return v
+	}
+	x.values[index] = "" // in case the placeholder contains itself
+	def := x.defs[index]
+	v := ""
+	if len(def.choices) > 0 {
+		v = def.choices[0]
+	} else {
+		var b strings.Builder
+		for _, nd := range def.children {
+			switch nd := nd.(type) {
+			case text:
+				b.WriteString(string(nd))
+			case *tabStop:
+				b.WriteString(nd.transform.Apply(x.value(nd.index)))
+			}
+		}
+		v = b.String()
+	}
+	x.values[index] = v
+	return v
+}
+
+// layout writes the text, noting where each tab stop ends up
+func (x *expander) layout(nodes []node) {
+	for _, nd := range nodes {
+		switch nd := nd.(type) {
+		case text:
+			x.write(string(nd))
+		case *tabStop:
+			start := x.pos
+			def := x.defs[nd.index] == nd
+			switch {
+			case def && len(nd.choices) > 0:
+				x.write(nd.choices[0])
+			case def:
+				x.layout(nd.children)
+			default:
+				x.write(nd.transform.Apply(x.value(nd.index)))
+			}
+			r := Range{Start: start, End: x.pos}
+			stop := x.stops[nd.index]
+			if stop == nil {
+				stop = &Stop{Index: nd.index}
+				x.stops[nd.index] = stop
+			}
+			if def {
+				stop.Ranges = append([]Range{r}, stop.Ranges...)
+				stop.Choices = nd.choices
+			} else {
+				r.Transform = nd.transform
+				stop.Ranges = append(stop.Ranges, r)
+			}
+		}
+	}
+}
+
+// write adds text, indenting the lines after the first
+func (x *expander) write(s string) {
+	for _, r := range s {
+		if r == '\r' {
+			continue
+		}
+		if r == '\n' {
+			x.text.WriteRune('\n')
+			x.text.WriteString(string(x.indent))
+			x.pos = Pos{Line: x.pos.Line + 1, Col: len(x.indent)}
+			continue
+		}
+		x.text.WriteRune(r)
+		x.pos.Col++
+	}
+}
@@ -0,0 +1,115 @@
+package snippet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe writes the stops of an expansion as index:ranges, e.g.
+// "1:0.4-0.7,1.0-1.3 0:2.0-2.0", with a * after mirrors with a transform
+func describe(stops []Stop) string {
+	var parts []string
+	for _, s := range stops {
+		var ranges []string
+		for _, r := range s.Ranges {
+			desc := fmt.Sprintf("%d.%d-%d.%d", r.Start.Line, r.Start.Col, r.End.Line, r.End.Col)
+			if r.Transform != nil {
+				desc += "*"
+			}
+			ranges = append(ranges, desc)
+		}
+		part := fmt.Sprintf("%d:%s", s.Index, strings.Join(ranges, ","))
+		if len(s.Choices) > 0 {
+			part += "|" + strings.Join(s.Choices, "|")
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestExpand(t *testing.T) {
+	vars := func(name string) (string, bool) {
+		switch name {
+		case "TM_FILENAME":
+			return "main.go", true
+		case "EMPTY":
+			return "", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		name   string
+		body   string
+		indent string
+		text   string
+		stops  string
+	}{
+		{"plain", "hello", "", "hello", "0:0.5-0.5"},
+		{"stops in order", "$2 and $1$0", "", " and ", "1:0.5-0.5 2:0.0-0.0 0:0.5-0.5"},
+		{"placeholder", "for ${1:i} := 0 {$0}", "", "for i := 0 {}", "1:0.4-0.5 0:0.12-0.12"},
+		{"mirror", "${1:x} = $1", "", "x = x", "1:0.0-0.1,0.4-0.5 0:0.5-0.5"},
+		{"mirror first", "$1 = ${1:x}", "", "x = x", "1:0.4-0.5,0.0-0.1 0:0.5-0.5"},
+		{"nested", "${1:a ${2:b}}", "", "a b", "1:0.0-0.3 2:0.2-0.3 0:0.3-0.3"},
+		{"choice", "${1|one,two|}", "", "one", "1:0.0-0.3|one|two 0:0.3-0.3"},
+		{"choice escape", `${1|a\,b,c|}`, "", "a,b", "1:0.0-0.3|a,b|c 0:0.3-0.3"},
+		{"transform mirror", "${1:foo} ${1/(.*)/${1:/upcase}/}", "", "foo FOO", "1:0.0-0.3,0.4-0.7* 0:0.7-0.7"},
+		{"variable", "// $TM_FILENAME", "", "// main.go", "0:0.10-0.10"},
+		{"variable braces", "${TM_FILENAME}!", "", "main.go!", "0:0.8-0.8"},
+		{"variable default", "${NOPE:def}", "", "def", "0:0.3-0.3"},
+		{"empty variable default", "${EMPTY:def}", "", "def", "0:0.3-0.3"},
+		{"empty variable", "[$EMPTY]", "", "[]", "0:0.2-0.2"},
+		{"unknown variable", "$NOPE $1 $NOPE", "", "NOPE  NOPE", "1:0.5-0.5 2:0.0-0.4,0.6-0.10 0:0.10-0.10"},
+		{"variable transform", "${TM_FILENAME/(.*)\\.go/$1/}", "", "main", "0:0.4-0.4"},
+		{"lines indented", "if {\n\t$1\n}", "  ", "if {\n  \t\n  }", "1:1.3-1.3 0:2.3-2.3"},
+		{"escapes", `\$1 \} \\`, "", `$1 } \`, "0:0.6-0.6"},
+		{"lone dollar", "cost $ 5", "", "cost $ 5", "0:0.8-0.8"},
+		{"unclosed", "${1:x", "", "${1:x", "0:0.5-0.5"},
+		{"carriage returns", "a\r\nb", "", "a\nb", "0:1.1-1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := Parse(tt.body).Expand(vars, tt.indent)
+			if exp.Text != tt.text {
+				t.Errorf("text = %q, want %q", exp.Text, tt.text)
+			}
+			if got := describe(exp.Stops); got != tt.stops {
+				t.Errorf("stops = %s, want %s", got, tt.stops)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	snippets := []*Snippet{
+		{Name: "for", Prefixes: []string{"for"}},
+		{Name: "forr", Prefixes: []string{"forr", "rfor"}},
+		{Name: "arrow", Prefixes: []string{"=>"}},
+	}
+	tests := []struct {
+		line  string
+		col   int
+		want  string // name, "" for no match
+		start int
+	}{
+		{"for", 3, "for", 0},
+		{"  forr", 6, "forr", 2},
+		{"x rfor", 6, "forr", 2},
+		{"before", 6, "", 0},
+		{"x=>", 3, "arrow", 1},
+		{"for x", 3, "for", 0},
+		{"fo", 2, "", 0},
+		{"éfor", 4, "", 0},
+		{"(for", 4, "for", 1},
+	}
+	for _, tt := range tests {
+		s, start, ok := Match(snippets, tt.line, tt.col)
+		name := ""
+		if ok {
+			name = s.Name
+		}
+		if name != tt.want || (ok && start != tt.start) {
+			t.Errorf("Match(%q, %d) = %q at %d, want %q at %d", tt.line, tt.col, name, start, tt.want, tt.start)
+		}
+	}
+}
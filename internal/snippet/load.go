@@ -0,0 +1,279 @@
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// Snippet is one entry of a snippets file
+type Snippet struct {
+	Name        string
+	Prefixes    []string
+	Body        string
+	Description string
+}
+
+// entry is a snippet as written in a file. Prefix and body may each be a
+// string or a list of strings, the lines of the body.
+type entry struct {
+	Prefix      json.RawMessage `json:"prefix"`
+	Body        json.RawMessage `json:"body"`
+	Description string          `json:"description"`
+	Scope       string          `json:"scope"` // filetypes of a .code-snippets file, comma separated
+}
+
+// Dir returns the directory snippets are loaded from
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vx", "snippets")
+}
+
+// CheckInterval is how often a library looks for changed files, which
+// takes a stat of each of them
+const CheckInterval = 2 * time.Second
+
+// Library holds the snippets of a directory: files named after a filetype,
+// such as go.json, for that filetype, and .code-snippets files for the
+// filetypes in each snippet's scope, or all of them. Files are read again
+// when they change, noticed within CheckInterval or on Reload.
+type Library struct {
+	dir     string
+	stamp   string                // names, sizes and times of the files last read
+	checked time.Time             // when the stamp was last compared
+	byType  map[string][]*Snippet // "" for snippets of every filetype
+}
+
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// For returns the snippets of a filetype. The error is that of reading the
+// files, when they were read again for this call.
+func (l *Library) For(filetype string) ([]*Snippet, error) {
+	var err error
+	if now := time.Now(); now.Sub(l.checked) >= CheckInterval {
+		l.checked = now
+		if stamp := l.currentStamp(); stamp != l.stamp {
+			l.stamp = stamp
+			err = l.load()
+		}
+	}
+	var list []*Snippet
+	if filetype != "" {
+		list = append(list, l.byType[filetype]...)
+	}
+	return append(list, l.byType[""]...), err
+}
+
+// Reload reads the files again, without waiting for the next check
+func (l *Library) Reload() error {
+	l.checked = time.Now()
+	l.stamp = l.currentStamp()
+	return l.load()
+}
+
+func (l *Library) currentStamp() string {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", e.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// load reads every snippets file, keeping what it can of files with errors
+func (l *Library) load() error {
+	l.byType = make(map[string][]*Snippet)
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var errs []string
+	for _, e := range entries {
+		name := e.Name()
+		ext := filepath.Ext(name)
+		if e.IsDir() || (ext != ".json" && ext != ".code-snippets") {
+			continue
+		}
+		if err := l.loadFile(filepath.Join(l.dir, name)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("snippets: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (l *Library) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var entries map[string]*entry
+	if err := json.Unmarshal(stripJSONC(data), &entries); err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	fileType := ""
+	if ext == ".json" {
+		fileType = filetypeOf(strings.TrimSuffix(filepath.Base(path), ext))
+	}
+
+	// The order in the file is lost, so keep them in name order
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := entries[name]
+		if e == nil {
+			continue
+		}
+		s := &Snippet{Name: name, Description: e.Description}
+		s.Prefixes, err = stringOrLines(e.Prefix)
+		if err != nil {
+			return fmt.Errorf("%s: prefix: %v", name, err)
+		}
+		body, err := stringOrLines(e.Body)
+		if err != nil {
+			return fmt.Errorf("%s: body: %v", name, err)
+		}
+		s.Body = strings.Join(body, "\n")
+
+		types := []string{fileType}
+		if ext == ".code-snippets" && e.Scope != "" {
+			types = nil
+			for _, scope := range strings.Split(e.Scope, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					types = append(types, filetypeOf(scope))
+				}
+			}
+		}
+		for _, t := range types {
+			l.byType[t] = append(l.byType[t], s)
+		}
+	}
+	return nil
+}
+
+// filetypeOf returns the filetype a snippets file or scope is named by,
+// such as "go" or "cpp"
+func filetypeOf(name string) string {
+	if lang, ok := highlight.FindLanguage(name); ok {
+		return lang
+	}
+	return strings.ToLower(name)
+}
+
+func stringOrLines(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}, nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return nil, fmt.Errorf("want a string or a list of strings")
+	}
+	return lines, nil
+}
+
+// stripJSONC removes the comments and trailing commas that snippet files,
+// like other VS Code settings, may have
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// Drop a comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.IndexByte(" \t\r\n", out[j]) >= 0 {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Match returns the snippet with a prefix ending the line before col, the
+// longest if several do, and the column the prefix starts at. A prefix
+// starting with a word character must start a word.
+func Match(snippets []*Snippet, line string, col int) (*Snippet, int, bool) {
+	runes := []rune(line)
+	col = min(col, len(runes))
+	before := string(runes[:col])
+	var best *Snippet
+	bestLen := 0
+	for _, s := range snippets {
+		for _, prefix := range s.Prefixes {
+			n := utf8.RuneCountInString(prefix)
+			if n == 0 || n <= bestLen || !strings.HasSuffix(before, prefix) {
+				continue
+			}
+			first, _ := utf8.DecodeRuneInString(prefix)
+			if start := col - n; start > 0 && isWordRune(first) && isWordRune(runes[start-1]) {
+				continue
+			}
+			best, bestLen = s, n
+		}
+	}
+	return best, col - bestLen, best != nil
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
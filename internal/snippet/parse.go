@@ -0,0 +1,319 @@
+// Package snippet expands snippets written in the VS Code snippet syntax:
+// text with tab stops ($1, ${1:placeholder}, ${1|one,two|}), the final
+// stop $0, variables ($TM_FILENAME, ${NAME:default}) and transforms
+// (${1/regex/format/flags}).
+package snippet
+
+import "strings"
+
+// Template is a parsed snippet body
+type Template struct {
+	nodes []node
+}
+
+type node interface{}
+
+type text string
+
+type tabStop struct {
+	index     int
+	children  []node // placeholder
+	choices   []string
+	transform *Transform
+}
+
+type variable struct {
+	name       string
+	children   []node // default
+	hasDefault bool
+	transform  *Transform
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+// Parse parses a snippet body. Anything that is not valid snippet syntax
+// is kept as text.
+func Parse(body string) *Template {
+	p := &parser{src: []rune(body)}
+	return &Template{nodes: p.parseAny(false)}
+}
+
+// parseAny parses text, tab stops and variables up to the end, or up to
+// an unescaped } when nested in a placeholder
+func (p *parser) parseAny(nested bool) []node {
+	var nodes []node
+	var buf []rune
+	flush := func() {
+		if len(buf) > 0 {
+			nodes = append(nodes, text(buf))
+			buf = nil
+		}
+	}
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune(`$}\`, p.src[p.pos+1]):
+			buf = append(buf, p.src[p.pos+1])
+			p.pos += 2
+		case r == '}' && nested:
+			flush()
+			return nodes
+		case r == '$':
+			start := p.pos
+			if n, ok := p.parseDollar(); ok {
+				flush()
+				nodes = append(nodes, n)
+				continue
+			}
+			// Not a tab stop or variable after all
+			p.pos = start + 1
+			buf = append(buf, '$')
+		default:
+			buf = append(buf, r)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes
+}
+
+// parseDollar parses the tab stop or variable starting at a $
+func (p *parser) parseDollar() (node, bool) {
+	p.pos++
+	if n, ok := p.number(); ok {
+		return &tabStop{index: n}, true
+	}
+	if name := p.name(); name != "" {
+		return &variable{name: name}, true
+	}
+	if !p.accept('{') {
+		return nil, false
+	}
+
+	if n, ok := p.number(); ok {
+		stop := &tabStop{index: n}
+		switch {
+		case p.accept('}'):
+			return stop, true
+		case p.accept(':'):
+			stop.children = p.parseAny(true)
+			return stop, p.accept('}')
+		case p.accept('|'):
+			choices, ok := p.choices()
+			stop.choices = choices
+			return stop, ok
+		case p.accept('/'):
+			t, ok := p.transform()
+			stop.transform = t
+			return stop, ok
+		}
+		return nil, false
+	}
+
+	if name := p.name(); name != "" {
+		v := &variable{name: name}
+		switch {
+		case p.accept('}'):
+			return v, true
+		case p.accept(':'):
+			v.children = p.parseAny(true)
+			v.hasDefault = true
+			return v, p.accept('}')
+		case p.accept('/'):
+			t, ok := p.transform()
+			v.transform = t
+			return v, ok
+		}
+	}
+	return nil, false
+}
+
+func (p *parser) accept(r rune) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) number() (int, bool) {
+	n, start := 0, p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		n = n*10 + int(p.src[p.pos]-'0')
+		p.pos++
+	}
+	return n, p.pos > start
+}
+
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (p.pos > start && r >= '0' && r <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return string(p.src[start:p.pos])
+}
+
+// choices parses the options of ${1|one,two|} after the first |
+func (p *parser) choices() ([]string, bool) {
+	var choices []string
+	var cur []rune
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune(`,|$}\`, p.src[p.pos+1]):
+			cur = append(cur, p.src[p.pos+1])
+			p.pos += 2
+		case r == ',':
+			choices = append(choices, string(cur))
+			cur = nil
+			p.pos++
+		case r == '|' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '}':
+			p.pos += 2
+			return append(choices, string(cur)), true
+		default:
+			cur = append(cur, r)
+			p.pos++
+		}
+	}
+	return nil, false
+}
+
+// transform parses /regex/format/flags} after the first /
+func (p *parser) transform() (*Transform, bool) {
+	var re []rune
+	for {
+		if p.pos >= len(p.src) {
+			return nil, false
+		}
+		r := p.src[p.pos]
+		p.pos++
+		if r == '/' {
+			break
+		}
+		if r == '\\' && p.pos < len(p.src) {
+			if p.src[p.pos] != '/' {
+				re = append(re, r)
+			}
+			r = p.src[p.pos]
+			p.pos++
+		}
+		re = append(re, r)
+	}
+	format, ok := p.format()
+	if !ok {
+		return nil, false
+	}
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '}' {
+		p.pos++
+	}
+	flags := string(p.src[start:p.pos])
+	if !p.accept('}') {
+		return nil, false
+	}
+	t, err := newTransform(string(re), format, flags)
+	return t, err == nil
+}
+
+// format parses the format of a transform up to its closing /
+func (p *parser) format() ([]formatPart, bool) {
+	var parts []formatPart
+	var buf []rune
+	flush := func() {
+		if len(buf) > 0 {
+			parts = append(parts, formatPart{text: string(buf), group: -1})
+			buf = nil
+		}
+	}
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\\' && p.pos+1 < len(p.src):
+			buf = append(buf, p.src[p.pos+1])
+			p.pos += 2
+		case r == '/':
+			p.pos++
+			flush()
+			return parts, true
+		case r == '$':
+			start := p.pos
+			if part, ok := p.formatGroup(); ok {
+				flush()
+				parts = append(parts, part)
+				continue
+			}
+			p.pos = start + 1
+			buf = append(buf, '$')
+		default:
+			buf = append(buf, r)
+			p.pos++
+		}
+	}
+	return nil, false
+}
+
+// formatGroup parses $1, ${1}, ${1:/upcase}, ${1:+if}, ${1:-else},
+// ${1:else} or ${1:?if:else}
+func (p *parser) formatGroup() (formatPart, bool) {
+	p.pos++
+	if n, ok := p.number(); ok {
+		return formatPart{group: n}, true
+	}
+	if !p.accept('{') {
+		return formatPart{}, false
+	}
+	n, ok := p.number()
+	if !ok {
+		return formatPart{}, false
+	}
+	part := formatPart{group: n}
+	switch {
+	case p.accept('}'):
+		return part, true
+	case !p.accept(':'):
+		return formatPart{}, false
+	case p.accept('/'):
+		part.modifier = p.name()
+		return part, p.accept('}')
+	case p.accept('+'):
+		part.ifText, ok = p.formatText(`}`)
+		part.conditional = true
+	case p.accept('?'):
+		part.ifText, ok = p.formatText(`:`)
+		if ok && p.accept(':') {
+			part.elseText, ok = p.formatText(`}`)
+		}
+		part.conditional = true
+	default:
+		p.accept('-')
+		part.elseText, ok = p.formatText(`}`)
+		part.conditional = true
+	}
+	return part, ok && p.accept('}')
+}
+
+// formatText reads text up to an unescaped rune of stop
+func (p *parser) formatText(stop string) (string, bool) {
+	var buf []rune
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if strings.ContainsRune(stop, r) {
+			return string(buf), true
+		}
+		if r == '\\' && p.pos+1 < len(p.src) {
+			p.pos++
+			r = p.src[p.pos]
+		}
+		buf = append(buf, r)
+		p.pos++
+	}
+	return "", false
+}
@@ -0,0 +1,131 @@
+package snippet
+
+// Edit is the text from Start to End replaced by text ending at NewEnd
+type Edit struct {
+	Start, End, NewEnd Pos
+}
+
+// Move returns where a position is after the edit. Positions in the
+// replaced text, or at an insertion, go to the start of the new text, or to
+// its end when after is true.
+func (ed Edit) Move(p Pos, after bool) Pos {
+	switch {
+	case p.Less(ed.Start):
+		return p
+	case ed.End.Less(p):
+		if p.Line == ed.End.Line {
+			return Pos{Line: ed.NewEnd.Line, Col: ed.NewEnd.Col + p.Col - ed.End.Col}
+		}
+		return Pos{Line: p.Line + ed.NewEnd.Line - ed.End.Line, Col: p.Col}
+	case after:
+		return ed.NewEnd
+	}
+	return ed.Start
+}
+
+// Session follows the tab stops of an expanded snippet while its text is
+// edited
+type Session struct {
+	stops   []Stop
+	outer   Range // the whole snippet
+	current int
+}
+
+// NewSession starts at the first tab stop of x, inserted at line and col
+func NewSession(x *Expansion, line, col int) *Session {
+	at := func(p Pos) Pos {
+		if p.Line == 0 {
+			p.Col += col
+		}
+		p.Line += line
+		return p
+	}
+	s := &Session{}
+	for _, stop := range x.Stops {
+		ranges := make([]Range, len(stop.Ranges))
+		for i, r := range stop.Ranges {
+			ranges[i] = Range{Start: at(r.Start), End: at(r.End), Transform: r.Transform}
+		}
+		stop.Ranges = ranges
+		s.stops = append(s.stops, stop)
+	}
+	var end Pos
+	for _, r := range x.Text {
+		if r == '\n' {
+			end.Line++
+			end.Col = 0
+		} else {
+			end.Col++
+		}
+	}
+	s.outer = Range{Start: at(Pos{}), End: at(end)}
+	return s
+}
+
+// Stop returns the current tab stop
+func (s *Session) Stop() *Stop {
+	return &s.stops[s.current]
+}
+
+// Final reports whether the current tab stop is the last one, $0
+func (s *Session) Final() bool {
+	return s.current == len(s.stops)-1
+}
+
+// Next moves to the next tab stop
+func (s *Session) Next() {
+	if s.current < len(s.stops)-1 {
+		s.current++
+	}
+}
+
+// Prev moves to the previous tab stop, returning false at the first
+func (s *Session) Prev() bool {
+	if s.current == 0 {
+		return false
+	}
+	s.current--
+	return true
+}
+
+// Contains reports whether p is in the snippet
+func (s *Session) Contains(p Pos) bool {
+	return !p.Less(s.outer.Start) && !s.outer.End.Less(p)
+}
+
+// Lines returns the first and last lines of the snippet
+func (s *Session) Lines() (top, bottom int) {
+	return s.outer.Start.Line, s.outer.End.Line
+}
+
+// MoveLines moves the snippet down by delta lines for lines added above it
+func (s *Session) MoveLines(delta int) {
+	for i := range s.stops {
+		for j := range s.stops[i].Ranges {
+			r := &s.stops[i].Ranges[j]
+			r.Start.Line += delta
+			r.End.Line += delta
+		}
+	}
+	s.outer.Start.Line += delta
+	s.outer.End.Line += delta
+}
+
+// Apply moves the tab stops for an edit. grow, the range being typed in,
+// takes in text inserted at its ends, as does the snippet; other ranges
+// keep out of it.
+func (s *Session) Apply(ed Edit, grow *Range) {
+	for i := range s.stops {
+		for j := range s.stops[i].Ranges {
+			r := &s.stops[i].Ranges[j]
+			sticky := r == grow
+			r.Start = ed.Move(r.Start, !sticky)
+			r.End = ed.Move(r.End, sticky)
+			if r.End.Less(r.Start) {
+				r.End = r.Start
+			}
+		}
+	}
+	s.outer.Start = ed.Move(s.outer.Start, false)
+	s.outer.End = ed.Move(s.outer.End, true)
+}
@@ -0,0 +1,125 @@
+package snippet
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transform rewrites the text of a variable or mirror with a regular
+// expression, as in ${TM_FILENAME/(.*)\.go/${1:/upcase}/}
+type Transform struct {
+	re     *regexp.Regexp
+	format []formatPart
+	global bool
+}
+
+// formatPart is literal text, or a group of the match when group >= 0
+type formatPart struct {
+	text        string
+	group       int
+	modifier    string // upcase, downcase, capitalize, camelcase or pascalcase
+	conditional bool   // ifText when the group matched, elseText otherwise
+	ifText      string
+	elseText    string
+}
+
+// newTransform compiles a transform. Of the flags, g replaces every match
+// and i, m and s are those of the regular expression.
+func newTransform(expr string, format []formatPart, flags string) (*Transform, error) {
+	t := &Transform{format: format}
+	var modes string
+	for _, f := range flags {
+		switch f {
+		case 'g':
+			t.global = true
+		case 'i', 'm', 's':
+			modes += string(f)
+		}
+	}
+	if modes != "" {
+		expr = "(?" + modes + ")" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	t.re = re
+	return t, nil
+}
+
+// Apply returns s transformed. A nil transform leaves s as it is.
+func (t *Transform) Apply(s string) string {
+	if t == nil {
+		return s
+	}
+	matches := t.re.FindAllStringSubmatchIndex(s, -1)
+	if !t.global && len(matches) > 1 {
+		matches = matches[:1]
+	}
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		t.expand(&b, s, m)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// expand writes the format for one match
+func (t *Transform) expand(b *strings.Builder, s string, m []int) {
+	for _, part := range t.format {
+		if part.group < 0 {
+			b.WriteString(part.text)
+			continue
+		}
+		value := ""
+		if i := part.group * 2; i+1 < len(m) && m[i] >= 0 {
+			value = s[m[i]:m[i+1]]
+		}
+		switch {
+		case part.conditional && value != "":
+			b.WriteString(part.ifText)
+		case part.conditional:
+			b.WriteString(part.elseText)
+		default:
+			b.WriteString(modify(value, part.modifier))
+		}
+	}
+}
+
+// modify changes the case of a group's text
+func modify(s, modifier string) string {
+	if s == "" {
+		return s
+	}
+	switch modifier {
+	case "upcase":
+		return strings.ToUpper(s)
+	case "downcase":
+		return strings.ToLower(s)
+	case "capitalize":
+		r, size := utf8.DecodeRuneInString(s)
+		return string(unicode.ToUpper(r)) + s[size:]
+	case "camelcase", "pascalcase":
+		words := strings.FieldsFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for i, w := range words {
+			if i == 0 && modifier == "camelcase" {
+				r, size := utf8.DecodeRuneInString(w)
+				words[i] = string(unicode.ToLower(r)) + w[size:]
+				continue
+			}
+			words[i] = modify(w, "capitalize")
+		}
+		return strings.Join(words, "")
+	}
+	return s
+}
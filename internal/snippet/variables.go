@@ -0,0 +1,56 @@
+package snippet
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// Variable returns the value of a variable that does not depend on the
+// editor, such as CURRENT_YEAR or UUID, or false if name is not one
+func Variable(name string, now time.Time) (string, bool) {
+	switch name {
+	case "CURRENT_YEAR":
+		return now.Format("2006"), true
+	case "CURRENT_YEAR_SHORT":
+		return now.Format("06"), true
+	case "CURRENT_MONTH":
+		return now.Format("01"), true
+	case "CURRENT_MONTH_NAME":
+		return now.Format("January"), true
+	case "CURRENT_MONTH_NAME_SHORT":
+		return now.Format("Jan"), true
+	case "CURRENT_DATE":
+		return now.Format("02"), true
+	case "CURRENT_DAY_NAME":
+		return now.Format("Monday"), true
+	case "CURRENT_DAY_NAME_SHORT":
+		return now.Format("Mon"), true
+	case "CURRENT_HOUR":
+		return now.Format("15"), true
+	case "CURRENT_MINUTE":
+		return now.Format("04"), true
+	case "CURRENT_SECOND":
+		return now.Format("05"), true
+	case "CURRENT_SECONDS_UNIX":
+		return strconv.FormatInt(now.Unix(), 10), true
+	case "CURRENT_TIMEZONE_OFFSET":
+		return now.Format("-07:00"), true
+	case "RANDOM":
+		n, _ := rand.Int(rand.Reader, big.NewInt(1000000))
+		return fmt.Sprintf("%06d", n), true
+	case "RANDOM_HEX":
+		b := make([]byte, 3)
+		rand.Read(b)
+		return fmt.Sprintf("%x", b), true
+	case "UUID":
+		b := make([]byte, 16)
+		rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), true
+	}
+	return "", false
+}
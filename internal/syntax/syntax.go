@@ -1,79 +1,281 @@
 package syntax
 
 import (
-	"strings"
-
 	"github.com/Adelodunpeter25/vx/internal/buffer"
 	"github.com/Adelodunpeter25/vx/pkg/highlight"
 )
 
-const MaxHighlightLines = 10000 // Don't highlight files larger than this
+// MaxHighlightLines is the largest file lexed from its first line. Larger
+// files are lexed in a window around the lines being drawn.
+const MaxHighlightLines = 10000
+
+const (
+	lookahead  = 128 // lines lexed at least once lexing starts
+	windowLead = 200 // lines above the drawn ones a window starts lexing
+)
 
+// Engine highlights a buffer incrementally. Lines are lexed on demand and
+// cached; after an edit, lexing restarts at the nearest safe line before it
+// and stops as soon as the lexer is back in the state it had before the edit.
+// A line is safe when it ends outside any string or comment. Tokens that the
+// lexer only recognises by looking past the edit, such as an earlier
+// unterminated string closed by the edit, are not re-lexed.
+//
+// Files over MaxHighlightLines are highlighted approximately: the cache starts
+// a little above the drawn lines, which are assumed to begin in a safe state,
+// and the lexer only sees a bounded window of text.
 type Engine struct {
-	highlighter    *highlight.Highlighter
-	enabled        bool
-	cache          map[int][]highlight.StyledRune
-	cachedVersion  int
-	tooLarge       bool
+	highlighter *highlight.Highlighter
+	enabled     bool
+	base        int    // buffer line of lines[0]
+	lines       []line // cached lines; lines[:valid] are current
+	valid       int
+	version     int
+}
+
+// line is a cached line. Edited lines are kept as placeholders so the lines
+// after them still line up with the buffer.
+type line struct {
+	highlight.Line
+	edited bool
 }
 
 func New(filename string) *Engine {
 	return &Engine{
-		highlighter:   highlight.New(filename),
-		enabled:       true,
-		cache:         make(map[int][]highlight.StyledRune),
-		cachedVersion: -1,
+		highlighter: highlight.New(filename),
+		enabled:     true,
+		version:     -1,
 	}
 }
 
-func (e *Engine) HighlightLine(lineNum int, line string, buf *buffer.Buffer) []highlight.StyledRune {
-	if !e.enabled || e.tooLarge {
+func (e *Engine) HighlightLine(lineNum int, text string, buf *buffer.Buffer) []highlight.StyledRune {
+	if !e.enabled {
 		return nil
 	}
-	
-	// Check if buffer is too large for highlighting
-	if buf.LineCount() > MaxHighlightLines {
-		e.tooLarge = true
-		e.cache = nil // Free memory
-		return nil
+	return e.line(lineNum, buf).Runes
+}
+
+// Tokens returns the tokens of a line, lexed even when highlighting is off
+func (e *Engine) Tokens(lineNum int, buf *buffer.Buffer) []highlight.Token {
+	return e.line(lineNum, buf).Tokens
+}
+
+// line returns a line, lexing up to it if it isn't cached
+func (e *Engine) line(lineNum int, buf *buffer.Buffer) highlight.Line {
+	if lineNum < 0 || lineNum >= buf.LineCount() {
+		return highlight.Line{}
+	}
+
+	e.sync(buf)
+	e.moveWindow(lineNum, buf.LineCount() > MaxHighlightLines)
+	for e.base+e.valid <= lineNum {
+		e.lex(buf, lineNum)
+	}
+	return e.lines[lineNum-e.base].Line
+}
+
+// moveWindow restarts the cache just above line n when a windowed file is
+// drawn out of reach of the cached lines, and drops lines scrolled far above.
+//
+// Nothing is known about the lines above a new window, so lexing starts
+// windowLead lines up as if that line began outside any string or comment.
+// Highlighting there is best-effort: a string or comment open across the
+// start of the window shows wrong until a line that closes it.
+func (e *Engine) moveWindow(n int, windowed bool) {
+	switch {
+	case !windowed:
+		if e.base > 0 {
+			e.reset(0)
+		}
+	case n < e.base || n > e.base+e.valid+windowLead:
+		e.reset(max(0, n-windowLead))
+	default:
+		if drop := n - e.base - windowLead; drop > windowLead && drop < e.valid {
+			e.lines = e.lines[drop:]
+			e.base += drop
+			e.valid -= drop
+		}
+	}
+}
+
+// sync brings the cache in line with edits made since the last call
+func (e *Engine) sync(buf *buffer.Buffer) {
+	version := buf.ModVersion()
+	if version == e.version {
+		return
+	}
+	changes, ok := buf.ChangesSince(e.version)
+	if !ok {
+		e.InvalidateCache()
+		e.version = version
+		return
+	}
+	e.version = version
+	for _, c := range changes {
+		e.apply(c)
+	}
+	if total := buf.LineCount() - e.base; len(e.lines) > total {
+		if total < 0 {
+			e.reset(0)
+			return
+		}
+		e.lines = e.lines[:total]
+		e.valid = min(e.valid, total)
 	}
-	
-	// Only re-highlight if buffer version changed
-	currentVersion := buf.ModVersion()
-	if e.cachedVersion != currentVersion {
-		e.highlightBuffer(buf)
-		e.cachedVersion = currentVersion
-	}
-	
-	if styled, ok := e.cache[lineNum]; ok {
-		return styled
-	}
-	
-	return e.highlighter.HighlightLine(line)
-}
-
-func (e *Engine) highlightBuffer(buf *buffer.Buffer) {
-	e.cache = make(map[int][]highlight.StyledRune)
-	
-	// Build full text
-	var fullText strings.Builder
-	for i := 0; i < buf.LineCount(); i++ {
-		if i > 0 {
-			fullText.WriteString("\n")
+}
+
+// apply shifts the cache for one edit and marks the edited lines
+func (e *Engine) apply(c buffer.Change) {
+	n := c.Line - e.base
+	if n < 0 {
+		// Lines added or removed above the window move it
+		if c.Delta != 0 {
+			e.reset(max(0, e.base+c.Delta))
 		}
-		fullText.WriteString(buf.Line(i))
+		return
 	}
-	
-	// Highlight entire buffer
-	lines := e.highlighter.HighlightText(fullText.String())
-	for i, line := range lines {
-		e.cache[i] = line
+	if n >= len(e.lines) {
+		e.valid = min(e.valid, n)
+		return
+	}
+
+	after := n + 1
+	switch {
+	case c.Delta > 0:
+		inserted := make([]line, c.Delta)
+		for i := range inserted {
+			inserted[i].edited = true
+		}
+		e.lines = append(e.lines[:after], append(inserted, e.lines[after:]...)...)
+	case c.Delta < 0:
+		end := min(after-c.Delta, len(e.lines))
+		e.lines = append(e.lines[:after], e.lines[end:]...)
 	}
+	e.lines[n] = line{edited: true}
+	e.valid = min(e.valid, n)
+}
+
+// lex re-lexes from the checkpoint before the first stale line until line n
+// is current, or until the lexer reaches a line it left in the same state
+// before the edit. The cache is then current up to the next edited line.
+//
+// The lexer sees the lines up to lookahead past the stopping line, not the
+// rest of the file. A token cut short by the end of them leaves the lexer
+// out of sync, or fails to match and shows as an error, so then it lexes
+// again from the same line with twice as many. Windowed files keep the
+// bounded chunk.
+func (e *Engine) lex(buf *buffer.Buffer, n int) {
+	total := buf.LineCount() - e.base
+	start := e.checkpoint(e.valid)
+	stop := max(n+1-e.base, start+lookahead)
+	windowed := buf.LineCount() > MaxHighlightLines
+	for size := stop + lookahead - start; ; size *= 2 {
+		end := min(total, start+size)
+		lexed, synced, converged := e.lexChunk(buf, start, end, stop)
+		if (!synced || hasError(lexed)) && end < total && !windowed {
+			continue
+		}
+
+		for i, l := range lexed {
+			e.store(start+i, l)
+		}
+		next := start + len(lexed)
+		switch {
+		case next >= end:
+			e.valid = end
+		case converged:
+			e.valid = e.nextEdited(next)
+		default:
+			e.valid = next
+		}
+		if next >= total {
+			e.lines = e.lines[:total]
+		}
+		return
+	}
+}
+
+// lexChunk lexes lines start to end of the cache until it is in sync: it
+// reaches line stop at a safe line, or converges on a line it left in the
+// same state before the edit. It returns the lines lexed without storing
+// them, so a chunk too short to get in sync leaves the cache as it was.
+func (e *Engine) lexChunk(buf *buffer.Buffer, start, end, stop int) (lexed []highlight.Line, synced, converged bool) {
+	text := make([]string, end-start)
+	for i := range text {
+		text[i] = buf.Line(e.base + start + i)
+	}
+
+	num := start
+	e.highlighter.Lex(text, func(l highlight.Line) bool {
+		next := num + 1
+		converged = l.Safe && next > e.valid && next < len(e.lines) &&
+			!e.lines[num].edited && e.lines[num].Safe
+		lexed = append(lexed, l)
+		num = next
+		synced = converged || l.Safe && next >= stop
+		return !synced
+	})
+	return lexed, synced, converged
+}
+
+// hasError reports whether any of the lines has text the lexer didn't match
+func hasError(lines []highlight.Line) bool {
+	for _, l := range lines {
+		if l.HasError() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkpoint returns the nearest line at or before n where lexing can start
+func (e *Engine) checkpoint(n int) int {
+	for n > 0 && !e.lines[n-1].Safe {
+		n--
+	}
+	return n
+}
+
+// nextEdited returns the first edited line at or after n
+func (e *Engine) nextEdited(n int) int {
+	for n < len(e.lines) && !e.lines[n].edited {
+		n++
+	}
+	return n
+}
+
+func (e *Engine) store(n int, l highlight.Line) {
+	if n < len(e.lines) {
+		e.lines[n] = line{Line: l}
+	} else {
+		e.lines = append(e.lines, line{Line: l})
+	}
+}
+
+// reset empties the cache and starts it at buffer line base
+func (e *Engine) reset(base int) {
+	e.base = base
+	e.lines = nil
+	e.valid = 0
 }
 
 func (e *Engine) InvalidateCache() {
-	e.cachedVersion = -1
-	e.cache = make(map[int][]highlight.StyledRune)
+	e.reset(0)
+	e.version = -1
+}
+
+// SetLanguage switches the language the buffer is highlighted as
+func (e *Engine) SetLanguage(language string) {
+	if name, _ := highlight.FindLanguage(language); name == e.Language() {
+		return
+	}
+	e.highlighter = highlight.NewForLanguage(language)
+	e.InvalidateCache()
+}
+
+// Language returns the language being highlighted, or "" for plain text
+func (e *Engine) Language() string {
+	return e.highlighter.Language()
 }
 
 func (e *Engine) Toggle() {
@@ -83,7 +285,3 @@ func (e *Engine) Toggle() {
 func (e *Engine) IsEnabled() bool {
 	return e.enabled
 }
-
-func (e *Engine) IsTooLarge() bool {
-	return e.tooLarge
-}
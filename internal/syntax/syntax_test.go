@@ -0,0 +1,97 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Adelodunpeter25/vx/internal/buffer"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// colors returns the foreground color of each rune of a line
+func colors(l highlight.Line) string {
+	var b strings.Builder
+	for _, r := range l.Runes {
+		fg, _, _ := r.Style.Decompose()
+		fmt.Fprintf(&b, "%v,", fg)
+	}
+	return b.String()
+}
+
+// spanning returns a file with a construct that spans more lines than a
+// chunk, between an opening and a closing line, and code after it
+func spanning(open, inside, close, after string) []string {
+	lines := []string{open}
+	for i := 0; i < 600; i++ {
+		lines = append(lines, fmt.Sprintf(inside, i))
+	}
+	lines = append(lines, close)
+	for i := 0; i < 300; i++ {
+		lines = append(lines, fmt.Sprintf(after, i))
+	}
+	return lines
+}
+
+// checkLines checks that the engine highlights lines of buf in order the
+// way lexing the whole buffer at once does
+func checkLines(t *testing.T, e *Engine, name string, buf *buffer.Buffer, order []int) {
+	t.Helper()
+	lines := make([]string, buf.LineCount())
+	for i := range lines {
+		lines[i] = buf.Line(i)
+	}
+	var want []highlight.Line
+	highlight.New(name).Lex(lines, func(l highlight.Line) bool {
+		want = append(want, l)
+		return true
+	})
+	for _, n := range order {
+		if got := e.line(n, buf); colors(got) != colors(want[n]) {
+			t.Fatalf("line %d %q is highlighted differently than in the whole file", n, lines[n])
+		}
+	}
+}
+
+func newBuffer(lines []string) *buffer.Buffer {
+	buf := buffer.New()
+	buf.ReplaceRange(0, 0, 0, 0, strings.Join(lines, "\n"))
+	return buf
+}
+
+func TestLexLongConstructs(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"x.go", spanning("var s = `", "raw %d // not a comment", "`", "func f%d() { return \"s\" } // c")},
+		{"x.c", spanning("/*", "  int y%d = \"q\";", "*/", "int w%d = 2; // c")},
+		{"x.py", spanning("s = '''", "def f%d(): return 1 # c", "'''", "z%d = 'a' # c")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := newBuffer(tt.lines)
+			e := New(tt.name)
+			checkLines(t, e, tt.name, buf, []int{0, 1, 100, 300, 601, 602, 700, 901})
+
+			// Starting in the middle lexes from the top
+			e.InvalidateCache()
+			checkLines(t, e, tt.name, buf, []int{500, 650, 901, 10})
+		})
+	}
+}
+
+func TestLexAfterEdit(t *testing.T) {
+	lines := spanning("var s = `", "raw %d // not a comment", "`", "func f%d() { return \"s\" } // c")
+	buf := newBuffer(lines)
+	e := New("x.go")
+	checkLines(t, e, "x.go", buf, []int{0, 300, 700})
+
+	// Closing the raw string early turns the rest of it into code, and the
+	// old closing backtick opens a new one
+	buf.ReplaceRange(5, 0, 5, 0, "`")
+	checkLines(t, e, "x.go", buf, []int{5, 6, 300, 601, 602, 700})
+
+	buf.ReplaceRange(5, 0, 5, 1, "")
+	checkLines(t, e, "x.go", buf, []int{4, 5, 6, 601, 602, 901})
+}
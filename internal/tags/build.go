@@ -0,0 +1,94 @@
+package tags
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/Adelodunpeter25/vx/internal/grep"
+	"github.com/Adelodunpeter25/vx/internal/outline"
+	"github.com/Adelodunpeter25/vx/internal/syntax"
+	"github.com/Adelodunpeter25/vx/internal/utils"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+const (
+	maxLineBytes = 1024 * 1024 // longest line a file can have before it is skipped
+	detectLines  = 100         // lines looked at to detect a file's language
+)
+
+// Build indexes the files below root that a project search would visit,
+// finding symbols from their tokens as the outline does. Files over
+// syntax.MaxHighlightLines lines are skipped. It returns nil if ctx is
+// cancelled.
+func Build(ctx context.Context, root string, hidden bool) *Index {
+	paths := make(chan string, 256)
+	go grep.Files(ctx, root, hidden, paths)
+
+	var mu sync.Mutex
+	var tags []Tag
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				found := FromFile(path)
+				mu.Lock()
+				tags = append(tags, found...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return newIndex(root, "", tags)
+}
+
+// FromFile returns the tags of a single file
+func FromFile(path string) []Tag {
+	if binary, err := utils.IsBinaryFile(path); err != nil || binary {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		if len(lines) == syntax.MaxHighlightLines {
+			return nil
+		}
+		lines = append(lines, scanner.Text())
+	}
+	if scanner.Err() != nil {
+		return nil
+	}
+
+	tail := lines[max(0, len(lines)-highlight.ModelineLines):]
+	language := highlight.Detect(path, lines[:min(len(lines), detectLines)], tail)
+	if language == "" {
+		return nil
+	}
+	return FromSymbols(path, outline.Extract(language, lines))
+}
+
+// FromSymbols turns the symbols of a file, other than headings, into tags
+func FromSymbols(path string, symbols []outline.Symbol) []Tag {
+	var tags []Tag
+	for _, s := range symbols {
+		if s.Kind == outline.Heading {
+			continue
+		}
+		tags = append(tags, Tag{Name: s.Name, Path: path, Line: s.Line, Kind: s.Kind.String()})
+	}
+	return tags
+}
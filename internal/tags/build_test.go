@@ -0,0 +1,50 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Adelodunpeter25/vx/internal/theme"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// TestBuildWhileStyling indexes a project while tokens are styled with a
+// theme, as the renderer does on the main goroutine. Run it with -race.
+func TestBuildWhileStyling(t *testing.T) {
+	th := theme.FromChroma(styles.Get("monokai"))
+	highlight.SetStyle(th.TokenStyle)
+	defer highlight.SetStyle(nil)
+
+	root := t.TempDir()
+	const files = 20
+	for i := range files {
+		src := fmt.Sprintf("package p\n\n// F%d is a func\nfunc F%d() int { return %d }\n\ntype T%d struct{ s string }\n", i, i, i, i)
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("f%d.go", i)), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan *Index)
+	go func() { done <- Build(context.Background(), root, false) }()
+	h := highlight.NewForLanguage("go")
+	var idx *Index
+	for idx == nil {
+		select {
+		case idx = <-done:
+		default:
+			th.Fit(256)
+			h.Lex([]string{`func f() string { return "x" } // y`}, func(highlight.Line) bool { return true })
+		}
+	}
+
+	if got := idx.Len(); got != 2*files {
+		t.Errorf("indexed %d tags, want %d", got, 2*files)
+	}
+	if got := idx.Lookup("T7", ""); len(got) != 1 || got[0].Path != filepath.Join(root, "f7.go") {
+		t.Errorf("Lookup(T7) = %+v", got)
+	}
+}
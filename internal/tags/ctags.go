@@ -0,0 +1,123 @@
+package tags
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileNames are the tags files looked for in a project root
+var FileNames = []string{"tags", ".tags"}
+
+// FindFile returns the tags file in root, or "" if there is none
+func FindFile(root string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(root, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadFile reads a tags file written by universal-ctags or exuberant-ctags.
+// File names in it are relative to the directory holding it.
+func LoadFile(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	var tags []Tag
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "!_") {
+			continue
+		}
+		tag, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filepath.Base(path), lineNum, err)
+		}
+		if !filepath.IsAbs(tag.Path) {
+			tag.Path = filepath.Join(dir, tag.Path)
+		}
+		tags = append(tags, tag)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newIndex(dir, path, tags), nil
+}
+
+// parseLine parses `name<TAB>file<TAB>address;"<TAB>fields`
+func parseLine(line string) (Tag, error) {
+	name, rest, ok := strings.Cut(line, "\t")
+	if !ok {
+		return Tag{}, fmt.Errorf("not a tag line")
+	}
+	path, rest, ok := strings.Cut(rest, "\t")
+	if !ok {
+		return Tag{}, fmt.Errorf("not a tag line")
+	}
+	address, fields, _ := strings.Cut(rest, ";\"\t")
+	address = strings.TrimSuffix(address, ";\"")
+
+	tag := Tag{Name: name, Path: path, Line: -1}
+	if n, err := strconv.Atoi(address); err == nil {
+		tag.Line = n - 1
+	} else if len(address) >= 2 && (address[0] == '/' || address[0] == '?') {
+		tag.Pattern, tag.Exact = parsePattern(address)
+	} else {
+		return Tag{}, fmt.Errorf("bad address: %s", address)
+	}
+
+	for _, field := range strings.Split(fields, "\t") {
+		key, value, ok := strings.Cut(field, ":")
+		switch {
+		case !ok && field != "":
+			tag.Kind = field
+		case key == "kind":
+			tag.Kind = value
+		case key == "line":
+			if n, err := strconv.Atoi(value); err == nil {
+				tag.Line = n - 1
+			}
+		}
+	}
+	return tag, nil
+}
+
+// parsePattern turns a search address such as /^func main() {$/ into the
+// text of the line. exact is false when the pattern isn't anchored at the
+// end, as ctags does for very long lines.
+func parsePattern(address string) (text string, exact bool) {
+	delim := address[0]
+	body := address[1:]
+	if strings.HasSuffix(body, string(delim)) {
+		body = body[:len(body)-1]
+	}
+	body = strings.TrimPrefix(body, "^")
+	if strings.HasSuffix(body, "$") && !strings.HasSuffix(body, `\$`) {
+		body = body[:len(body)-1]
+		exact = true
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' && i+1 < len(body) {
+			i++
+		}
+		b.WriteByte(body[i])
+	}
+	return b.String(), exact
+}
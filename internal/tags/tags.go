@@ -0,0 +1,99 @@
+package tags
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Tag is the definition of a symbol in a file
+type Tag struct {
+	Name    string
+	Path    string // absolute
+	Line    int    // 0-based, or -1 when only Pattern is known
+	Pattern string // text of the defining line, from a tags file
+	Exact   bool   // Pattern is the whole line rather than its start
+	Kind    string
+}
+
+// Lines is a file's text, used to find a tag given by pattern
+type Lines interface {
+	LineCount() int
+	Line(n int) string
+}
+
+// Find returns the line the tag is on, or -1 if it can't be found
+func (t Tag) Find(lines Lines) int {
+	if t.Line >= 0 {
+		return min(t.Line, lines.LineCount()-1)
+	}
+	for n := 0; n < lines.LineCount(); n++ {
+		line := lines.Line(n)
+		if line == t.Pattern || (!t.Exact && strings.HasPrefix(line, t.Pattern)) {
+			return n
+		}
+	}
+	return -1
+}
+
+// Index holds the tags of a project
+type Index struct {
+	Root   string
+	Source string // tags file the index was loaded from, or "" if it was built
+	tags   []Tag
+	byName map[string][]int
+}
+
+func newIndex(root, source string, tags []Tag) *Index {
+	idx := &Index{Root: root, Source: source, tags: tags}
+	idx.sort()
+	return idx
+}
+
+// sort orders the tags by path and line and rebuilds the name lookup
+func (idx *Index) sort() {
+	sort.SliceStable(idx.tags, func(i, j int) bool {
+		a, b := idx.tags[i], idx.tags[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	idx.byName = make(map[string][]int, len(idx.tags))
+	for i, t := range idx.tags {
+		idx.byName[t.Name] = append(idx.byName[t.Name], i)
+		// M.f and Foo::bar are also found by their last part
+		if j := strings.LastIndexAny(t.Name, ".:"); j >= 0 && j < len(t.Name)-1 {
+			idx.byName[t.Name[j+1:]] = append(idx.byName[t.Name[j+1:]], i)
+		}
+	}
+}
+
+// Len returns the number of tags
+func (idx *Index) Len() int {
+	return len(idx.tags)
+}
+
+// All returns every tag, ordered by path and line
+func (idx *Index) All() []Tag {
+	return idx.tags
+}
+
+// Lookup returns the tags named name. Tags in the file prefer come first.
+func (idx *Index) Lookup(name, prefer string) []Tag {
+	var found []Tag
+	for _, i := range idx.byName[name] {
+		found = append(found, idx.tags[i])
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Path == prefer && found[j].Path != prefer
+	})
+	return found
+}
+
+// Replace swaps the tags of one file, e.g. with those of an edited buffer
+func (idx *Index) Replace(path string, tags []Tag) {
+	idx.tags = slices.DeleteFunc(idx.tags, func(t Tag) bool { return t.Path == path })
+	idx.tags = append(idx.tags, tags...)
+	idx.sort()
+}
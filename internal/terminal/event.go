@@ -9,6 +9,7 @@ const (
 	EventResize
 	EventMouse
 	EventQuit
+	EventWake
 )
 
 type Event struct {
@@ -32,6 +33,8 @@ func (t *Terminal) ReadEvent() *Event {
 	case *tcell.EventResize:
 		t.screen.Sync()
 		return &Event{Type: EventResize}
+	case *tcell.EventInterrupt:
+		return &Event{Type: EventWake}
 	case *tcell.EventMouse:
 		x, y := ev.Position()
 		return &Event{
@@ -43,3 +46,9 @@ func (t *Terminal) ReadEvent() *Event {
 	}
 	return nil
 }
+
+// Wake interrupts a blocked ReadEvent so background work can be picked up.
+// It is safe to call from any goroutine.
+func (t *Terminal) Wake() {
+	_ = t.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
@@ -3,7 +3,20 @@ package terminal
 import "github.com/gdamore/tcell/v2"
 
 func (t *Terminal) SetCell(x, y int, r rune, style tcell.Style) {
-	t.screen.SetContent(x, y, r, nil, style)
+	t.screen.SetContent(x, y, r, nil, t.withBase(style))
+}
+
+// withBase replaces default colors in style with the base style's
+func (t *Terminal) withBase(style tcell.Style) tcell.Style {
+	fg, bg, _ := style.Decompose()
+	baseFg, baseBg, _ := t.base.Decompose()
+	if fg == tcell.ColorDefault {
+		style = style.Foreground(baseFg)
+	}
+	if bg == tcell.ColorDefault {
+		style = style.Background(baseBg)
+	}
+	return style
 }
 
 func (t *Terminal) DrawText(x, y int, text string, style tcell.Style) {
@@ -6,6 +6,7 @@ import (
 
 type Terminal struct {
 	screen tcell.Screen
+	base   tcell.Style // colors used where a style leaves the default
 }
 
 func New() (*Terminal, error) {
@@ -29,6 +30,18 @@ func (t *Terminal) Size() (width, height int) {
 	return t.screen.Size()
 }
 
+// Colors returns the number of colors the terminal can show
+func (t *Terminal) Colors() int {
+	return t.screen.Colors()
+}
+
+// SetBaseStyle sets the foreground and background drawn in place of the
+// terminal's default colors
+func (t *Terminal) SetBaseStyle(style tcell.Style) {
+	t.base = style
+	t.screen.SetStyle(style)
+}
+
 func (t *Terminal) Clear() {
 	t.screen.Clear()
 }
@@ -0,0 +1,144 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// file is the contents of a theme file. Entries use Chroma's style syntax,
+// e.g. "bold #f8f8f2 bg:#272822".
+type file struct {
+	Base   string            `json:"base"`   // theme to start from
+	Syntax map[string]string `json:"syntax"` // by Chroma token type, e.g. "NameFunction"
+	UI     map[string]string `json:"ui"`     // by UI element, e.g. "statusbar"
+}
+
+// Dir returns the directory user themes are loaded from
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vx", "themes")
+}
+
+// Load finds a theme by name: a theme file path, a file in Dir named
+// name.toml or name.json, the built-in theme, or a Chroma style
+func Load(name string) (*Theme, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("no color scheme given")
+	}
+	if strings.ContainsRune(name, os.PathSeparator) || filepath.Ext(name) == ".toml" || filepath.Ext(name) == ".json" {
+		return loadFile(name, nil)
+	}
+	if dir := Dir(); dir != "" {
+		for _, ext := range []string{".toml", ".json"} {
+			path := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return loadFile(path, nil)
+			}
+		}
+	}
+	return builtin(name)
+}
+
+// builtin returns the default theme or a Chroma style by name
+func builtin(name string) (*Theme, error) {
+	lower := strings.ToLower(name)
+	if lower == DefaultName {
+		return Default(), nil
+	}
+	if style, ok := styles.Registry[lower]; ok {
+		return FromChroma(style), nil
+	}
+	return nil, fmt.Errorf("unknown color scheme: %s", name)
+}
+
+// loadFile reads a TOML or JSON theme file. seen guards against themes
+// that use each other as a base.
+func loadFile(path string, seen map[string]bool) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &f)
+	} else {
+		err = parseTOML(string(data), &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+
+	t := Default()
+	if f.Base != "" {
+		if seen == nil {
+			seen = make(map[string]bool)
+		}
+		seen[path] = true
+		t, err = loadBase(f.Base, filepath.Dir(path), seen)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+		}
+	}
+	t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if err := f.apply(t); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+	return t, nil
+}
+
+// loadBase loads the theme a theme file builds on
+func loadBase(name, dir string, seen map[string]bool) (*Theme, error) {
+	for _, ext := range []string{".toml", ".json"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("theme %s uses itself as a base", name)
+		}
+		return loadFile(path, seen)
+	}
+	return builtin(name)
+}
+
+// apply sets the file's entries on t
+func (f *file) apply(t *Theme) error {
+	fields := t.UI.fields()
+	for name, value := range f.UI {
+		s, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("unknown ui element: %s", name)
+		}
+		entry, err := chroma.ParseStyleEntry(value)
+		if err != nil {
+			return fmt.Errorf("ui %s: %v", name, err)
+		}
+		*s = apply(*s, entry)
+	}
+
+	for name, value := range f.Syntax {
+		tt, err := chroma.TokenTypeString(name)
+		if err != nil {
+			return fmt.Errorf("unknown token type: %s", name)
+		}
+		entry, err := chroma.ParseStyleEntry(value)
+		if err != nil {
+			return fmt.Errorf("syntax %s: %v", name, err)
+		}
+		if t.overrides == nil {
+			t.overrides = make(map[chroma.TokenType]chroma.StyleEntry)
+		}
+		t.overrides[tt] = entry
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package theme
+
+import (
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/gdamore/tcell/v2"
+)
+
+// DefaultName is the name of the built-in theme
+const DefaultName = "default"
+
+// UI holds the styles of the editor outside of syntax tokens
+type UI struct {
+	Normal        tcell.Style // text and background
+	StatusBar     tcell.Style
+	StatusError   tcell.Style
+	LineNumber    tcell.Style
+	Selection     tcell.Style
+	IndentGuide   tcell.Style
+	SearchMatch   tcell.Style
+	SearchCurrent tcell.Style
+	MatchBracket  tcell.Style
+	NonText       tcell.Style // "~" below the end of the buffer
+	Divider       tcell.Style
+	Folded        tcell.Style // fold marker and the text after a closed fold
+	Popup         tcell.Style // hover text and other boxes over the panes
+	Error         tcell.Style // diagnostic signs by severity
+	Warning       tcell.Style
+	Info          tcell.Style
+	Hint          tcell.Style
+	GitAdd        tcell.Style // git gutter signs
+	GitChange     tcell.Style
+	GitDelete     tcell.Style
+}
+
+// fields maps the element names used in theme files to their styles
+func (u *UI) fields() map[string]*tcell.Style {
+	return map[string]*tcell.Style{
+		"normal":        &u.Normal,
+		"statusbar":     &u.StatusBar,
+		"statuserror":   &u.StatusError,
+		"linenumber":    &u.LineNumber,
+		"selection":     &u.Selection,
+		"indentguide":   &u.IndentGuide,
+		"searchmatch":   &u.SearchMatch,
+		"searchcurrent": &u.SearchCurrent,
+		"matchbracket":  &u.MatchBracket,
+		"nontext":       &u.NonText,
+		"divider":       &u.Divider,
+		"folded":        &u.Folded,
+		"popup":         &u.Popup,
+		"error":         &u.Error,
+		"warning":       &u.Warning,
+		"info":          &u.Info,
+		"hint":          &u.Hint,
+		"gitadd":        &u.GitAdd,
+		"gitchange":     &u.GitChange,
+		"gitdelete":     &u.GitDelete,
+	}
+}
+
+// Theme is a color scheme for syntax tokens and the editor UI
+type Theme struct {
+	Name string
+	UI   UI
+
+	style     *chroma.Style // nil for the built-in token colors
+	overrides map[chroma.TokenType]chroma.StyleEntry
+	colors    int
+	tokens    map[chroma.TokenType]tcell.Style
+}
+
+// Default returns the built-in theme
+func Default() *Theme {
+	return &Theme{
+		Name: DefaultName,
+		UI: UI{
+			Normal:        tcell.StyleDefault,
+			StatusBar:     tcell.StyleDefault.Reverse(true),
+			StatusError:   tcell.StyleDefault.Reverse(true).Foreground(tcell.ColorRed).Bold(true),
+			LineNumber:    tcell.StyleDefault.Foreground(tcell.NewRGBColor(100, 100, 100)),
+			Selection:     tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorBlack),
+			IndentGuide:   tcell.StyleDefault.Foreground(tcell.ColorGray).Dim(true),
+			SearchMatch:   tcell.StyleDefault.Background(tcell.NewRGBColor(80, 80, 80)).Foreground(tcell.ColorWhite).Bold(true),
+			SearchCurrent: tcell.StyleDefault.Background(tcell.NewRGBColor(0, 200, 200)).Foreground(tcell.ColorBlack).Bold(true),
+			MatchBracket:  tcell.StyleDefault.Background(tcell.NewRGBColor(255, 200, 0)).Foreground(tcell.ColorBlack).Bold(true),
+			NonText:       tcell.StyleDefault.Foreground(tcell.ColorBlue),
+			Divider:       tcell.StyleDefault.Foreground(tcell.ColorGray),
+			Folded:        tcell.StyleDefault.Foreground(tcell.ColorGray).Bold(true),
+			Popup:         tcell.StyleDefault.Background(tcell.NewRGBColor(50, 50, 60)).Foreground(tcell.ColorWhite),
+			Error:         tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true),
+			Warning:       tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true),
+			Info:          tcell.StyleDefault.Foreground(tcell.ColorBlue),
+			Hint:          tcell.StyleDefault.Foreground(tcell.ColorGray),
+			GitAdd:        tcell.StyleDefault.Foreground(tcell.ColorGreen),
+			GitChange:     tcell.StyleDefault.Foreground(tcell.ColorYellow),
+			GitDelete:     tcell.StyleDefault.Foreground(tcell.ColorRed),
+		},
+	}
+}
+
+// FromChroma returns a theme using a Chroma style. UI colors come from the
+// style's background, line number and line highlight entries.
+func FromChroma(style *chroma.Style) *Theme {
+	t := Default()
+	t.Name = style.Name
+	t.style = style
+
+	bg := style.Get(chroma.Background)
+	t.UI.Normal = apply(tcell.StyleDefault, bg)
+	numbers := style.Get(chroma.LineNumbers)
+	if numbers.Colour.IsSet() && numbers.Colour != bg.Colour {
+		gutter := tcell.StyleDefault.Foreground(color(numbers.Colour))
+		t.UI.LineNumber = gutter
+		t.UI.NonText = gutter
+		t.UI.Divider = gutter
+		t.UI.Folded = gutter.Bold(true)
+		t.UI.IndentGuide = gutter.Dim(true)
+		t.UI.Hint = gutter
+	}
+	if added := style.Get(chroma.GenericInserted); added.Colour.IsSet() {
+		t.UI.GitAdd = tcell.StyleDefault.Foreground(color(added.Colour))
+	}
+	if deleted := style.Get(chroma.GenericDeleted); deleted.Colour.IsSet() {
+		t.UI.GitDelete = tcell.StyleDefault.Foreground(color(deleted.Colour))
+	}
+	if hl := style.Get(chroma.LineHighlight); hl.Background.IsSet() && hl.Background != bg.Background {
+		t.UI.Selection = tcell.StyleDefault.Background(color(hl.Background))
+		t.UI.Popup = t.UI.Normal.Background(color(hl.Background))
+	}
+	return t
+}
+
+// TokenStyle returns the style a token type is drawn with
+func (t *Theme) TokenStyle(tt chroma.TokenType) tcell.Style {
+	if s, ok := t.tokens[tt]; ok {
+		return s
+	}
+
+	var s tcell.Style
+	if t.style != nil {
+		s = t.UI.Normal
+		entry := t.style.Get(tt)
+		normalBg := t.style.Get(chroma.Background).Background
+		if entry.Background == normalBg {
+			entry.Background = 0
+		}
+		s = apply(s, entry)
+	} else {
+		s = highlight.DefaultStyle(tt)
+	}
+	for _, key := range []chroma.TokenType{tt.Category(), tt.SubCategory(), tt} {
+		if entry, ok := t.overrides[key]; ok {
+			s = apply(s, entry)
+		}
+	}
+	s = t.fit(s)
+
+	if t.tokens == nil {
+		t.tokens = make(map[chroma.TokenType]tcell.Style)
+	}
+	t.tokens[tt] = s
+	return s
+}
+
+// Fit adapts the theme to a terminal showing colors colors. Below 256
+// colors the background is left to the terminal, since few themes survive
+// being reduced to 16 colors with it.
+func (t *Theme) Fit(colors int) {
+	t.colors = colors
+	t.tokens = nil
+	if colors > 0 && colors < 256 {
+		_, bg, _ := t.UI.Normal.Decompose()
+		t.UI.Normal = t.UI.Normal.Background(tcell.ColorDefault)
+		t.dropBackground(bg)
+	}
+	for _, s := range t.UI.fields() {
+		*s = t.fit(*s)
+	}
+}
+
+// dropBackground clears the background of UI elements drawn on bg
+func (t *Theme) dropBackground(bg tcell.Color) {
+	if bg == tcell.ColorDefault {
+		return
+	}
+	for _, s := range t.UI.fields() {
+		if _, b, _ := s.Decompose(); b == bg {
+			*s = s.Background(tcell.ColorDefault)
+		}
+	}
+}
+
+// fit maps RGB colors in s to the nearest color the terminal can show
+func (t *Theme) fit(s tcell.Style) tcell.Style {
+	if t.colors <= 0 || t.colors >= 1<<24 {
+		return s
+	}
+	palette := make([]tcell.Color, min(t.colors, 256))
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+	fg, bg, _ := s.Decompose()
+	if fg.IsRGB() {
+		s = s.Foreground(tcell.FindColor(fg, palette))
+	}
+	if bg.IsRGB() {
+		s = s.Background(tcell.FindColor(bg, palette))
+	}
+	return s
+}
+
+// apply sets the colors and attributes of a Chroma style entry on s
+func apply(s tcell.Style, e chroma.StyleEntry) tcell.Style {
+	if e.Colour.IsSet() {
+		s = s.Foreground(color(e.Colour))
+	}
+	if e.Background.IsSet() {
+		s = s.Background(color(e.Background))
+	}
+	if e.Bold != chroma.Pass {
+		s = s.Bold(e.Bold == chroma.Yes)
+	}
+	if e.Italic != chroma.Pass {
+		s = s.Italic(e.Italic == chroma.Yes)
+	}
+	if e.Underline != chroma.Pass {
+		s = s.Underline(e.Underline == chroma.Yes)
+	}
+	return s
+}
+
+func color(c chroma.Colour) tcell.Color {
+	return tcell.NewRGBColor(int32(c.Red()), int32(c.Green()), int32(c.Blue()))
+}
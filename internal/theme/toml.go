@@ -0,0 +1,100 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML reads the subset of TOML theme files use: comments, a top-level
+// base key, and [syntax] and [ui] tables of string values
+func parseTOML(data string, f *file) error {
+	table := ""
+	for i, raw := range strings.Split(data, "\n") {
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: invalid table header", i+1)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if table != "syntax" && table != "ui" {
+				return fmt.Errorf("line %d: unknown table [%s]", i+1, table)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key, err := unquote(strings.TrimSpace(key))
+		if err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+		value, err = unquote(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		switch table {
+		case "":
+			if key != "base" {
+				return fmt.Errorf("line %d: unknown key %s", i+1, key)
+			}
+			f.Base = value
+		case "syntax":
+			if f.Syntax == nil {
+				f.Syntax = make(map[string]string)
+			}
+			f.Syntax[key] = value
+		case "ui":
+			if f.UI == nil {
+				f.UI = make(map[string]string)
+			}
+			f.UI[key] = value
+		}
+	}
+	return nil
+}
+
+// stripComment removes a # comment that is not inside a string
+func stripComment(line string) string {
+	quote := rune(0)
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote == '"':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote returns a bare key or the contents of a basic or literal string
+func unquote(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s == "" || strings.ContainsAny(s, " \t\"'"):
+		return "", fmt.Errorf("invalid value %q", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,116 @@
+// Package tool reads the configuration of the external tools run on files,
+// such as formatters and linters, and starts them.
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Adelodunpeter25/vx/internal/utils"
+	"github.com/Adelodunpeter25/vx/pkg/highlight"
+)
+
+// Tool is a command run on a file
+type Tool struct {
+	Command []string `json:"command"` // ${file} stands for the path of the file
+}
+
+// Name returns the tool's command name, with its subcommand for tools such
+// as go vet
+func (t *Tool) Name() string {
+	if len(t.Command) == 0 {
+		return ""
+	}
+	name := filepath.Base(t.Command[0])
+	if name == "go" && len(t.Command) > 1 {
+		name += " " + t.Command[1]
+	}
+	return name
+}
+
+// Cmd returns the command run on filename. It runs next to the file, so the
+// tool finds the project's settings.
+func (t *Tool) Cmd(ctx context.Context, filename string) *exec.Cmd {
+	args := make([]string, len(t.Command))
+	for i, arg := range t.Command {
+		args[i] = strings.ReplaceAll(arg, "${file}", filename)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = filepath.Dir(filename)
+	return cmd
+}
+
+// Failure returns the error of a run that failed with err: that it timed
+// out, or else the first line of output, what the tool wrote about it
+func (t *Tool) Failure(ctx context.Context, timeout time.Duration, output string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s: timed out after %v", t.Name(), timeout)
+	}
+	if msg := utils.FirstLine(output); msg != "" {
+		return fmt.Errorf("%s: %s", t.Name(), msg)
+	}
+	return fmt.Errorf("%s: %v", t.Name(), err)
+}
+
+// Config maps filetypes to tools
+type Config map[string]*Tool
+
+// ConfigDir returns the directory config files are read from
+func ConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vx")
+}
+
+// LoadConfig returns the installed tools of defaults overridden by file in
+// the config directory, a JSON object of filetypes to tools, e.g.
+//
+//	{"go": {"command": ["goimports"]}, "md": null}
+//
+// where null turns a default tool off.
+func LoadConfig(file string, defaults Config) (Config, error) {
+	config := make(Config, len(defaults))
+	for filetype, t := range defaults {
+		if _, err := exec.LookPath(t.Command[0]); err == nil {
+			config[filetype] = t
+		}
+	}
+	dir := ConfigDir()
+	if dir == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	var user Config
+	if err := json.Unmarshal(data, &user); err != nil {
+		return config, fmt.Errorf("%s: %v", file, err)
+	}
+	for filetype, t := range user {
+		if lang, ok := highlight.FindLanguage(filetype); ok {
+			filetype = lang
+		}
+		if t == nil {
+			delete(config, filetype)
+			continue
+		}
+		if len(t.Command) == 0 {
+			return config, fmt.Errorf("%s: %s: no command given", file, filetype)
+		}
+		config[filetype] = t
+	}
+	return config, nil
+}
@@ -7,6 +7,7 @@ type Action struct {
 	Col      int
 	Text     string
 	OldText  string
+	Group    int // actions with the same non-zero group undo together
 }
 
 type ActionType int
@@ -18,12 +19,16 @@ const (
 	ActionDeleteLine
 	ActionSplitLine
 	ActionJoinLine
+	ActionReplaceText
 )
 
 // Stack manages undo/redo history
 type Stack struct {
-	actions []Action
-	current int
+	actions   []Action
+	current   int
+	group     int
+	lastGroup int
+	depth     int
 }
 
 func NewStack() *Stack {
@@ -40,10 +45,71 @@ func (s *Stack) Push(action Action) {
 		s.actions = s.actions[:s.current+1]
 	}
 	
+	action.Group = s.group
 	s.actions = append(s.actions, action)
 	s.current++
 }
 
+// BeginGroup starts collecting actions into a single undo step. Groups nest;
+// only the outermost EndGroup closes the step.
+func (s *Stack) BeginGroup() {
+	if s.depth == 0 {
+		s.lastGroup++
+		s.group = s.lastGroup
+	}
+	s.depth++
+}
+
+// EndGroup closes the group started by BeginGroup
+func (s *Stack) EndGroup() {
+	if s.depth == 0 {
+		return
+	}
+	s.depth--
+	if s.depth == 0 {
+		s.group = 0
+	}
+}
+
+// ResumeGroup reopens group id while it still holds the newest action, so
+// changes made over several calls undo as one step; otherwise it begins a
+// new group. It returns the id of the open group, for the next call.
+func (s *Stack) ResumeGroup(id int) int {
+	if s.depth == 0 && id != 0 && s.current >= 0 && s.current == len(s.actions)-1 && s.actions[s.current].Group == id {
+		s.group = id
+		s.depth = 1
+		return id
+	}
+	s.BeginGroup()
+	return s.group
+}
+
+// UndoGroup returns the actions of the next undo step, most recent first
+func (s *Stack) UndoGroup() []Action {
+	action := s.Undo()
+	if action == nil {
+		return nil
+	}
+	actions := []Action{*action}
+	for action.Group != 0 && s.current >= 0 && s.actions[s.current].Group == action.Group {
+		actions = append(actions, *s.Undo())
+	}
+	return actions
+}
+
+// RedoGroup returns the actions of the next redo step, oldest first
+func (s *Stack) RedoGroup() []Action {
+	action := s.Redo()
+	if action == nil {
+		return nil
+	}
+	actions := []Action{*action}
+	for action.Group != 0 && s.current+1 < len(s.actions) && s.actions[s.current+1].Group == action.Group {
+		actions = append(actions, *s.Redo())
+	}
+	return actions
+}
+
 // Undo returns the action to undo, or nil if nothing to undo
 func (s *Stack) Undo() *Action {
 	if s.current < 0 {
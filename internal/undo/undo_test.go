@@ -0,0 +1,142 @@
+package undo
+
+import (
+	"slices"
+	"testing"
+)
+
+// lines returns the Line of each action, which the tests use to tell them
+// apart
+func lines(actions []Action) []int {
+	out := make([]int, len(actions))
+	for i, a := range actions {
+		out[i] = a.Line
+	}
+	return out
+}
+
+func TestUndoGroup(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(s *Stack)
+		steps [][]int // lines of each undo step, most recent first
+	}{
+		{"ungrouped", func(s *Stack) {
+			s.Push(Action{Line: 1})
+			s.Push(Action{Line: 2})
+		}, [][]int{{2}, {1}}},
+		{"group", func(s *Stack) {
+			s.Push(Action{Line: 1})
+			s.BeginGroup()
+			s.Push(Action{Line: 2})
+			s.Push(Action{Line: 3})
+			s.EndGroup()
+			s.Push(Action{Line: 4})
+		}, [][]int{{4}, {3, 2}, {1}}},
+		{"nested", func(s *Stack) {
+			s.BeginGroup()
+			s.Push(Action{Line: 1})
+			s.BeginGroup()
+			s.Push(Action{Line: 2})
+			s.EndGroup()
+			s.Push(Action{Line: 3})
+			s.EndGroup()
+		}, [][]int{{3, 2, 1}}},
+		{"adjacent groups", func(s *Stack) {
+			s.BeginGroup()
+			s.Push(Action{Line: 1})
+			s.Push(Action{Line: 2})
+			s.EndGroup()
+			s.BeginGroup()
+			s.Push(Action{Line: 3})
+			s.EndGroup()
+		}, [][]int{{3}, {2, 1}}},
+		{"empty group", func(s *Stack) {
+			s.Push(Action{Line: 1})
+			s.BeginGroup()
+			s.EndGroup()
+			s.Push(Action{Line: 2})
+		}, [][]int{{2}, {1}}},
+		{"resumed group", func(s *Stack) {
+			id := s.ResumeGroup(0)
+			s.Push(Action{Line: 1})
+			s.EndGroup()
+			s.ResumeGroup(id)
+			s.Push(Action{Line: 2})
+			s.EndGroup()
+		}, [][]int{{2, 1}}},
+		{"resume after another push", func(s *Stack) {
+			id := s.ResumeGroup(0)
+			s.Push(Action{Line: 1})
+			s.EndGroup()
+			s.Push(Action{Line: 2})
+			s.ResumeGroup(id)
+			s.Push(Action{Line: 3})
+			s.EndGroup()
+		}, [][]int{{3}, {2}, {1}}},
+		{"resume after undo", func(s *Stack) {
+			id := s.ResumeGroup(0)
+			s.Push(Action{Line: 1})
+			s.Push(Action{Line: 2})
+			s.EndGroup()
+			s.Undo()
+			s.ResumeGroup(id)
+			s.Push(Action{Line: 3})
+			s.EndGroup()
+		}, [][]int{{3}, {1}}},
+		{"unbalanced end", func(s *Stack) {
+			s.EndGroup()
+			s.Push(Action{Line: 1})
+			s.BeginGroup()
+			s.Push(Action{Line: 2})
+			s.EndGroup()
+			s.EndGroup()
+			s.Push(Action{Line: 3})
+		}, [][]int{{3}, {2}, {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStack()
+			tt.build(s)
+			for i, want := range tt.steps {
+				if got := lines(s.UndoGroup()); !slices.Equal(got, want) {
+					t.Fatalf("undo step %d = %v, want %v", i, got, want)
+				}
+			}
+			if got := s.UndoGroup(); got != nil {
+				t.Errorf("undo past the start = %v, want nil", lines(got))
+			}
+
+			// Redo gives the steps back oldest first
+			for i := len(tt.steps) - 1; i >= 0; i-- {
+				want := slices.Clone(tt.steps[i])
+				slices.Reverse(want)
+				if got := lines(s.RedoGroup()); !slices.Equal(got, want) {
+					t.Fatalf("redo of step %d = %v, want %v", i, got, want)
+				}
+			}
+			if got := s.RedoGroup(); got != nil {
+				t.Errorf("redo past the end = %v, want nil", lines(got))
+			}
+		})
+	}
+}
+
+func TestPushClearsRedo(t *testing.T) {
+	s := NewStack()
+	s.BeginGroup()
+	s.Push(Action{Line: 1})
+	s.Push(Action{Line: 2})
+	s.EndGroup()
+	s.UndoGroup()
+	s.Push(Action{Line: 3})
+	if s.CanRedo() {
+		t.Error("CanRedo after a push")
+	}
+	if got := lines(s.UndoGroup()); !slices.Equal(got, []int{3}) {
+		t.Errorf("undo = %v, want [3]", got)
+	}
+	if s.CanUndo() {
+		t.Error("CanUndo with the undone group dropped")
+	}
+}
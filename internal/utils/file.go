@@ -2,6 +2,7 @@ package utils
 
 import (
 	"os"
+	"path/filepath"
 	"unicode/utf8"
 )
 
@@ -10,6 +11,19 @@ const (
 	MaxLines    = 1000000            // 1 million lines
 )
 
+// CanonicalPath returns path made absolute with its symlinks resolved, so
+// that two paths to the same file compare equal. A path that can't be
+// resolved, such as one that doesn't exist, is only made absolute.
+func CanonicalPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
 // IsFileTooLarge checks if a file exceeds safe size limits
 func IsFileTooLarge(filename string) (bool, int64, error) {
 	info, err := os.Stat(filename)
@@ -76,3 +90,49 @@ func CountLines(filename string) (int, error) {
 	
 	return count, nil
 }
+
+// WriteFileAtomic writes data to a temporary file that then replaces path,
+// so a failure never leaves the file half written. A symlink is followed so
+// the file it points to is replaced rather than the link, and the file
+// keeps its owner where the system allows it.
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".vx-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		keepOwner(tmp, info)
+	}
+	if err := tmp.Chmod(mode.Perm()); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
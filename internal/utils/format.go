@@ -1,6 +1,9 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // FormatFileSize formats bytes into human-readable size
 func FormatFileSize(bytes int64) string {
@@ -31,3 +34,14 @@ func FormatLineCount(count int) string {
 func FormatFileInfo(filename string, size int64, lines int) string {
 	return fmt.Sprintf("\"%s\" %s, %s", filename, FormatFileSize(size), FormatLineCount(lines))
 }
+
+// FirstLine returns the first line of s that isn't blank, trimmed, such as
+// the line of a command's output worth showing as its error
+func FirstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
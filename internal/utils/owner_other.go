@@ -0,0 +1,8 @@
+//go:build !unix
+
+package utils
+
+import "os"
+
+// keepOwner does nothing where files have no Unix owner
+func keepOwner(f *os.File, info os.FileInfo) {}
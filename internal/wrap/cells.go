@@ -0,0 +1,31 @@
+package wrap
+
+// Cells returns the screen column each rune of text starts at, with the
+// width of the whole text as a last element. Tabs advance to the next
+// multiple of tabstop; every other rune takes one column.
+func Cells(text string, tabstop int) []int {
+	if tabstop < 1 {
+		tabstop = 1
+	}
+	cells := make([]int, 0, len(text)+1)
+	col := 0
+	for _, r := range text {
+		cells = append(cells, col)
+		if r == '\t' {
+			col += tabstop - col%tabstop
+		} else {
+			col++
+		}
+	}
+	return append(cells, col)
+}
+
+// segment returns the runes start to end of text, with their columns
+// counted from base
+func segment(runes []rune, cells []int, start, end, base, lineNum int) Line {
+	cols := make([]int, end-start+1)
+	for i := range cols {
+		cols[i] = cells[start+i] - base
+	}
+	return Line{Text: string(runes[start:end]), StartCol: start, LineNum: lineNum, IsWrapped: start > 0, Cells: cols}
+}
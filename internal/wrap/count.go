@@ -1,28 +1,18 @@
 package wrap
 
 // VisualLineCount returns how many screen rows a line takes when wrapped
-func VisualLineCount(text string, maxWidth int) int {
+func VisualLineCount(text string, maxWidth, tabstop int) int {
 	if maxWidth <= 0 {
 		return 1
 	}
-	
-	length := len([]rune(text))
-	if length == 0 {
-		return 1
-	}
-	
-	count := length / maxWidth
-	if length%maxWidth != 0 {
-		count++
-	}
-	return count
+	return len(WrapLine(text, 0, maxWidth, tabstop))
 }
 
 // TotalVisualLines calculates total screen rows for a range of buffer lines
-func TotalVisualLines(lines []string, startLine, endLine, maxWidth int) int {
+func TotalVisualLines(lines []string, startLine, endLine, maxWidth, tabstop int) int {
 	total := 0
 	for i := startLine; i <= endLine && i < len(lines); i++ {
-		total += VisualLineCount(lines[i], maxWidth)
+		total += VisualLineCount(lines[i], maxWidth, tabstop)
 	}
 	return total
 }
@@ -6,4 +6,43 @@ type Line struct {
 	StartCol   int // Column in original line where this segment starts
 	LineNum    int // Original line number
 	IsWrapped  bool // True if this is a continuation (not first segment)
+	Cells      []int // Screen column each rune starts at, and the segment's width last
+}
+
+// X returns the screen column, counted from the start of the segment, that
+// column col of the original line is drawn at. Columns past the end of the
+// segment are one cell wide.
+func (l Line) X(col int) int {
+	i := col - l.StartCol
+	if l.Cells == nil || i < 0 {
+		return i
+	}
+	last := len(l.Cells) - 1
+	if i > last {
+		return l.Cells[last] + i - last
+	}
+	return l.Cells[i]
+}
+
+// Col returns the column of the original line drawn at screen column x of
+// the segment, or the column just past the segment when x is beyond it
+func (l Line) Col(x int) int {
+	if l.Cells == nil {
+		return l.StartCol + min(x, len([]rune(l.Text)))
+	}
+	last := len(l.Cells) - 1
+	for i := 0; i < last; i++ {
+		if x < l.Cells[i+1] {
+			return l.StartCol + i
+		}
+	}
+	return l.StartCol + last
+}
+
+// Width returns how many screen columns the segment takes
+func (l Line) Width() int {
+	if l.Cells == nil {
+		return len([]rune(l.Text))
+	}
+	return l.Cells[len(l.Cells)-1]
 }
@@ -0,0 +1,23 @@
+package wrap
+
+// Window returns the part of a line visible when scrolled horizontally by
+// offset screen columns, for use when wrapping is disabled. A tab only
+// partly scrolled out of view is left out.
+func Window(text string, lineNum, offset, width, tabstop int) Line {
+	runes := []rune(text)
+	cells := Cells(text, tabstop)
+	if offset < 0 {
+		offset = 0
+	}
+	start := 0
+	for start < len(runes) && cells[start] < offset {
+		start++
+	}
+	end := start
+	for end < len(runes) && (width <= 0 || cells[end+1]-offset <= width) {
+		end++
+	}
+	line := segment(runes, cells, start, end, offset, lineNum)
+	line.IsWrapped = false
+	return line
+}
@@ -1,31 +1,25 @@
 package wrap
 
 // WrapLine wraps a single line into segments that fit within maxWidth
-func WrapLine(text string, lineNum, maxWidth int) []Line {
-	if maxWidth <= 0 {
-		return []Line{{Text: text, StartCol: 0, LineNum: lineNum, IsWrapped: false}}
-	}
-	
+// screen columns, with tabs expanded to tabstop
+func WrapLine(text string, lineNum, maxWidth, tabstop int) []Line {
 	runes := []rune(text)
-	if len(runes) <= maxWidth {
-		return []Line{{Text: text, StartCol: 0, LineNum: lineNum, IsWrapped: false}}
+	cells := Cells(text, tabstop)
+	if maxWidth <= 0 || cells[len(runes)] <= maxWidth {
+		return []Line{segment(runes, cells, 0, len(runes), 0, lineNum)}
 	}
 	
 	var segments []Line
 	startCol := 0
 	
 	for startCol < len(runes) {
-		endCol := startCol + maxWidth
-		if endCol > len(runes) {
-			endCol = len(runes)
+		// Every segment takes at least one rune, even a tab wider than maxWidth
+		endCol := startCol + 1
+		for endCol < len(runes) && cells[endCol+1]-cells[startCol] <= maxWidth {
+			endCol++
 		}
 		
-		segments = append(segments, Line{
-			Text:      string(runes[startCol:endCol]),
-			StartCol:  startCol,
-			LineNum:   lineNum,
-			IsWrapped: startCol > 0,
-		})
+		segments = append(segments, segment(runes, cells, startCol, endCol, cells[startCol], lineNum))
 		
 		startCol = endCol
 	}
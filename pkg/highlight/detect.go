@@ -0,0 +1,155 @@
+package highlight
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// ModelineLines is how many lines at the start and end of a file are searched
+// for a modeline
+const ModelineLines = 5
+
+var (
+	// vim: set ft=go :   vi: filetype=python   vx: ft=sh
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vim?|ex|vx):.*?\b(?:ft|filetype|syn|syntax)=([\w+#.-]+)`)
+	// -*- mode: python -*-   -*- python -*-
+	emacsModeline = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+)
+
+// interpreters maps shebang interpreters to languages where the names differ
+var interpreters = map[string]string{
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"deno":       "typescript",
+	"pwsh":       "powershell",
+	"runhaskell": "haskell",
+	"tclsh":      "tcl",
+	"wish":       "tcl",
+	"escript":    "erlang",
+	"rdmd":       "d",
+}
+
+// Detect returns the language of a file from, in order of preference, a
+// modeline, its name, its shebang line and its content. head holds the first
+// lines of the file and tail the last ones. It returns "" when nothing
+// matches.
+func Detect(filename string, head, tail []string) string {
+	if name := modeline(head, tail); name != "" {
+		return name
+	}
+	if filename != "" {
+		if lexer := matchFilename(filepath.Base(filename)); lexer != nil {
+			return languageName(lexer)
+		}
+	}
+	if len(head) > 0 {
+		if name := shebang(head[0]); name != "" {
+			return name
+		}
+	}
+	if lexer := lexers.Analyse(strings.Join(head, "\n")); lexer != nil {
+		return languageName(lexer)
+	}
+	return ""
+}
+
+// FindLanguage returns the canonical name of a language given its name, an
+// alias or a file extension
+func FindLanguage(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	lexer := lexers.Get(name)
+	if lexer == nil {
+		return "", false
+	}
+	return languageName(lexer), true
+}
+
+// matchFilename matches a file name, then its name without the last
+// extension, so that Dockerfile.prod is a Dockerfile
+func matchFilename(base string) chroma.Lexer {
+	if lexer := lexers.Match(base); lexer != nil {
+		return lexer
+	}
+	if ext := filepath.Ext(base); ext != "" && ext != base {
+		return lexers.Match(strings.TrimSuffix(base, ext))
+	}
+	return nil
+}
+
+func modeline(head, tail []string) string {
+	lines := head[:min(len(head), ModelineLines)]
+	lines = append(lines[:len(lines):len(lines)], tail...)
+	for _, line := range lines {
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			if name, ok := FindLanguage(m[1]); ok {
+				return name
+			}
+		}
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			if name, ok := FindLanguage(emacsMode(m[1])); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// emacsMode returns the mode from the variables of an Emacs modeline
+func emacsMode(vars string) string {
+	if !strings.Contains(vars, ":") {
+		return strings.TrimSpace(vars)
+	}
+	for _, v := range strings.Split(vars, ";") {
+		key, value, ok := strings.Cut(v, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "mode") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// shebang returns the language of the interpreter named on a #! line
+func shebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		// Skip env's flags and variable assignments
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = f
+				break
+			}
+		}
+	}
+	// python3.12 -> python
+	interp = strings.TrimRight(interp, "0123456789.")
+	if lang, ok := interpreters[interp]; ok {
+		interp = lang
+	}
+	name, _ := FindLanguage(interp)
+	return name
+}
+
+// languageName is the name a lexer is known by in options and the status bar
+func languageName(lexer chroma.Lexer) string {
+	config := lexer.Config()
+	name := strings.ToLower(config.Name)
+	if len(config.Aliases) == 0 || slices.Contains(config.Aliases, name) {
+		return name
+	}
+	return config.Aliases[0]
+}
@@ -21,6 +21,26 @@ func New(filename string) *Highlighter {
 	return &Highlighter{lexer: lexer}
 }
 
+// NewForLanguage creates a highlighter for a language name or alias. An empty
+// or unknown name gives plain text.
+func NewForLanguage(language string) *Highlighter {
+	lexer := lexers.Fallback
+	if language != "" {
+		if l := lexers.Get(language); l != nil {
+			lexer = l
+		}
+	}
+	return &Highlighter{lexer: chroma.Coalesce(lexer)}
+}
+
+// Language returns the name of the highlighted language, or "" for plain text
+func (h *Highlighter) Language() string {
+	if h.lexer == nil || h.lexer.Config().Name == lexers.Fallback.Config().Name {
+		return ""
+	}
+	return languageName(h.lexer)
+}
+
 func (h *Highlighter) HighlightText(text string) [][]StyledRune {
 	if h.lexer == nil {
 		return plainTextLines(text)
@@ -35,7 +55,7 @@ func (h *Highlighter) HighlightText(text string) [][]StyledRune {
 	var currentLine []StyledRune
 
 	for _, token := range iterator.Tokens() {
-		style := tokenToStyle(token.Type)
+		style := tokenStyle(token.Type)
 		for _, r := range token.Value {
 			if r == '\n' {
 				lines = append(lines, currentLine)
@@ -56,6 +76,99 @@ func (h *Highlighter) HighlightText(text string) [][]StyledRune {
 	return lines
 }
 
+// Line is one highlighted line of a larger text
+type Line struct {
+	Runes  []StyledRune
+	Tokens []Token
+	Safe   bool // ended outside any string or comment, so lexing can restart after it
+}
+
+// HasError reports whether the lexer failed to match some of the line, as
+// it does where a token was cut off by the end of the text
+func (l Line) HasError() bool {
+	for _, t := range l.Tokens {
+		if t.Type == chroma.Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Lex lexes lines as one text and passes each highlighted line to fn in
+// order. Tokens are produced lazily, so lexing stops once fn returns false.
+func (h *Highlighter) Lex(lines []string, fn func(Line) bool) {
+	h.lex(lines, true, fn)
+}
+
+// LexTokens is Lex without styling: the lines passed to fn have tokens but
+// no runes. It never calls the style function set with SetStyle, so it is
+// safe to use off the main goroutine.
+func (h *Highlighter) LexTokens(lines []string, fn func(Line) bool) {
+	h.lex(lines, false, fn)
+}
+
+func (h *Highlighter) lex(lines []string, styled bool, fn func(Line) bool) {
+	plain := func(line string) []StyledRune {
+		if !styled {
+			return nil
+		}
+		return plainText(line)
+	}
+
+	var iterator chroma.Iterator
+	var err error
+	if h.lexer != nil {
+		iterator, err = h.lexer.Tokenise(nil, strings.Join(lines, "\n")+"\n")
+	}
+	if h.lexer == nil || err != nil {
+		for _, line := range lines {
+			if !fn(Line{Runes: plain(line), Safe: true}) {
+				return
+			}
+		}
+		return
+	}
+
+	n, col := 0, 0
+	var current []StyledRune
+	var tokens []Token
+	for token := iterator(); token != chroma.EOF && n < len(lines); token = iterator() {
+		var style tcell.Style
+		if styled {
+			style = tokenStyle(token.Type)
+		}
+		value := token.Value
+		for {
+			part, rest, newline := strings.Cut(value, "\n")
+			if strings.TrimSpace(part) != "" {
+				tokens = append(tokens, Token{Type: token.Type, Col: col, Text: part})
+			}
+			for _, r := range part {
+				if styled {
+					current = append(current, StyledRune{Rune: r, Style: style})
+				}
+				col++
+			}
+			if !newline {
+				break
+			}
+			if !fn(Line{Runes: current, Tokens: tokens, Safe: isPlain(token.Type)}) {
+				return
+			}
+			current, tokens, col = nil, nil, 0
+			if n++; n == len(lines) {
+				return
+			}
+			value = rest
+		}
+	}
+	for ; n < len(lines); n++ {
+		if !fn(Line{Runes: plain(lines[n])}) {
+			return
+		}
+	}
+}
+
 func (h *Highlighter) HighlightLine(line string) []StyledRune {
 	if h.lexer == nil {
 		return plainText(line)
@@ -68,7 +181,7 @@ func (h *Highlighter) HighlightLine(line string) []StyledRune {
 
 	var result []StyledRune
 	for _, token := range iterator.Tokens() {
-		style := tokenToStyle(token.Type)
+		style := tokenStyle(token.Type)
 		for _, r := range token.Value {
 			if r != '\n' {
 				result = append(result, StyledRune{
@@ -7,6 +7,32 @@ import (
 	"github.com/gdamore/tcell/v2"
 )
 
+// Token is a lexed token on one line. Tokens spanning lines are split and
+// whitespace is left out.
+type Token struct {
+	Type chroma.TokenType
+	Col  int // rune column the token starts at
+	Text string
+}
+
+// StyleFunc returns the style a token type is drawn with
+type StyleFunc func(chroma.TokenType) tcell.Style
+
+var tokenStyle StyleFunc = tokenToStyle
+
+// SetStyle changes how tokens are drawn; nil restores the built-in colors
+func SetStyle(f StyleFunc) {
+	if f == nil {
+		f = tokenToStyle
+	}
+	tokenStyle = f
+}
+
+// DefaultStyle returns the built-in style of a token type
+func DefaultStyle(t chroma.TokenType) tcell.Style {
+	return tokenToStyle(t)
+}
+
 func tokenToStyle(tokenType chroma.TokenType) tcell.Style {
 	base := tcell.StyleDefault
 	typeStr := tokenType.String()
@@ -180,3 +206,9 @@ func isError(t chroma.TokenType, s string) bool {
 	return t == chroma.Error ||
 		strings.Contains(s, "Error")
 }
+
+// isPlain reports whether a token is plain text rather than part of a
+// string, comment or other construct that can span lines
+func isPlain(t chroma.TokenType) bool {
+	return t == chroma.Text || t == chroma.TextWhitespace
+}